@@ -85,7 +85,9 @@ var testCheckCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		custom.ProcessFoundChecks(checkFile)
+		if err := custom.ProcessFoundChecks(checkFile); err != nil {
+			return err
+		}
 		for _, passTest := range passTests {
 			results, err := scanTestFile(passTest)
 			if err != nil {
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aquasecurity/tfsec/internal/pkg/catalogue"
+	"github.com/spf13/cobra"
+)
+
+var changelogFormat string
+
+func init() {
+	changelogCmd.Flags().StringVarP(&changelogFormat, "format", "f", "markdown", "Select output format: markdown, json")
+	rootCmd.AddCommand(changelogCmd)
+}
+
+// generateExport writes the metadata of every check as JSON or CSV, for other tools to import
+func generateExport(fileContents []*FileContent, format string) error {
+	var checks []catalogue.Rule
+	for _, contents := range fileContents {
+		checks = append(checks, contents.Checks...)
+	}
+	catalogue.Sort(checks)
+
+	w := io.Writer(os.Stdout)
+	if outputPath != "" {
+		f, err := os.Create(outputPath)
+		if err != nil {
+			return fmt.Errorf("failed to create export file: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+
+	if format == "csv" {
+		return catalogue.WriteCSV(w, checks)
+	}
+	return catalogue.WriteJSON(w, checks)
+}
+
+var changelogCmd = &cobra.Command{
+	Use:   "changelog <old-export> <new-export>",
+	Short: "Show the checks which were added, removed or changed severity between two exports",
+	Long: `Show the checks which were added, removed or changed severity between two exports made with --format json or --format csv,
+such as those made before and after a tfsec upgrade. Files with the .csv extension are read as CSV, and others as JSON.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		before, err := catalogue.ReadExport(args[0])
+		if err != nil {
+			return err
+		}
+		after, err := catalogue.ReadExport(args[1])
+		if err != nil {
+			return err
+		}
+		changelog := catalogue.Diff(before, after)
+
+		switch strings.ToLower(changelogFormat) {
+		case "markdown", "md":
+			printChangelogMarkdown(cmd.OutOrStdout(), changelog)
+		case "json":
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "\t")
+			return encoder.Encode(changelog)
+		default:
+			return fmt.Errorf("invalid format specified: '%s' - should be one of markdown, json", changelogFormat)
+		}
+		return nil
+	},
+}
+
+func printChangelogMarkdown(w io.Writer, changelog *catalogue.Changelog) {
+	_, _ = fmt.Fprintf(w, "# Checks changelog\n\n")
+	if changelog.Empty() {
+		_, _ = fmt.Fprintf(w, "No checks were added, removed or changed severity.\n")
+		return
+	}
+	printChangelogTable(w, "Added", changelog.Added)
+	printChangelogTable(w, "Removed", changelog.Removed)
+
+	if len(changelog.SeverityChanges) > 0 {
+		_, _ = fmt.Fprintf(w, "## Severity changed: %d check(s)\n\n", len(changelog.SeverityChanges))
+		_, _ = fmt.Fprintf(w, "| ID | Severity | Previous Severity | Summary |\n")
+		_, _ = fmt.Fprintf(w, "|----|----------|-------------------|---------|\n")
+		for _, change := range changelog.SeverityChanges {
+			_, _ = fmt.Fprintf(w, "| `%s` | *%s* | *%s* | %s |\n", change.Rule.ID, change.Rule.Severity, change.PreviousSeverity, change.Rule.Summary)
+		}
+		_, _ = fmt.Fprint(w, "\n")
+	}
+}
+
+func printChangelogTable(w io.Writer, title string, checks []catalogue.Rule) {
+	if len(checks) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "## %s: %d check(s)\n\n", title, len(checks))
+	_, _ = fmt.Fprintf(w, "| ID | Severity | Summary |\n")
+	_, _ = fmt.Fprintf(w, "|----|----------|---------|\n")
+	for _, check := range checks {
+		_, _ = fmt.Fprintf(w, "| `%s` | *%s* | %s |\n", check.ID, check.Severity, check.Summary)
+	}
+	_, _ = fmt.Fprint(w, "\n")
+}
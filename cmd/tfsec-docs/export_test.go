@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/aquasecurity/tfsec/internal/pkg/catalogue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GenerateExport(t *testing.T) {
+	original := outputPath
+	t.Cleanup(func() { outputPath = original })
+
+	for _, format := range []string{"json", "csv"} {
+		outputPath = filepath.Join(t.TempDir(), "rules."+format)
+		require.NoError(t, generateExport(testFileContents, format))
+
+		rules, err := catalogue.ReadExport(outputPath)
+		require.NoError(t, err, format)
+		require.Len(t, rules, 2, format)
+		assert.Equal(t, "custom-custom-doc001", rules[0].ID)
+		assert.Equal(t, "MEDIUM", rules[0].Severity)
+		assert.Contains(t, rules[0].BadExample, `resource "aws_s3_bucket" "bad"`)
+		assert.Equal(t, "custom.buckets.named", rules[1].ID)
+	}
+}
+
+func Test_PrintChangelogMarkdown(t *testing.T) {
+	changelog := catalogue.Diff(
+		[]catalogue.Rule{{ID: "aws-s3-a", Severity: "LOW", Summary: "A"}, {ID: "aws-s3-b", Severity: "LOW", Summary: "B"}},
+		[]catalogue.Rule{{ID: "aws-s3-a", Severity: "HIGH", Summary: "A"}, {ID: "aws-s3-c", Severity: "LOW", Summary: "C"}},
+	)
+
+	var buffer bytes.Buffer
+	printChangelogMarkdown(&buffer, changelog)
+	out := buffer.String()
+	assert.Contains(t, out, "## Added: 1 check(s)")
+	assert.Contains(t, out, "| `aws-s3-c` | *LOW* | C |")
+	assert.Contains(t, out, "## Removed: 1 check(s)")
+	assert.Contains(t, out, "| `aws-s3-b` | *LOW* | B |")
+	assert.Contains(t, out, "| `aws-s3-a` | *HIGH* | *LOW* | A |")
+
+	buffer.Reset()
+	printChangelogMarkdown(&buffer, catalogue.Diff(nil, nil))
+	assert.Contains(t, buffer.String(), "No checks were added, removed or changed severity.")
+}
@@ -6,18 +6,20 @@ import (
 	"sort"
 	"strings"
 	"text/template"
+
+	"github.com/aquasecurity/tfsec/internal/pkg/catalogue"
 )
 
 func generateIndexPages(fileContents []*FileContent) error {
 
-	provs := make(map[string]map[string][]templateObject)
+	provs := make(map[string]map[string][]catalogue.Rule)
 
 	for _, fc := range fileContents {
-		provServices := make(map[string][]templateObject)
+		provServices := make(map[string][]catalogue.Rule)
 		for _, c := range fc.Checks {
 
 			if _, ok := provServices[c.Service]; !ok {
-				provServices[c.Service] = make([]templateObject, 0)
+				provServices[c.Service] = make([]catalogue.Rule, 0)
 			}
 
 			provServices[c.Service] = append(provServices[c.Service], c)
@@ -42,7 +44,7 @@ func generateIndexPages(fileContents []*FileContent) error {
 			serviceIndexPath := filepath.Join(webPath, strings.ToLower(p), strings.ToLower(strings.ReplaceAll(s, " ", "-")), "index.md")
 
 			sort.Slice(checks, func(i, j int) bool {
-				return checks[i].ShortCode < checks[j].ShortCode
+				return pageName(checks[i]) < pageName(checks[j])
 			})
 			if err := writeTemplate(map[string]interface{}{
 				"DisplayName": s,
@@ -95,7 +97,7 @@ title: {{$.DisplayName}}
 ## Checks
 
 {{range $link := $.Checks}}
-- [{{.ShortCode}}]({{.ShortCode}}) {{.Summary}}
+- [{{PageName .}}]({{PageName .}}) {{.Summary}}
 {{end}}
 
 
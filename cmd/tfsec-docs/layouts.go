@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/aquasecurity/tfsec/internal/pkg/catalogue"
+)
+
+type providerSection struct {
+	Name     string
+	Services []serviceSection
+}
+
+type serviceSection struct {
+	Name   string
+	Checks []catalogue.Rule
+}
+
+// groupChecks groups the checks of each provider by service, for the layouts which show every check together
+func groupChecks(fileContents []*FileContent) []providerSection {
+	var sections []providerSection
+	for _, contents := range fileContents {
+		byService := make(map[string][]catalogue.Rule)
+		for _, check := range contents.Checks {
+			byService[check.Service] = append(byService[check.Service], check)
+		}
+		section := providerSection{Name: contents.Provider}
+		for service, checks := range byService {
+			section.Services = append(section.Services, serviceSection{Name: service, Checks: checks})
+		}
+		sort.Slice(section.Services, func(i, j int) bool {
+			return section.Services[i].Name < section.Services[j].Name
+		})
+		sections = append(sections, section)
+	}
+	return sections
+}
+
+// generateSinglePage writes every check into a single markdown file
+func generateSinglePage(fileContents []*FileContent) error {
+	if err := os.MkdirAll(webPath, os.ModePerm); err != nil {
+		return err
+	}
+	filePath := filepath.Join(webPath, "checks.md")
+	fmt.Printf("Generating single page at %s\n", filePath)
+	singleTmpl := template.Must(template.New("single").Funcs(funcMap).Parse(singlePageTemplate))
+	return writeTemplate(groupChecks(fileContents), filePath, singleTmpl)
+}
+
+// generateHTMLSite writes a static HTML site, with an index of every check and a page for each
+func generateHTMLSite(fileContents []*FileContent) error {
+	indexTmpl := htmltemplate.Must(htmltemplate.New("index").Funcs(htmlFuncMap).Parse(htmlIndexTemplate))
+	checkTmpl := htmltemplate.Must(htmltemplate.New("check").Funcs(htmlFuncMap).Parse(htmlCheckTemplate))
+
+	for _, contents := range fileContents {
+		for _, check := range contents.Checks {
+			filePath := filepath.Join(webPath, filepath.FromSlash(htmlPagePath(check)))
+			if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+				return err
+			}
+			fmt.Printf("Generating page for %s at %s\n", check.ID, filePath)
+			if err := writeTemplate(check, filePath, checkTmpl); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := os.MkdirAll(webPath, os.ModePerm); err != nil {
+		return err
+	}
+	indexPath := filepath.Join(webPath, "index.html")
+	fmt.Printf("Generating index page at %s\n", indexPath)
+	return writeTemplate(groupChecks(fileContents), indexPath, indexTmpl)
+}
+
+// htmlPagePath is the path of the page of a check, relative to the root of the HTML site
+func htmlPagePath(check catalogue.Rule) string {
+	return path.Join(strings.ToLower(check.Provider), strings.ToLower(check.Service), pageName(check)+".html")
+}
+
+var htmlFuncMap = htmltemplate.FuncMap{
+	"ToLower":            strings.ToLower,
+	"FormatProviderName": formatProviderName,
+	"PagePath":           htmlPagePath,
+	"TrimSpace":          strings.TrimSpace,
+}
+
+const singlePageTemplate = `---
+title: Checks
+---
+
+# Checks
+{{range $provider := .}}
+## {{FormatProviderName $provider.Name}}
+{{range $service := $provider.Services}}
+### {{$service.Name}}
+{{range $check := $service.Checks}}
+<a id="{{$check.ID}}"></a>
+#### {{$check.ID}}
+
+{{if $check.Summary}}{{$check.Summary}}
+
+{{end}}**Severity:** {{$check.Severity | ToLower}}{{if $check.LegacyIDs}} | **Legacy IDs:** {{range $i, $id := $check.LegacyIDs}}{{if $i}}, {{end}}{{$id}}{{end}}{{end}}
+{{if $check.Explanation}}
+{{$check.Explanation}}
+{{end}}{{if $check.Impact}}
+**Possible Impact:** {{$check.Impact}}
+{{end}}{{if $check.Resolution}}
+**Suggested Resolution:** {{$check.Resolution}}
+{{end}}{{if $check.BadExample}}
+Insecure example:
+
+` + "```terraform" + `
+{{TrimSpace $check.BadExample}}
+` + "```" + `
+{{end}}{{if $check.GoodExample}}
+Secure example:
+
+` + "```terraform" + `
+{{TrimSpace $check.GoodExample}}
+` + "```" + `
+{{end}}{{if $check.Links}}
+Links:
+{{range $link := $check.Links}}
+- [{{$link}}]({{$link}})
+{{- end}}
+{{end}}{{end}}{{end}}{{end}}`
+
+const htmlStyle = `<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 960px; margin: 2rem auto; padding: 0 1rem; color: #24292f; }
+a { color: #0969da; }
+pre { background: #f6f8fa; padding: 1rem; overflow-x: auto; }
+.severity { display: inline-block; min-width: 5rem; padding: 0 .4rem; border-radius: 3px; font-size: .8rem; text-align: center; color: #fff; background: #6e7781; }
+.severity.critical { background: #8b0000; }
+.severity.high { background: #cf222e; }
+.severity.medium { background: #bf8700; }
+.severity.low { background: #0969da; }
+li { margin: .25rem 0; }
+</style>`
+
+const htmlIndexTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Checks</title>
+` + htmlStyle + `
+</head>
+<body>
+<h1>Checks</h1>
+{{range $provider := .}}
+<h2>{{FormatProviderName $provider.Name}}</h2>
+{{range $service := $provider.Services}}
+<h3>{{$service.Name}}</h3>
+<ul>
+{{- range $check := $service.Checks}}
+<li><span class="severity {{$check.Severity | ToLower}}">{{$check.Severity | ToLower}}</span> <a href="{{PagePath $check}}">{{$check.ID}}</a> {{$check.Summary}}</li>
+{{- end}}
+</ul>
+{{end}}{{end}}
+</body>
+</html>
+`
+
+const htmlCheckTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.ID}}</title>
+` + htmlStyle + `
+</head>
+<body>
+<p><a href="../../index.html">All checks</a></p>
+<h1>{{if .Summary}}{{.Summary}}{{else}}{{.ID}}{{end}}</h1>
+<p><code>{{.ID}}</code> <span class="severity {{.Severity | ToLower}}">{{.Severity | ToLower}}</span></p>
+{{- if .LegacyIDs}}
+<p>Legacy IDs: {{range $i, $id := .LegacyIDs}}{{if $i}}, {{end}}<code>{{$id}}</code>{{end}}</p>
+{{- end}}
+{{- if .Explanation}}
+<h2>Explanation</h2>
+<p>{{.Explanation}}</p>
+{{- end}}
+{{- if .Impact}}
+<h2>Possible Impact</h2>
+<p>{{.Impact}}</p>
+{{- end}}
+{{- if .Resolution}}
+<h2>Suggested Resolution</h2>
+<p>{{.Resolution}}</p>
+{{- end}}
+{{- if .BadExample}}
+<h2>Insecure Example</h2>
+<p>The following example will fail the {{.ID}} check.</p>
+<pre><code>{{TrimSpace .BadExample}}</code></pre>
+{{- end}}
+{{- if .GoodExample}}
+<h2>Secure Example</h2>
+<p>The following example will pass the {{.ID}} check.</p>
+<pre><code>{{TrimSpace .GoodExample}}</code></pre>
+{{- end}}
+{{- if .Links}}
+<h2>Links</h2>
+<ul>
+{{- range .Links}}
+<li><a href="{{.}}" rel="nofollow noreferrer noopener">{{.}}</a></li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`
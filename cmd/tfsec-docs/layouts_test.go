@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aquasecurity/tfsec/internal/pkg/catalogue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFileContents = []*FileContent{
+	{
+		Provider: "custom",
+		Checks: []catalogue.Rule{
+			{
+				ID:          "custom-custom-doc001",
+				ShortCode:   "DOC001",
+				Source:      catalogue.SourceCustom,
+				Provider:    "custom",
+				Service:     "custom",
+				Severity:    "MEDIUM",
+				Summary:     "Buckets must have an owner tag",
+				Impact:      "Nobody knows who owns the bucket",
+				BadExample:  "resource \"aws_s3_bucket\" \"bad\" {\n}\n",
+				GoodExample: "resource \"aws_s3_bucket\" \"good\" {\n  tags = { owner = \"me\" }\n}\n",
+			},
+		},
+	},
+	{
+		Provider: "generic",
+		Checks: []catalogue.Rule{
+			{
+				ID:       "custom.buckets.named",
+				Source:   catalogue.SourceRego,
+				Provider: "generic",
+				Service:  "general",
+				Severity: "UNKNOWN",
+				Links:    []string{"https://example.com/buckets"},
+			},
+		},
+	},
+}
+
+func withWebPath(t *testing.T) string {
+	original := webPath
+	webPath = t.TempDir()
+	t.Cleanup(func() { webPath = original })
+	return webPath
+}
+
+func readFile(t *testing.T, path string) string {
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func Test_GenerateWebPages(t *testing.T) {
+	dir := withWebPath(t)
+	require.NoError(t, generateWebPages(testFileContents))
+	require.NoError(t, generateIndexPages(testFileContents))
+
+	page := readFile(t, filepath.Join(dir, "custom", "custom", "DOC001", "index.md"))
+	assert.Contains(t, page, "# Buckets must have an owner tag")
+	assert.Contains(t, page, `resource "aws_s3_bucket" "bad"`)
+
+	page = readFile(t, filepath.Join(dir, "generic", "general", "custom.buckets.named", "index.md"))
+	assert.Contains(t, page, "# custom.buckets.named")
+
+	index := readFile(t, filepath.Join(dir, "generic", "general", "index.md"))
+	assert.Contains(t, index, "[custom.buckets.named](custom.buckets.named)")
+}
+
+func Test_GenerateSinglePage(t *testing.T) {
+	dir := withWebPath(t)
+	require.NoError(t, generateSinglePage(testFileContents))
+
+	page := readFile(t, filepath.Join(dir, "checks.md"))
+	assert.Contains(t, page, "#### custom-custom-doc001")
+	assert.Contains(t, page, "**Severity:** medium")
+	assert.Contains(t, page, "**Possible Impact:** Nobody knows who owns the bucket")
+	assert.Contains(t, page, "tags = { owner = \"me\" }")
+	assert.Contains(t, page, "#### custom.buckets.named")
+	assert.Contains(t, page, "- [https://example.com/buckets](https://example.com/buckets)")
+}
+
+func Test_GenerateHTMLSite(t *testing.T) {
+	dir := withWebPath(t)
+	require.NoError(t, generateHTMLSite(testFileContents))
+
+	index := readFile(t, filepath.Join(dir, "index.html"))
+	assert.Contains(t, index, `href="custom/custom/DOC001.html"`)
+	assert.Contains(t, index, `href="generic/general/custom.buckets.named.html"`)
+
+	page := readFile(t, filepath.Join(dir, "custom", "custom", "DOC001.html"))
+	assert.Contains(t, page, "<h1>Buckets must have an owner tag</h1>")
+	assert.Contains(t, page, "resource &#34;aws_s3_bucket&#34; &#34;bad&#34;")
+}
@@ -6,24 +6,37 @@ import (
 	"sort"
 	"strings"
 
-	"github.com/aquasecurity/defsec/pkg/rules"
+	"github.com/aquasecurity/tfsec/internal/pkg/catalogue"
+	"github.com/aquasecurity/tfsec/internal/pkg/custom"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	projectRoot, _ = os.Getwd()
-	webPath        string
+	projectRoot, _  = os.Getwd()
+	webPath         string
+	layout          string
+	customCheckDirs []string
+	regoPolicyDirs  []string
+	includeBuiltin  bool
+	format          string
+	outputPath      string
 )
 
 type FileContent struct {
 	Provider string
-	Checks   []templateObject
+	Checks   []catalogue.Rule
 }
 
 func init() {
 	defaultWebDocsPath := fmt.Sprintf("%s/docs/checks", projectRoot)
 	rootCmd.Flags().StringVar(&webPath, "web-path", defaultWebDocsPath, "The path to generate web into, defaults to ./docs/checks")
+	rootCmd.Flags().StringVar(&layout, "layout", "pages", "Select the layout of the docs: pages (a markdown page for each check, with indexes), single (one markdown file) or html (a static HTML site)")
+	rootCmd.Flags().StringSliceVar(&customCheckDirs, "custom-check-dir", nil, "Directory to load custom checks to document from. Can be used multiple times")
+	rootCmd.Flags().StringSliceVar(&regoPolicyDirs, "rego-policy-dir", nil, "Directory to load rego policies to document from (recursively). Can be used multiple times")
+	rootCmd.Flags().BoolVar(&includeBuiltin, "builtin", true, "Document the built in checks. Use --builtin=false to document only custom checks and rego policies")
+	rootCmd.Flags().StringVarP(&format, "format", "f", "docs", "Select output format: docs (in the --layout), or json or csv to export the metadata of the checks")
+	rootCmd.Flags().StringVarP(&outputPath, "out", "O", "", "Set output file for json and csv exports. Defaults to stdout")
 }
 
 func main() {
@@ -39,54 +52,83 @@ var rootCmd = &cobra.Command{
 	Long:  `tfsec-docs generates the content for the root README and also can generate the missing base pages for the wiki`,
 	RunE: func(_ *cobra.Command, _ []string) error {
 
-		fileContents := getSortedFileContents()
-		if err := generateWebPages(fileContents); err != nil {
+		if err := loadCustomChecks(customCheckDirs); err != nil {
 			return err
 		}
+		fileContents, err := getSortedFileContents()
+		if err != nil {
+			return err
+		}
+
+		switch strings.ToLower(format) {
+		case "docs":
+		case "json", "csv":
+			return generateExport(fileContents, strings.ToLower(format))
+		default:
+			return fmt.Errorf("invalid format specified: '%s' - should be one of docs, json, csv", format)
+		}
 
-		return generateIndexPages(fileContents)
+		switch strings.ToLower(layout) {
+		case "pages":
+			if err := generateWebPages(fileContents); err != nil {
+				return err
+			}
+			return generateIndexPages(fileContents)
+		case "single":
+			return generateSinglePage(fileContents)
+		case "html":
+			return generateHTMLSite(fileContents)
+		default:
+			return fmt.Errorf("invalid layout specified: '%s' - should be one of pages, single, html", layout)
+		}
 	},
 }
 
-func getSortedFileContents() []*FileContent {
+func loadCustomChecks(dirs []string) error {
+	loader := custom.NewLoader()
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); err != nil {
+			return fmt.Errorf("failed to access custom check dir: %w", err)
+		}
+		if err := loader.Add(dir, dir); err != nil {
+			return fmt.Errorf("failed to load custom checks from %s: %w", dir, err)
+		}
+	}
+	return loader.Register()
+}
 
-	checkMap := make(map[string][]templateObject)
+func getSortedFileContents() ([]*FileContent, error) {
 
-	for _, r := range rules.GetRegistered() {
-		if r.Rule().Terraform == nil {
+	var checks []catalogue.Rule
+	for _, check := range catalogue.Registered() {
+		if check.Source == catalogue.SourceBuiltin && !includeBuiltin {
 			continue
 		}
-		provider := string(r.Rule().Provider)
-		checkMap[provider] = append(checkMap[provider], templateObject{
-			ID:          r.Rule().LongID(),
-			ShortCode:   r.Rule().ShortCode,
-			Severity:    strings.ToLower(string(r.Rule().Severity)),
-			Service:     r.Rule().Service,
-			Provider:    string(r.Rule().Provider),
-			Summary:     r.Rule().Summary,
-			Explanation: r.Rule().Explanation,
-			Impact:      r.Rule().Impact,
-			Resolution:  r.Rule().Resolution,
-			BadExample:  r.Rule().Terraform.BadExamples[0],
-			GoodExample: r.Rule().Terraform.GoodExamples[0],
-			Links:       append(r.Rule().Terraform.Links, r.Rule().Links...),
-		})
+		checks = append(checks, check)
+	}
+	for _, dir := range regoPolicyDirs {
+		regoChecks, err := catalogue.LoadRego(dir)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, regoChecks...)
+	}
+	catalogue.Sort(checks)
+
+	checkMap := make(map[string][]catalogue.Rule)
+	for _, check := range checks {
+		checkMap[check.Provider] = append(checkMap[check.Provider], check)
 	}
 
 	var fileContents []*FileContent
 	for provider := range checkMap {
-		checks := checkMap[provider]
-		sortChecks(checks)
 		fileContents = append(fileContents, &FileContent{
 			Provider: provider,
-			Checks:   checks,
+			Checks:   checkMap[provider],
 		})
 	}
-	return fileContents
-}
-
-func sortChecks(checks []templateObject) {
-	sort.Slice(checks, func(i, j int) bool {
-		return checks[i].ID < checks[j].ID
+	sort.Slice(fileContents, func(i, j int) bool {
+		return fileContents[i].Provider < fileContents[j].Provider
 	})
+	return fileContents, nil
 }
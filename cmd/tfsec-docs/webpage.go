@@ -2,30 +2,16 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"text/template"
 
 	"github.com/aquasecurity/defsec/pkg/providers"
+	"github.com/aquasecurity/tfsec/internal/pkg/catalogue"
 )
 
-type templateObject struct {
-	ID          string
-	ShortCode   string
-	LegacyID    string
-	Severity    string
-	Summary     string
-	Service     string
-	Provider    string
-	Explanation string
-	Impact      string
-	Resolution  string
-	BadExample  string
-	GoodExample string
-	Links       []string
-}
-
 func generateWebPages(fileContents []*FileContent) error {
 	for _, contents := range fileContents {
 		for _, check := range contents.Checks {
@@ -43,6 +29,8 @@ var funcMap = template.FuncMap{
 	"ToLower":            strings.ToLower,
 	"FormatProviderName": formatProviderName,
 	"Join":               join,
+	"PageName":           pageName,
+	"TrimSpace":          strings.TrimSpace,
 }
 
 func join(s []string) string {
@@ -52,6 +40,15 @@ func join(s []string) string {
 	return strings.Join(s[1:], s[0])
 }
 
+// pageName is the name of the page of a check within its service. Rego policies without a short code are named by
+// their package.
+func pageName(r catalogue.Rule) string {
+	if r.ShortCode == "" {
+		return r.ID
+	}
+	return r.ShortCode
+}
+
 func formatProviderName(providerName string) string {
 	if providerName == "digitalocean" {
 		providerName = "digital ocean"
@@ -59,12 +56,12 @@ func formatProviderName(providerName string) string {
 	return providers.Provider(providerName).DisplayName()
 }
 
-func generateWebPage(webProviderPath string, r templateObject) error {
+func generateWebPage(webProviderPath string, r catalogue.Rule) error {
 
 	if err := os.MkdirAll(webProviderPath, os.ModePerm); err != nil {
 		return err
 	}
-	filePath := filepath.Join(webProviderPath, r.ShortCode, "index.md")
+	filePath := filepath.Join(webProviderPath, pageName(r), "index.md")
 	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
 		return err
 	}
@@ -75,7 +72,12 @@ func generateWebPage(webProviderPath string, r templateObject) error {
 
 }
 
-func writeTemplate(contents interface{}, path string, tmpl *template.Template) error {
+// templateExecutor is satisfied by both text and html templates
+type templateExecutor interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+func writeTemplate(contents interface{}, path string, tmpl templateExecutor) error {
 	outputFile, err := os.Create(path)
 	if err != nil {
 		return err
@@ -85,12 +87,12 @@ func writeTemplate(contents interface{}, path string, tmpl *template.Template) e
 }
 
 const baseWebPageTemplate = `---
-title: {{$.Summary}}
+title: {{if $.Summary}}{{$.Summary}}{{else}}{{$.ID}}{{end}}
 ---
 
-# {{$.Summary}}
+# {{if $.Summary}}{{$.Summary}}{{else}}{{$.ID}}{{end}}
 
-### Default Severity: <span class="severity {{$.Severity | ToLower }}">{{$.Severity }}</span>
+### Default Severity: <span class="severity {{$.Severity | ToLower }}">{{$.Severity | ToLower }}</span>
 
 ### Explanation
 
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/severity"
+	"github.com/aquasecurity/tfsec/internal/pkg/compare"
+	"github.com/aquasecurity/tfsec/internal/pkg/report"
+	"github.com/liamg/tml"
+	"github.com/spf13/cobra"
+)
+
+var compareFormat string
+var compareThreshold string
+var compareMaxNew int
+var compareTrimPrefixes []string
+
+func compareCommand() *cobra.Command {
+	compareCmd := &cobra.Command{
+		Use:   "compare <old.json> <new.json>",
+		Short: "Compare two reports written with --format json, and show the new, fixed and unchanged findings",
+		Long: `Compare two reports written with --format json, and show the new, fixed and unchanged findings.
+
+Failed results are matched by a fingerprint of their check, resource and file, so findings which have only moved
+to different lines are unchanged. The command fails when the number of new findings at or above the --threshold
+severity is more than --max-new.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			threshold := severity.StringToSeverity(compareThreshold)
+			if threshold == severity.None {
+				return fmt.Errorf("'%s' is not a valid severity - should be one of CRITICAL, HIGH, MEDIUM, LOW", compareThreshold)
+			}
+
+			before, err := report.Load(args[0])
+			if err != nil {
+				return err
+			}
+			after, err := report.Load(args[1])
+			if err != nil {
+				return err
+			}
+			comparison := compare.Compare(before, after, compare.Options{TrimPrefixes: compareTrimPrefixes})
+
+			w := cmd.OutOrStdout()
+			if outputFlag != "" {
+				f, err := os.Create(outputFlag)
+				if err != nil {
+					return err
+				}
+				defer func() { _ = f.Close() }()
+				w = f
+			}
+
+			switch strings.ToLower(compareFormat) {
+			case "lovely", "default":
+				printComparisonLovely(w, args[0], args[1], comparison)
+			case "markdown":
+				printComparisonMarkdown(w, comparison)
+			case "json":
+				encoder := json.NewEncoder(w)
+				encoder.SetIndent("", "\t")
+				if err := encoder.Encode(struct {
+					OldReport string `json:"old_report"`
+					NewReport string `json:"new_report"`
+					*compare.Comparison
+				}{args[0], args[1], comparison}); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("invalid format specified: '%s'", compareFormat)
+			}
+
+			if count := comparison.CountNew(threshold); count > compareMaxNew {
+				_ = tml.Fprintf(cmd.ErrOrStderr(), "<red><bold>%d new finding(s) at or above %s, more than the %d allowed.\n", count, threshold, compareMaxNew)
+				return &ExitCodeError{code: 1}
+			}
+			return nil
+		},
+	}
+
+	compareCmd.Flags().StringVarP(&compareFormat, "format", "f", "lovely", "Select output format: lovely, markdown, json")
+	compareCmd.Flags().StringVarP(&outputFlag, "out", "O", "", "Write the comparison to this file instead of stdout")
+	compareCmd.Flags().StringVar(&compareThreshold, "threshold", "LOW", "The minimum severity of the new findings which fail the comparison. One of CRITICAL, HIGH, MEDIUM, LOW.")
+	compareCmd.Flags().IntVar(&compareMaxNew, "max-new", 0, "The number of new findings at or above --threshold which are allowed before the comparison fails")
+	compareCmd.Flags().StringSliceVar(&compareTrimPrefixes, "trim-prefix", nil, "Remove this prefix from the filenames in both reports before matching them, for reports of scans of different checkouts. Can be given more than once.")
+	compareCmd.Flags().BoolVar(&disableColours, "no-colour", false, "Disable coloured output")
+	compareCmd.Flags().BoolVar(&disableColours, "no-color", false, "Disable colored output (American style!)")
+	return compareCmd
+}
+
+func printComparisonLovely(w io.Writer, oldPath, newPath string, comparison *compare.Comparison) {
+	_ = tml.Fprintf(w, "\n  <bold>comparison</bold>\n  %s\n", strings.Repeat("─", 42))
+	_ = tml.Fprintf(w, "  <dim>%-20s</dim> %s\n", "old", oldPath)
+	_ = tml.Fprintf(w, "  <dim>%-20s</dim> %s\n\n", "new", newPath)
+
+	_ = tml.Fprintf(w, "  <bold>%-20s %6s %6s %7s</bold>\n", "severity", "old", "new", "change")
+	for _, delta := range comparison.Severities {
+		change := fmt.Sprintf("%7d", delta.Delta)
+		switch {
+		case delta.Delta > 0:
+			change = tml.Sprintf("<red>%7s</red>", fmt.Sprintf("+%d", delta.Delta))
+		case delta.Delta < 0:
+			change = tml.Sprintf("<green>%7d</green>", delta.Delta)
+		}
+		_ = tml.Fprintf(w, "  <dim>%-20s</dim> %6d %6d %s\n", delta.Severity, delta.Old, delta.New, change)
+	}
+
+	printComparisonSection(w, tml.Sprintf("<red>new</red>"), comparison.New)
+	printComparisonSection(w, tml.Sprintf("<green>fixed</green>"), comparison.Fixed)
+	printComparisonSection(w, "unchanged", comparison.Unchanged)
+
+	_ = tml.Fprintf(w, "\n  <bold>%d new, %d fixed, %d unchanged finding(s).\n\n", len(comparison.New), len(comparison.Fixed), len(comparison.Unchanged))
+}
+
+func printComparisonSection(w io.Writer, title string, findings []compare.Finding) {
+	if len(findings) == 0 {
+		return
+	}
+	_ = tml.Fprintf(w, "\n  <bold>%s (%d)</bold>\n  %s\n", title, len(findings), strings.Repeat("─", 42))
+	for _, finding := range findings {
+		var was string
+		if finding.PreviousSeverity != "" {
+			was = tml.Sprintf(" <dim>(was %s)</dim>", finding.PreviousSeverity)
+		}
+		_ = tml.Fprintf(w, "  %-8s %s  <dim>%s:%d</dim>  %s%s\n", finding.Result.Severity, finding.Result.LongID, finding.Path, finding.Result.Location.StartLine, finding.Result.Resource, was)
+	}
+}
+
+func printComparisonMarkdown(w io.Writer, comparison *compare.Comparison) {
+	_, _ = fmt.Fprintf(w, "# [tfsec] Comparison\n")
+	_, _ = fmt.Fprintf(w, "| Severity | Old | New | Change |\n")
+	_, _ = fmt.Fprintf(w, "|----------|-----|-----|--------|\n")
+	for _, delta := range comparison.Severities {
+		change := fmt.Sprintf("%d", delta.Delta)
+		if delta.Delta > 0 {
+			change = "+" + change
+		}
+		_, _ = fmt.Fprintf(w, "| *%s* | %d | %d | %s |\n", delta.Severity, delta.Old, delta.New, change)
+	}
+	_, _ = fmt.Fprint(w, "\n")
+
+	printComparisonTableMarkdown(w, "New", comparison.New)
+	printComparisonTableMarkdown(w, "Fixed", comparison.Fixed)
+	printComparisonTableMarkdown(w, "Unchanged", comparison.Unchanged)
+}
+
+func printComparisonTableMarkdown(w io.Writer, title string, findings []compare.Finding) {
+	if len(findings) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "## %s: %d finding(s)\n", title, len(findings))
+	_, _ = fmt.Fprintf(w, "| # | ID | Severity | Title | Location | Resource |\n")
+	_, _ = fmt.Fprintf(w, "|---|----|----------|-------|----------|----------|\n")
+	for i, finding := range findings {
+		sev := fmt.Sprintf("*%s*", finding.Result.Severity)
+		if finding.PreviousSeverity != "" {
+			sev = fmt.Sprintf("%s (was *%s*)", sev, finding.PreviousSeverity)
+		}
+		_, _ = fmt.Fprintf(
+			w,
+			"| %d | `%s` | %s | _%s_ | `%s:%d` | `%s` |\n",
+			i+1,
+			finding.Result.LongID,
+			sev,
+			finding.Result.RuleSummary,
+			finding.Path,
+			finding.Result.Location.StartLine,
+			finding.Result.Resource,
+		)
+	}
+	_, _ = fmt.Fprint(w, "\n")
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aquasecurity/tfsec/internal/pkg/compliance"
+	"github.com/aquasecurity/tfsec/internal/pkg/config"
+)
+
+// resolveFramework finds the compliance framework with the given ID, among the built in frameworks, those the
+// registered checks declare and those of the config
+func resolveFramework(resolved *config.Resolved, id string) (*compliance.Framework, error) {
+	var extra []compliance.Framework
+	if resolved != nil {
+		extra = resolved.Config.Frameworks
+	}
+	frameworks, err := compliance.Frameworks(extra...)
+	if err != nil {
+		return nil, err
+	}
+	fw, ok := compliance.Find(frameworks, id)
+	if !ok {
+		return nil, fmt.Errorf("unknown compliance framework '%s' - should be one of %s", id, strings.Join(compliance.IDs(frameworks), ", "))
+	}
+	if len(fw.Controls) == 0 {
+		return nil, fmt.Errorf("compliance framework '%s' has no controls which are covered by a check", id)
+	}
+	return &fw, nil
+}
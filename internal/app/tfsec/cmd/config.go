@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/aquasecurity/defsec/pkg/framework"
+	"github.com/aquasecurity/defsec/pkg/rules"
+	"github.com/aquasecurity/tfsec/internal/pkg/config"
+	"github.com/aquasecurity/tfsec/internal/pkg/legacy"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+func configCommand() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect tfsec configuration",
+	}
+
+	showCmd := &cobra.Command{
+		Use:   "show [directory]",
+		Short: "Print the effective config for a directory, and where each value came from",
+		Args:  cobra.RangeArgs(0, 1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := findDirectory(args)
+			if err != nil {
+				return err
+			}
+			resolved, err := resolveConfig(dir)
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			return printResolvedConfig(cmd.OutOrStdout(), dir, resolved)
+		},
+	}
+	showCmd.Flags().StringVar(&configFile, "config-file", "", "Config file to use during run")
+	showCmd.Flags().BoolVar(&noConfigInheritance, "no-config-inheritance", false, "Only use the config file in the scanned directory (or --config-file), rather than merging those found in parent and child directories")
+
+	validateCmd := &cobra.Command{
+		Use:   "validate [directory]",
+		Short: "Check the config files for a directory for errors, including references to rules which do not exist",
+		Args:  cobra.RangeArgs(0, 1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := findDirectory(args)
+			if err != nil {
+				return err
+			}
+			resolved, _ := resolveConfig(dir)
+			if err := loadCustomChecks(dir, resolved, nil); err != nil {
+				return err
+			}
+			if !validateConfigFiles(cmd.OutOrStdout(), configFilesFor(dir)) {
+				return fmt.Errorf("config validation failed")
+			}
+			return nil
+		},
+	}
+	validateCmd.Flags().StringVar(&configFile, "config-file", "", "Config file to use during run")
+	validateCmd.Flags().BoolVar(&noConfigInheritance, "no-config-inheritance", false, "Only use the config file in the scanned directory (or --config-file), rather than merging those found in parent and child directories")
+	validateCmd.Flags().StringSliceVar(&customCheckDirs, "custom-check-dir", nil, "Directory to load custom checks from, in addition to the .tfsec directory. Can be used multiple times")
+
+	configCmd.AddCommand(showCmd)
+	configCmd.AddCommand(validateCmd)
+	return configCmd
+}
+
+// configFilesFor returns every config file which would be used when scanning dir
+func configFilesFor(dir string) []string {
+	var paths []string
+	switch {
+	case !noConfigInheritance:
+		paths = config.DiscoverParents(dir)
+		children := config.DiscoverChildren(dir)
+		for _, childDir := range config.SortedDirs(children) {
+			paths = append(paths, children[childDir])
+		}
+	case configFile == "":
+		if path := config.FindConfigFile(dir); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	if configFile != "" {
+		paths = append(paths, configFile)
+	}
+	return paths
+}
+
+// validateConfigFiles reports any problems with each of the given config files, returning false if there were any
+func validateConfigFiles(w io.Writer, paths []string) bool {
+	if len(paths) == 0 {
+		_, _ = fmt.Fprintln(w, "No config files found.")
+		return true
+	}
+	known := knownRuleIDs()
+	valid := true
+	for _, path := range paths {
+		conf, err := config.LoadConfig(path)
+		if err != nil {
+			_, _ = fmt.Fprintf(w, "%s: %s\n", path, err)
+			valid = false
+			continue
+		}
+		problems := config.ValidateRuleIDs(conf, known)
+		for _, problem := range problems {
+			_, _ = fmt.Fprintf(w, "%s: %s\n", path, problem)
+		}
+		if len(problems) > 0 {
+			valid = false
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "%s: OK\n", path)
+	}
+	return valid
+}
+
+// knownRuleIDs returns every ID which can be used to refer to a registered rule in a config file
+func knownRuleIDs() []string {
+	var ids []string
+	for _, registered := range rules.GetRegistered(framework.ALL) {
+		rule := registered.Rule()
+		ids = append(ids, rule.LongID())
+		if rule.AVDID != "" {
+			ids = append(ids, rule.AVDID)
+		}
+		ids = append(ids, rule.Aliases...)
+	}
+	for legacyID := range legacy.IDs {
+		ids = append(ids, legacyID)
+	}
+	return ids
+}
+
+func printResolvedConfig(w io.Writer, dir string, resolved *config.Resolved) error {
+	_, _ = fmt.Fprintf(w, "# effective config for %s\n", dir)
+	if resolved == nil {
+		_, _ = fmt.Fprintln(w, "# no config files found")
+		return nil
+	}
+
+	data, err := yaml.Marshal(resolved.Config)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(w, "%s\n", data)
+
+	_, _ = fmt.Fprintln(w, "# sources:")
+	for _, key := range resolved.Keys() {
+		_, _ = fmt.Fprintf(w, "#   %s: %s\n", key, resolved.Source(key))
+	}
+
+	if !noConfigInheritance {
+		children := config.DiscoverChildren(dir)
+		if len(children) > 0 {
+			_, _ = fmt.Fprintln(w, "# scoped to subdirectories:")
+			for _, childDir := range config.SortedDirs(children) {
+				rel, err := filepath.Rel(dir, childDir)
+				if err != nil {
+					rel = childDir
+				}
+				_, _ = fmt.Fprintf(w, "#   %s: %s\n", rel, children[childDir])
+			}
+		}
+	}
+
+	return nil
+}
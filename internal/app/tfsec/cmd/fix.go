@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/aquasecurity/defsec/pkg/extrafs"
+	"github.com/aquasecurity/tfsec/internal/pkg/metrics"
+	"github.com/aquasecurity/tfsec/internal/pkg/remediation"
+	"github.com/liamg/tml"
+	"github.com/spf13/cobra"
+)
+
+func validateFixFlags() error {
+	if fixDryRun && !fix {
+		return fmt.Errorf("--dry-run can only be used with --fix")
+	}
+	if !fix {
+		return nil
+	}
+	if watch {
+		return fmt.Errorf("--fix cannot be combined with --watch")
+	}
+	if printRegoInput || regoInputOut != "" || regoEval != "" {
+		return fmt.Errorf("--fix cannot be combined with rego input flags")
+	}
+	return nil
+}
+
+// fixScan applies the available fixes for the failed results of run, and rescans the directory with the config and
+// custom checks of run to confirm that the fixed results are resolved. The report of what was changed is written to
+// stderr, so it does not mix with the output of the scan. With --dry-run, nothing is written and the original run is
+// returned.
+func fixScan(ctx context.Context, cmd *cobra.Command, dir string, run *scanRun) (*scanRun, error) {
+	plan, err := remediation.NewPlan(run.results, run.root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fix results: %w", err)
+	}
+
+	w := cmd.ErrOrStderr()
+	printFixPlan(w, dir, plan)
+
+	fixed := plan.Fixed()
+	if fixDryRun || len(fixed) == 0 {
+		return run, nil
+	}
+
+	if err := plan.Write(); err != nil {
+		return nil, fmt.Errorf("failed to write fixes: %w", err)
+	}
+	_ = tml.Fprintf(w, "Wrote fixes to %d file(s). Rescanning to confirm...\n", len(plan.Files()))
+
+	metrics.ClearSession()
+	rescan, err := run.prepared.scan(ctx, extrafs.OSDir(run.root))
+	if err != nil {
+		return nil, fmt.Errorf("rescan after fixing failed: %w", err)
+	}
+
+	remaining := failedFindings(rescan.results)
+	var unresolved []string
+	for _, change := range fixed {
+		if _, ok := remaining[findingKey(change.Result)]; ok {
+			unresolved = append(unresolved, describeFinding(change.Result))
+		}
+	}
+	if len(unresolved) == 0 {
+		_ = tml.Fprintf(w, "<green>All %d fixed result(s) are resolved.</green>\n\n", len(fixed))
+	} else {
+		_ = tml.Fprintf(w, "<yellow>%d fixed result(s) are still reported:</yellow>\n", len(unresolved))
+		for _, finding := range unresolved {
+			_ = tml.Fprintf(w, "  <yellow>!</yellow> %s\n", finding)
+		}
+		_, _ = fmt.Fprintln(w)
+	}
+	return rescan, nil
+}
+
+func printFixPlan(w io.Writer, dir string, plan *remediation.Plan) {
+	if len(plan.Changes) == 0 {
+		_ = tml.Fprintf(w, "No failed results to fix.\n")
+		return
+	}
+
+	verb := "Fixed"
+	if fixDryRun {
+		verb = "Would fix"
+	}
+	fixed := plan.Fixed()
+	_ = tml.Fprintf(w, "%s %d of %d failed result(s):\n", verb, len(fixed), len(plan.Changes))
+
+	for _, change := range plan.Changes {
+		location := fmt.Sprintf("%s:%d", relativePath(dir, change.Path), change.Result.Range().GetStartLine())
+		if !change.Fixed() {
+			_ = tml.Fprintf(w, "  <dim>-</dim> %s %s <dim>(%s)</dim>\n", change.Result.Rule().LongID(), location, change.Skipped)
+			continue
+		}
+		_ = tml.Fprintf(w, "  <green>✓</green> %s %s %s\n", change.Result.Rule().LongID(), location, change.Block)
+		for _, edit := range change.Edits {
+			_ = tml.Fprintf(w, "      %s\n", edit)
+		}
+	}
+	_, _ = fmt.Fprintln(w)
+}
+
+func relativePath(dir string, path string) string {
+	if rel, err := filepath.Rel(dir, path); err == nil {
+		return rel
+	}
+	return path
+}
@@ -1,19 +1,20 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
 	"github.com/aquasecurity/defsec/pkg/scanners/options"
+	"github.com/aquasecurity/tfsec/internal/pkg/bundle"
 	"github.com/aquasecurity/tfsec/internal/pkg/custom"
-	"github.com/google/uuid"
 
 	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/bmatcuk/doublestar"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -38,10 +39,17 @@ var excludeIgnoresIDs string
 var tfvarsPaths []string
 var excludePaths []string
 var outputFlag string
-var customCheckDir string
-var customCheckUrl string
+var customCheckDirs []string
+var customCheckUrls []string
 var configFile string
 var configFileUrl string
+var noConfigInheritance bool
+var policyBundle string
+var policyBundleSHA256 string
+var policyBundlePublicKey string
+var policyBundleSignature string
+var policyBundleCacheDir string
+var ignoreConfigErrors bool
 var conciseOutput bool
 var excludeDownloaded bool
 var includePassed bool
@@ -59,10 +67,22 @@ var minimumSeverity string
 var disableIgnores bool
 var regoPolicyDir string
 var printRegoInput bool
+var regoInputOut string
+var regoInputFilter string
+var regoEval string
 var noModuleDownloads bool
 var regoOnly bool
 var codeTheme string
 var noCode bool
+var watch bool
+var fix bool
+var fixDryRun bool
+var postTo string
+var complianceFramework string
+var metricsOut string
+var profileRules bool
+var profileTop int
+var cpuProfile string
 
 func configureFlags(cmd *cobra.Command) {
 	v := viper.New()
@@ -79,7 +99,7 @@ func configureFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVarP(&showVersion, "version", "v", false, "Show version information and exit")
 	cmd.Flags().BoolVar(&runUpdate, "update", false, "Update to latest version")
 	cmd.Flags().BoolVar(&migrateIgnores, "migrate-ignores", false, "Migrate ignore codes to the new ID structure")
-	cmd.Flags().StringVarP(&format, "format", "f", "lovely", "Select output format: lovely, json, csv, checkstyle, junit, sarif, text, markdown, html, gif. To use multiple formats, separate with a comma and specify a base output filename with --out. A file will be written for each type. The first format will additionally be written stdout.")
+	cmd.Flags().StringVarP(&format, "format", "f", "lovely", "Select output format: lovely, json, csv, checkstyle, junit, sarif, gitlab-codequality, gitlab-sast, review-json, text, markdown, html, gif, statistics-json, statistics-csv. To use multiple formats, separate with a comma and specify a base output filename with --out. A file will be written for each type. The first format will additionally be written stdout.")
 	cmd.Flags().StringVarP(&excludedRuleIDs, "exclude", "e", "", "Provide comma-separated list of rule IDs to exclude from run.")
 	cmd.Flags().StringVarP(&excludeIgnoresIDs, "exclude-ignores", "E", "", "Provide comma-separated list of ignored rule to exclude from run.")
 	cmd.Flags().StringVar(&filterResults, "filter-results", "", "Filter results to return specific checks only (supports comma-delimited input).")
@@ -88,11 +108,18 @@ func configureFlags(cmd *cobra.Command) {
 	cmd.Flags().StringSliceVar(&tfvarsPaths, "var-file", nil, "Path to .tfvars file, can be used multiple times and evaluated in order of specification (same functionality as --tfvars-file but consistent with Terraform)")
 	cmd.Flags().StringSliceVar(&excludePaths, "exclude-path", nil, "Folder path to exclude, can be used multiple times and evaluated in order of specification")
 	cmd.Flags().StringVarP(&outputFlag, "out", "O", "", "Set output file. This filename will have a format descriptor appended if multiple formats are specified with --format")
-	cmd.Flags().StringVar(&customCheckDir, "custom-check-dir", "", "Explicitly set the custom checks dir location")
-	cmd.Flags().StringVar(&customCheckUrl, "custom-check-url", "",
-		"Download a custom check file from a remote location. Must be json or yaml")
+	cmd.Flags().StringSliceVar(&customCheckDirs, "custom-check-dir", nil, "Directory to load custom checks from, in addition to the .tfsec directory. Can be used multiple times")
+	cmd.Flags().StringSliceVar(&customCheckUrls, "custom-check-url", nil,
+		"Download a custom check file from a remote location, in addition to the .tfsec directory. Must be json or yaml. Can be used multiple times")
 	cmd.Flags().StringVar(&configFile, "config-file", "", "Config file to use during run")
 	cmd.Flags().StringVar(&configFileUrl, "config-file-url", "", "Config file to download from a remote location. Must be json or yaml")
+	cmd.Flags().BoolVar(&noConfigInheritance, "no-config-inheritance", false, "Only use the config file in the scanned directory (or --config-file), rather than merging those found in parent and child directories")
+	cmd.Flags().StringVar(&policyBundle, "policy-bundle", "", "Policy bundle to load custom checks, rego policies and config from: the URL of a tarball, a local tarball, or an OCI layout directory")
+	cmd.Flags().StringVar(&policyBundleSHA256, "policy-bundle-sha256", "", "Expected sha256 digest of the policy bundle. Pinned bundles are cached for offline use")
+	cmd.Flags().StringVar(&policyBundlePublicKey, "policy-bundle-public-key", "", "PEM encoded public key to verify the policy bundle signature with")
+	cmd.Flags().StringVar(&policyBundleSignature, "policy-bundle-signature", "", "Path or URL of the policy bundle signature (defaults to the bundle location with a .sig suffix)")
+	cmd.Flags().StringVar(&policyBundleCacheDir, "policy-bundle-cache-dir", "", "Directory to cache downloaded policy bundles in (defaults to a tfsec directory in the user cache directory)")
+	cmd.Flags().BoolVar(&ignoreConfigErrors, "ignore-config-errors", false, "Warn about config files which fail to load and continue without them, rather than failing")
 	cmd.Flags().BoolVar(&debug, "debug", false, "Enable debug logging (same as verbose)")
 	cmd.Flags().BoolVar(&debug, "verbose", false, "Enable verbose logging (same as debug)")
 	cmd.Flags().BoolVar(&conciseOutput, "concise-output", false, "Reduce the amount of output and no statistics")
@@ -101,23 +128,35 @@ func configureFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&includeIgnored, "include-ignored", false, "Include ignored checks in the result output")
 	cmd.Flags().BoolVar(&disableIgnores, "no-ignores", false, "Do not apply any ignore rules - normally ignored checks will fail")
 	cmd.Flags().BoolVar(&allDirs, "force-all-dirs", false, "Don't search for tf files, include everything below provided directory.")
-	cmd.Flags().BoolVar(&runStatistics, "run-statistics", false, "View statistics table of current findings.")
+	cmd.Flags().BoolVar(&runStatistics, "run-statistics", false, "Show statistics of the failures by rule, severity, provider, service, file and module alongside the results, in the lovely, text and json formats.")
 	cmd.Flags().BoolVarP(&stopOnCheckError, "allow-checks-to-panic", "p", false, "Allow panics to propagate up from rule checking")
 	cmd.Flags().StringVarP(&workspace, "workspace", "w", "default", "Specify a workspace for ignore limits")
 	cmd.Flags().StringVarP(&minimumSeverity, "minimum-severity", "m", "", "The minimum severity to report. One of CRITICAL, HIGH, MEDIUM, LOW.")
 	cmd.Flags().StringVar(&regoPolicyDir, "rego-policy-dir", "", "Directory to load rego policies from (recursively).")
 	cmd.Flags().BoolVar(&printRegoInput, "print-rego-input", false, "Print a JSON representation of the input supplied to rego policies.")
+	cmd.Flags().StringVar(&regoInputOut, "rego-input-out", "", "Write a pretty-printed JSON representation of the input supplied to rego policies to this file.")
+	cmd.Flags().StringVar(&regoInputFilter, "rego-input-filter", "", "Restrict the rego input to the given providers or services (supports comma-delimited input), e.g. aws.s3,google")
+	cmd.Flags().StringVar(&regoEval, "rego-eval", "", "Evaluate an ad-hoc rego query against the rego input and print the result, e.g. 'input.aws.s3.buckets[_].name.value'")
 	cmd.Flags().BoolVar(&noModuleDownloads, "no-module-downloads", false, "Do not download remote modules.")
 	cmd.Flags().BoolVar(&regoOnly, "rego-only", false, "Run rego policies exclusively.")
 	cmd.Flags().StringVar(&codeTheme, "code-theme", "dark", "Theme for annotated code. Either 'light' or 'dark'.")
 	cmd.Flags().BoolVar(&noCode, "no-code", false, "Don't include the code snippets in the output.")
+	cmd.Flags().BoolVar(&watch, "watch", false, "Keep running, and rescan whenever terraform files, custom checks or rego policies change.")
+	cmd.Flags().BoolVar(&fix, "fix", false, "Apply the available fixes for failed results to the terraform files, then rescan to confirm they are resolved.")
+	cmd.Flags().BoolVar(&fixDryRun, "dry-run", false, "With --fix, report the fixes which would be made without changing any files.")
+	cmd.Flags().StringVar(&postTo, "post-to", "", "POST the failed results as review comments, in the review-json format, to this URL after the scan.")
+	cmd.Flags().StringVar(&complianceFramework, "compliance", "", "Only run the checks which cover the controls of a compliance framework, such as cis-aws-1.4, and report whether each control passed.")
+	cmd.Flags().StringVar(&metricsOut, "metrics-out", "", "Write the metrics of the scan, including the time spent running each rule other than rego policies, to this file: as JSON if it ends in .json, otherwise in the OpenMetrics text format.")
+	cmd.Flags().BoolVar(&profileRules, "profile-rules", false, "Time every rule, including custom checks, and show the slowest rules, and the slowest rules of each module, alongside the results in the lovely, text and json formats. Rego policies are run together by the scanner and are not timed.")
+	cmd.Flags().IntVar(&profileTop, "profile-top", 10, "The number of the slowest rules, and of the slowest rules of each module, shown by --profile-rules.")
+	cmd.Flags().StringVar(&cpuProfile, "cpu-profile", "", "Write a Go pprof CPU profile of the whole scan to this file.")
 
 	_ = cmd.Flags().MarkHidden("allow-checks-to-panic")
 
 	bindFlags(cmd, v)
 }
 
-// Bind each cobra flag to its associated viper configuration (config file and environment variable)
+// Bind each cobra flag to its associated environment variable - config file values are applied later by applyConfigToFlags
 func bindFlags(cmd *cobra.Command, v *viper.Viper) {
 	cmd.Flags().VisitAll(func(f *pflag.Flag) {
 		// Determine the naming convention of the flags when represented in the config file
@@ -180,7 +219,7 @@ func excludeFunc(excludePaths []string) func(results scan.Results) scan.Results
 	}
 }
 
-func configureOptions(cmd *cobra.Command, fsRoot, dir string) ([]options.ScannerOption, error) {
+func configureOptions(cmd *cobra.Command, fsRoot, dir string, resolved *config.Resolved, regoInput *regoInputCollector, policies *bundle.Bundle) ([]options.ScannerOption, error) {
 
 	var scannerOptions []options.ScannerOption
 	scannerOptions = append(
@@ -211,12 +250,12 @@ func configureOptions(cmd *cobra.Command, fsRoot, dir string) ([]options.Scanner
 		scannerOptions = append(scannerOptions, scanner.ScannerWithTFVarsPaths(fixedPaths...))
 	}
 
-	if regoPolicyDir != "" {
-		fixedPath, err := makePathRelativeToFSRoot(fsRoot, regoPolicyDir)
-		if err != nil {
-			return nil, fmt.Errorf("rego policy dir problem: %w", err)
-		}
-		scannerOptions = append(scannerOptions, options.ScannerWithPolicyDirs(fixedPath))
+	policyDirs, err := regoPolicyDirs(fsRoot, policies)
+	if err != nil {
+		return nil, err
+	}
+	if len(policyDirs) > 0 {
+		scannerOptions = append(scannerOptions, options.ScannerWithPolicyDirs(policyDirs...))
 	}
 
 	if disableIgnores {
@@ -248,14 +287,40 @@ func configureOptions(cmd *cobra.Command, fsRoot, dir string) ([]options.Scanner
 		scannerOptions = append(scannerOptions, options.ScannerWithDebug(cmd.ErrOrStderr()))
 	}
 
-	if printRegoInput {
+	if printRegoInput || regoInputOut != "" || regoEval != "" {
 		scannerOptions = append(scannerOptions, scanner.ScannerWithStateFunc(func(s *state.State) {
-			data, _ := json.Marshal(s.ToRego())
-			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "\n%s\n\n", string(data))
+			input := regoInput.Collect(s)
+			if printRegoInput {
+				data, _ := json.Marshal(input)
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "\n%s\n\n", string(data))
+			}
 		}))
 	}
 
-	return applyConfigFiles(scannerOptions, dir)
+	return applyConfigFiles(scannerOptions, fsRoot, dir, resolved, commandLineConfigFlags())
+}
+
+// regoPolicyDirs returns the directories to load rego policies from, relative to the root of the scanned filesystem
+func regoPolicyDirs(fsRoot string, policies *bundle.Bundle) ([]string, error) {
+	var policyDirs []string
+	if regoPolicyDir != "" {
+		fixedPath, err := makePathRelativeToFSRoot(fsRoot, regoPolicyDir)
+		if err != nil {
+			return nil, fmt.Errorf("rego policy dir problem: %w", err)
+		}
+		policyDirs = append(policyDirs, fixedPath)
+	}
+
+	if policies != nil {
+		if policyDir := policies.PolicyDir(); policyDir != "" {
+			fixedPath, err := makePathRelativeToFSRoot(fsRoot, policyDir)
+			if err != nil {
+				return nil, fmt.Errorf("policy bundle problem: %w", err)
+			}
+			policyDirs = append(policyDirs, fixedPath)
+		}
+	}
+	return policyDirs, nil
 }
 
 func explodeGlob(paths []string, root string, dir string) []string {
@@ -276,114 +341,407 @@ func explodeGlob(paths []string, root string, dir string) []string {
 	return exploded
 }
 
-func applyConfigFiles(options []options.ScannerOption, dir string) ([]options.ScannerOption, error) {
-	if configFileUrl != "" {
-		if remoteConfigDownloaded() {
-			defer func() { _ = os.Remove(configFile) }()
+// configFlags are the flags which applyConfigFiles combines with the settings from config files. Scans which are not
+// run from the command line, such as those of the scan server, leave them unset.
+type configFlags struct {
+	excludedRuleIDs     string
+	excludeIgnoresIDs   string
+	minimumSeverity     string
+	noConfigInheritance bool
+	ignoreConfigErrors  bool
+}
+
+func commandLineConfigFlags() configFlags {
+	return configFlags{
+		excludedRuleIDs:     excludedRuleIDs,
+		excludeIgnoresIDs:   excludeIgnoresIDs,
+		minimumSeverity:     minimumSeverity,
+		noConfigInheritance: noConfigInheritance,
+		ignoreConfigErrors:  ignoreConfigErrors,
+	}
+}
+
+func applyConfigFiles(options []options.ScannerOption, fsRoot, dir string, resolved *config.Resolved, flags configFlags) ([]options.ScannerOption, error) {
+	if resolved == nil {
+		return options, nil
+	}
+
+	for _, path := range resolved.Files {
+		logger.Log("Loaded config file at %s", path)
+	}
+
+	conf := resolved.Config
+	if !minVersionSatisfied(conf) {
+		return nil, fmt.Errorf("minimum tfsec version requirement not satisfied")
+	}
+	if len(conf.ExcludeIgnores) > 0 {
+		options = append(options, scanner.ScannerWithExcludeIgnores(append(conf.ExcludeIgnores, flags.excludeIgnoresIDs)))
+	}
+
+	var scopes []scopedConfig
+	if !flags.noConfigInheritance {
+		var err error
+		if scopes, err = resolveScopedConfigs(fsRoot, dir, conf, flags.ignoreConfigErrors); err != nil {
+			return nil, err
 		}
 	}
 
-	if configFile == "" {
-		configDir := filepath.Join(dir, ".tfsec")
-		for _, filename := range []string{"config.json", "config.yml", "config.yaml"} {
-			path := filepath.Join(configDir, filename)
-			if _, err := os.Stat(path); err == nil {
-				configFile = path
-				logger.Log("Found default config file at %s", configFile)
-				break
-			}
+	if len(scopes) > 0 {
+		// a config below dir can lower the minimum severity, or include or change the severity of more rules, for
+		// its own directory, which the scanner's filters would already have hidden, so each result is checked
+		// against the merged config for its own directory instead
+		options = append(options, scanner.ScannerWithResultsFilter(scopedConfigFunc(conf, scopes)))
+	} else {
+		if conf.MinimumSeverity != "" {
+			options = append(options, scanner.ScannerWithMinimumSeverity(severity.StringToSeverity(conf.MinimumSeverity)))
+		}
+		if len(conf.SeverityOverrides) > 0 {
+			options = append(options, scanner.ScannerWithSeverityOverrides(conf.SeverityOverrides))
+		}
+		if len(conf.IncludedChecks) > 0 {
+			options = append(options, scanner.ScannerWithIncludedRules(conf.IncludedChecks))
+		}
+		if len(conf.GetValidExcludedChecks()) > 0 {
+			options = append(options, scanner.ScannerWithExcludedRules(append(conf.GetValidExcludedChecks(), flags.excludedRuleIDs)))
 		}
 	}
 
-	if configFile != "" {
-		if conf, err := config.LoadConfig(configFile); err == nil {
-			logger.Log("Loaded config file at %s", configFile)
-			if !minVersionSatisfied(conf) {
-				return nil, fmt.Errorf("minimum tfsec version requirement not satisfied")
-			}
-			if conf.MinimumSeverity != "" {
-				options = append(options, scanner.ScannerWithMinimumSeverity(severity.StringToSeverity(conf.MinimumSeverity)))
+	// overrides enforce the minimum severity of the config they are in, merged with those of its parents
+	overrides, err := resolvePathOverrides(fsRoot, dir, conf.Overrides, flags.minimumSeverity, conf.MinimumSeverity)
+	if err != nil {
+		return nil, err
+	}
+	for _, scope := range scopes {
+		scopeOverrides, err := resolvePathOverrides(fsRoot, dir, scope.overrides, flags.minimumSeverity, scope.conf.MinimumSeverity)
+		if err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, scopeOverrides...)
+	}
+	if len(overrides) > 0 {
+		options = append(options, scanner.ScannerWithResultsFilter(pathOverrideFunc(overrides)))
+	}
+
+	return options, nil
+}
+
+// applyConfigToFlags sets flags from the config file, unless they were already set on the command line or
+// through an environment variable, giving a precedence of flag > env > file
+func applyConfigToFlags(cmd *cobra.Command, conf *config.Config) error {
+	values := map[string]interface{}{
+		"exclude-path":        conf.ExcludePaths,
+		"tfvars-file":         conf.TFVarsPaths,
+		"workspace":           conf.Workspace,
+		"rego-policy-dir":     conf.RegoPolicyDir,
+		"include-passed":      conf.IncludePassed,
+		"format":              conf.Format,
+		"out":                 conf.Out,
+		"no-module-downloads": conf.NoModuleDownloads,
+	}
+	for name, value := range values {
+		f := cmd.Flags().Lookup(name)
+		if f == nil || f.Changed {
+			continue
+		}
+		if name == "tfvars-file" && cmd.Flags().Changed("var-file") {
+			continue
+		}
+		var err error
+		switch v := value.(type) {
+		case string:
+			if v != "" {
+				err = f.Value.Set(v)
 			}
-			if len(conf.SeverityOverrides) > 0 {
-				options = append(options, scanner.ScannerWithSeverityOverrides(conf.SeverityOverrides))
+		case *bool:
+			if v != nil {
+				err = f.Value.Set(fmt.Sprintf("%t", *v))
 			}
-			if len(conf.IncludedChecks) > 0 {
-				options = append(options, scanner.ScannerWithIncludedRules(conf.IncludedChecks))
+		case []string:
+			if len(v) > 0 {
+				err = f.Value.(pflag.SliceValue).Replace(v)
 			}
-			if len(conf.GetValidExcludedChecks()) > 0 {
-				options = append(options, scanner.ScannerWithExcludedRules(append(conf.GetValidExcludedChecks(), excludedRuleIDs)))
+		}
+		if err != nil {
+			return fmt.Errorf("invalid config value for %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// resolveConfig merges the config files which apply to dir: the given base config files, then those found in dir
+// and its parents (unless inheritance is disabled), followed by any explicitly provided config file
+func resolveConfig(dir string, basePaths ...string) (*config.Resolved, error) {
+	paths := basePaths
+	switch {
+	case !noConfigInheritance:
+		paths = append(paths, config.DiscoverParents(dir)...)
+	case configFile == "":
+		if path := config.FindConfigFile(dir); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	if configFile != "" {
+		paths = append(paths, configFile)
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	return config.Resolve(paths...)
+}
+
+type scopedConfig struct {
+	dir       string
+	conf      *config.Config
+	overrides []config.PathOverride
+}
+
+// resolveScopedConfigs finds config files in directories below dir, each of which is merged on top of
+// the config of its parent directories and applied only to results within its own directory
+func resolveScopedConfigs(fsRoot, dir string, base *config.Config, ignoreErrors bool) ([]scopedConfig, error) {
+	children := config.DiscoverChildren(dir)
+	var scopes []scopedConfig
+	var scopeDirs []string
+	for _, childDir := range config.SortedDirs(children) {
+		conf, err := config.LoadConfig(children[childDir])
+		if err != nil {
+			if ignoreErrors {
+				logger.Log("Ignoring invalid config file: %s", err)
+				continue
 			}
-			if len(conf.ExcludeIgnores) > 0 {
-				options = append(options, scanner.ScannerWithExcludeIgnores(append(conf.ExcludeIgnores, excludeIgnoresIDs)))
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+		merged := config.Merge(base, conf)
+		for i, scopeDir := range scopeDirs {
+			if isWithinDir(childDir, scopeDir) {
+				merged = config.Merge(scopes[i].conf, conf)
 			}
-		} else {
-			logger.Log("Failed to load config file: %s", err)
 		}
+		rel, err := makePathRelativeToFSRoot(fsRoot, childDir)
+		if err != nil {
+			return nil, err
+		}
+		logger.Log("Loaded config file at %s for results in %s", children[childDir], rel)
+		scopeDirs = append(scopeDirs, childDir)
+		scopes = append(scopes, scopedConfig{
+			dir:       rel,
+			conf:      merged,
+			overrides: conf.Overrides,
+		})
 	}
+	return scopes, nil
+}
 
-	return configureCustomChecks(options, dir)
+func isWithinDir(path string, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
 }
 
-func configureCustomChecks(options []options.ScannerOption, dir string) ([]options.ScannerOption, error) {
-	if customCheckUrl != "" {
-		if remoteCustomCheckDownloaded() {
-			defer func() { _ = os.RemoveAll(customCheckDir) }()
+// scopedConfigFunc applies to each result the config of the deepest scope its file is in, or base when it is in none
+func scopedConfigFunc(base *config.Config, scopes []scopedConfig) func(results scan.Results) scan.Results {
+	return func(results scan.Results) scan.Results {
+		for i, result := range results {
+			conf := base
+			for _, scope := range scopes {
+				if isWithinDir(result.Range().GetFilename(), scope.dir) {
+					conf = scope.conf
+				}
+			}
+			results[i] = applyConfigToResult(result, conf)
 		}
+		return results
 	}
+}
 
-	if customCheckDir == "" {
-		customCheckDir = filepath.Join(dir, ".tfsec")
-	}
-	if err := custom.Load(customCheckDir); err != nil {
-		return nil, fmt.Errorf("failed to load custom checks from %s: %w", customCheckDir, err)
+type pathOverride struct {
+	glob        string
+	conf        *config.Config
+	attribution string
+}
+
+// resolvePathOverrides makes the globs of the given overrides relative to fsRoot, so they can be matched against
+// result filenames. Each override also enforces the highest of the given minimum severities, so that lowering the
+// severity of a result cannot bring it below the minimum severity of the scan.
+func resolvePathOverrides(fsRoot, dir string, overrides []config.PathOverride, minimumSeverities ...string) ([]pathOverride, error) {
+	var resolved []pathOverride
+	for _, override := range overrides {
+		glob, err := makePathRelativeToFSRoot(fsRoot, override.Glob())
+		if err != nil {
+			return nil, fmt.Errorf("override for '%s': %w", override.Path, err)
+		}
+		conf := override.Config()
+		for _, minimum := range minimumSeverities {
+			if config.SeverityOrdinal(severity.StringToSeverity(minimum)) > config.SeverityOrdinal(severity.StringToSeverity(conf.MinimumSeverity)) {
+				conf.MinimumSeverity = minimum
+			}
+		}
+		source := override.Source()
+		if rel, err := filepath.Rel(dir, source); err == nil && !strings.HasPrefix(rel, "..") {
+			source = rel
+		}
+		logger.Log("Loaded override for results in %s from %s", glob, override.Source())
+		resolved = append(resolved, pathOverride{
+			glob:        filepath.ToSlash(glob),
+			conf:        conf,
+			attribution: fmt.Sprintf("override for '%s' in %s", override.Path, source),
+		})
 	}
-	return options, nil
+	return resolved, nil
 }
 
-func remoteConfigDownloaded() bool {
-	tempFile := filepath.Join(os.TempDir(), filepath.Base(configFileUrl))
+// pathOverrideFunc applies each override, in order, to the results in files matching its glob. The description of
+// any result changed by an override is annotated with where the override came from.
+func pathOverrideFunc(overrides []pathOverride) func(results scan.Results) scan.Results {
+	return func(results scan.Results) scan.Results {
+		for i, result := range results {
+			filename := filepath.ToSlash(result.Range().GetFilename())
+			for _, override := range overrides {
+				if matched, err := doublestar.Match(override.glob, filename); err != nil || !matched {
+					continue
+				}
+				updated := applyConfigToResult(result, override.conf)
+				if updated.Status() != result.Status() || updated.Severity() != result.Severity() {
+					updated.OverrideDescription(fmt.Sprintf("%s (%s)", updated.Description(), override.attribution))
+				}
+				result = updated
+			}
+			results[i] = result
+		}
+		return results
+	}
+}
 
-	/* #nosec */
-	resp, err := http.Get(configFileUrl)
-	if err != nil || resp.StatusCode != http.StatusOK {
+func applyConfigToResult(result scan.Result, conf *config.Config) scan.Result {
+	ids := append([]string{result.Rule().LongID(), result.Rule().AVDID}, legacy.FindIDs(result.Rule().LongID())...)
+	hasID := func(list []string) bool {
+		for _, item := range list {
+			for _, id := range ids {
+				if item == id {
+					return true
+				}
+			}
+		}
 		return false
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	configContent, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return false
+	for id, sev := range conf.SeverityOverrides {
+		if hasID([]string{id}) {
+			rule := result.Rule()
+			rule.Severity = severity.Severity(sev)
+			overrides := scan.Results{result}
+			overrides.SetRule(rule)
+			result = overrides[0]
+		}
 	}
 
-	if err := os.WriteFile(tempFile, configContent, os.ModePerm); err != nil {
-		return false
+	if hasID(conf.GetValidExcludedChecks()) || (len(conf.IncludedChecks) > 0 && !hasID(conf.IncludedChecks)) {
+		result.OverrideStatus(scan.StatusIgnored)
 	}
-	configFile = tempFile
-	return true
+
+	if conf.MinimumSeverity != "" && config.SeverityOrdinal(result.Severity()) < config.SeverityOrdinal(severity.StringToSeverity(conf.MinimumSeverity)) {
+		result.OverrideStatus(scan.StatusIgnored)
+	}
+
+	return result
 }
 
-func remoteCustomCheckDownloaded() bool {
-	customTempDir, err := os.MkdirTemp(os.TempDir(), fmt.Sprintf("tfsec_custom_check_%s", uuid.NewString()))
+// loadCustomChecks loads and registers the custom checks from every source: the .tfsec directory of dir, the
+// --custom-check-dir and --custom-check-url flags, the custom check sources of the config, and the policy bundle.
+// Loading fails if two sources define a check with the same code.
+func loadCustomChecks(dir string, resolved *config.Resolved, policies *bundle.Bundle) error {
+	loader, err := customCheckLoader(dir, resolved, policies)
 	if err != nil {
-		return false
+		return err
 	}
-	tempFile := filepath.Join(customTempDir, filepath.Base(customCheckUrl))
+	if err := loader.Register(); err != nil {
+		return fmt.Errorf("failed to register custom checks: %w", err)
+	}
+	return nil
+}
 
-	/* #nosec */
-	resp, err := http.Get(customCheckUrl)
-	if err != nil || resp.StatusCode != http.StatusOK {
-		return false
+// customCheckLoader loads the custom checks from every source that loadCustomChecks does, without registering them
+func customCheckLoader(dir string, resolved *config.Resolved, policies *bundle.Bundle) (*custom.Loader, error) {
+	sources := append([]string{filepath.Join(dir, ".tfsec")}, customCheckDirs...)
+	sources = append(sources, customCheckUrls...)
+	sources = append(sources, configCustomCheckSources(resolved)...)
+
+	loader := custom.NewLoader()
+	if err := addCustomCheckSources(loader, sources); err != nil {
+		return nil, err
+	}
+	if policies != nil {
+		if err := loader.Add(policies.Source, policies.Dir); err != nil {
+			return nil, fmt.Errorf("failed to load custom checks from policy bundle: %w", err)
+		}
+	}
+	return loader, nil
+}
+
+// configCustomCheckSources returns the custom check directories and URLs configured in the resolved config
+func configCustomCheckSources(resolved *config.Resolved) []string {
+	if resolved == nil {
+		return nil
+	}
+	var sources []string
+	if resolved.Config.CustomCheckDir != "" {
+		sources = append(sources, resolved.Config.CustomCheckDir)
+	}
+	return append(sources, resolved.Config.CustomCheckSources...)
+}
+
+// addCustomCheckSources loads the custom checks from each source, a directory or the URL of a check file
+func addCustomCheckSources(loader *custom.Loader, sources []string) error {
+	for _, source := range sources {
+		checkDir := source
+		if strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://") {
+			tempDir, err := downloadCustomChecks(source)
+			if err != nil {
+				return fmt.Errorf("failed to download custom checks: %w", err)
+			}
+			defer func() { _ = os.RemoveAll(tempDir) }()
+			checkDir = tempDir
+		}
+		logger.Log("Loading custom checks from %s", source)
+		if err := loader.Add(source, checkDir); err != nil {
+			return fmt.Errorf("failed to load custom checks from %s: %w", source, err)
+		}
 	}
-	defer func() { _ = resp.Body.Close() }()
+	return nil
+}
 
-	customCheckContent, err := io.ReadAll(resp.Body)
+// downloadConfigFile downloads the config file from configFileUrl into a private temporary directory, and
+// returns the directory so it can be removed once the config has been loaded
+func downloadConfigFile() (string, error) {
+	tempDir, err := os.MkdirTemp("", "tfsec_config_")
 	if err != nil {
-		return false
+		return "", err
+	}
+	tempFile := filepath.Join(tempDir, path.Base(configFileUrl))
+	if err := downloadFile(configFileUrl, tempFile); err != nil {
+		_ = os.RemoveAll(tempDir)
+		return "", err
+	}
+	configFile = tempFile
+	return tempDir, nil
+}
+
+// downloadCustomChecks downloads the custom check file at url into a private temporary directory, and returns
+// the directory so the checks can be loaded from it
+func downloadCustomChecks(url string) (string, error) {
+	tempDir, err := os.MkdirTemp("", "tfsec_custom_check_")
+	if err != nil {
+		return "", err
 	}
+	if err := downloadFile(url, filepath.Join(tempDir, path.Base(url))); err != nil {
+		_ = os.RemoveAll(tempDir)
+		return "", err
+	}
+	return tempDir, nil
+}
 
-	if err := os.WriteFile(tempFile, customCheckContent, os.ModePerm); err != nil {
-		return false
+func downloadFile(url string, target string) error {
+	data, err := bundle.Download(context.TODO(), url)
+	if err != nil {
+		return err
 	}
-	customCheckDir = customTempDir
-	return true
+	return os.WriteFile(target, data, 0o600)
 }
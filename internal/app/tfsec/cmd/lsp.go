@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/tfsec/internal/pkg/lsp"
+	"github.com/aquasecurity/tfsec/internal/pkg/metrics"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func lspCommand() *cobra.Command {
+	lspCmd := &cobra.Command{
+		Use:   "lsp",
+		Short: "Run a language server over stdio which reports tfsec results as diagnostics in your editor",
+		Long: `Run a language server which speaks the Language Server Protocol over stdin and stdout.
+
+Results are published as diagnostics for each module as documents are opened, changed and saved. The scanning flags
+and config files apply in the same way as they do for a normal scan.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sources, err := loadRemoteSources()
+			if err != nil {
+				return err
+			}
+			defer sources.Close()
+
+			// config files apply to the flags for each scan, so each module starts from the flags as given
+			restoreFlags := snapshotFlags(cmd.Flags())
+
+			scanModule := func(ctx context.Context, dir string, documents map[string][]byte) (scan.Results, string, error) {
+				restoreFlags()
+				metrics.ClearSession()
+
+				// the custom checks are registered in a scope of their own for each scan, rather than for every
+				// scan, so a check removed from one module's checks does not linger, and checks in one module's
+				// .tfsec directory do not apply to other modules
+				prepared, err := prepareScan(cmd, dir, sources.baseConfigs, sources.policies, true)
+				if err != nil {
+					return nil, "", err
+				}
+				run, err := prepared.scan(ctx, lsp.OverlayFS(prepared.root, documents))
+				if err != nil {
+					return nil, "", err
+				}
+				return run.results, run.root, nil
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			server := lsp.NewServer(cmd.InOrStdin(), cmd.OutOrStdout(), scanModule, gatherLinks).WithLogger(cmd.ErrOrStderr())
+			return server.Run(ctx)
+		},
+	}
+
+	configureFlags(lspCmd)
+	return lspCmd
+}
+
+// snapshotFlags records the current values of the flags which were not set on the command line, and returns a
+// function which restores them
+func snapshotFlags(flags *pflag.FlagSet) func() {
+	values := make(map[*pflag.Flag]interface{})
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Changed {
+			return
+		}
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			values[f] = slice.GetSlice()
+		} else {
+			values[f] = f.Value.String()
+		}
+	})
+	return func() {
+		for f, value := range values {
+			switch v := value.(type) {
+			case []string:
+				_ = f.Value.(pflag.SliceValue).Replace(v)
+			case string:
+				_ = f.Value.Set(v)
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"strings"
+
+	scanner "github.com/aquasecurity/defsec/pkg/scanners/terraform"
+	"github.com/aquasecurity/tfsec/internal/pkg/metrics"
+)
+
+// writeMetrics writes the metrics of the scan to path, as JSON if it has the .json extension and in the OpenMetrics
+// text format otherwise
+func writeMetrics(path string, scanMetrics scanner.Metrics) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	exported := metrics.FromScan(scanMetrics)
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return exported.WriteJSON(f)
+	}
+	return exported.WriteOpenMetrics(f)
+}
+
+// rulesTimed returns whether each rule is timed, for the metrics file or the rule profile
+func rulesTimed() bool {
+	return metricsOut != "" || profileRules
+}
+
+// startCPUProfile starts writing a CPU profile of the process to path, returning the func which finishes it
+func startCPUProfile(path string) (func(), error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return func() {
+		pprof.StopCPUProfile()
+		_ = f.Close()
+	}, nil
+}
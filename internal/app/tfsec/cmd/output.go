@@ -12,19 +12,22 @@ import (
 	"github.com/aquasecurity/defsec/pkg/providers"
 	"github.com/aquasecurity/defsec/pkg/scan"
 	scanner "github.com/aquasecurity/defsec/pkg/scanners/terraform"
+	"github.com/aquasecurity/tfsec/internal/pkg/compliance"
 	"github.com/aquasecurity/tfsec/internal/pkg/formatter"
+	tfsecmetrics "github.com/aquasecurity/tfsec/internal/pkg/metrics"
+	"github.com/aquasecurity/tfsec/internal/pkg/statistics"
 	"github.com/aquasecurity/tfsec/version"
 	"github.com/liamg/tml"
 )
 
-func output(cmd *cobra.Command, baseFilename string, formats []string, fsRoot, dir string, results []scan.Result, metrics scanner.Metrics) error {
+func output(cmd *cobra.Command, baseFilename string, formats []string, fsRoot, dir string, results []scan.Result, metrics scanner.Metrics, opts outputOptions) error {
 	if baseFilename == "" && len(formats) > 1 {
 		return fmt.Errorf("you must specify a base output filename with --out if you want to use multiple formats")
 	}
 
 	var files []string
 	for _, format := range formats {
-		if filename, err := outputFormat(cmd.OutOrStdout(), len(formats) > 1, baseFilename, format, fsRoot, dir, results, metrics); err != nil {
+		if filename, err := outputFormat(cmd.OutOrStdout(), len(formats) > 1, baseFilename, format, fsRoot, dir, results, metrics, opts); err != nil {
 			return err
 		} else if filename != "" {
 			files = append(files, filename)
@@ -38,6 +41,35 @@ func output(cmd *cobra.Command, baseFilename string, formats []string, fsRoot, d
 	return nil
 }
 
+// outputOptions controls how results are rendered
+type outputOptions struct {
+	colours        bool
+	grouping       bool
+	concise        bool
+	includePassed  bool
+	includeIgnored bool
+	noCode         bool
+	codeTheme      string
+	// compliance is the report of the framework the scan was restricted to with --compliance, if any
+	compliance *compliance.Report
+	// statistics counts the failures of the scan, when they are shown alongside the results with --run-statistics
+	statistics *statistics.Report
+	// profile is the slowest rules of the scan, when they are shown alongside the results with --profile-rules
+	profile *tfsecmetrics.Profile
+}
+
+func outputOptionsFromFlags() outputOptions {
+	return outputOptions{
+		colours:        !disableColours,
+		grouping:       !disableGrouping,
+		concise:        conciseOutput,
+		includePassed:  includePassed,
+		includeIgnored: includeIgnored,
+		noCode:         noCode,
+		codeTheme:      codeTheme,
+	}
+}
+
 func gatherLinks(result scan.Result) []string {
 	v := "latest"
 	if version.Version != "" {
@@ -67,18 +99,18 @@ func gatherLinks(result scan.Result) []string {
 }
 
 // nolint
-func outputFormat(w io.Writer, addExtension bool, baseFilename, format, fsRoot, dir string, results scan.Results, metrics scanner.Metrics) (string, error) {
+func outputFormat(w io.Writer, addExtension bool, baseFilename, format, fsRoot, dir string, results scan.Results, metrics scanner.Metrics, opts outputOptions) (string, error) {
 
 	factory := formatters.New().
 		WithDebugEnabled(debug).
-		WithColoursEnabled(!disableColours).
-		WithGroupingEnabled(!disableGrouping).
+		WithColoursEnabled(opts.colours).
+		WithGroupingEnabled(opts.grouping).
 		WithLinksFunc(gatherLinks).
 		WithFSRoot(fsRoot).
 		WithBaseDir(dir).
-		WithMetricsEnabled(!conciseOutput).
-		WithIncludeIgnored(includeIgnored).
-		WithIncludePassed(includePassed)
+		WithMetricsEnabled(!opts.concise).
+		WithIncludeIgnored(opts.includeIgnored).
+		WithIncludePassed(opts.includePassed)
 
 	var alsoStdout bool
 	makeRelative := true
@@ -86,10 +118,14 @@ func outputFormat(w io.Writer, addExtension bool, baseFilename, format, fsRoot,
 	switch strings.ToLower(format) {
 	case "lovely", "default":
 		alsoStdout = true
-		factory.WithCustomFormatterFunc(formatter.DefaultWithMetrics(metrics, conciseOutput, codeTheme,
-			!disableColours, noCode))
+		factory.WithCustomFormatterFunc(formatter.DefaultWithMetrics(metrics, opts.concise, opts.codeTheme,
+			opts.colours, opts.noCode, opts.compliance, opts.statistics, opts.profile))
 	case "json":
-		factory.AsJSON()
+		if opts.compliance != nil || opts.statistics != nil || opts.profile != nil {
+			factory.WithCustomFormatterFunc(formatter.JSON(opts.compliance, opts.statistics, opts.profile))
+		} else {
+			factory.AsJSON()
+		}
 		makeRelative = false
 	case "csv":
 		factory.AsCSV()
@@ -98,15 +134,29 @@ func outputFormat(w io.Writer, addExtension bool, baseFilename, format, fsRoot,
 	case "junit":
 		factory.AsJUnit()
 	case "text":
-		factory.WithCustomFormatterFunc(formatter.DefaultWithMetrics(metrics, conciseOutput, codeTheme, !disableColours, false)).WithColoursEnabled(false)
+		factory.WithCustomFormatterFunc(formatter.DefaultWithMetrics(metrics, opts.concise, opts.codeTheme, opts.colours, false, opts.compliance, opts.statistics, opts.profile)).WithColoursEnabled(false)
 	case "sarif":
-		factory.AsSARIF()
+		factory.WithCustomFormatterFunc(formatter.SARIF(fsRoot))
+	case "gitlab-codequality":
+		factory.WithCustomFormatterFunc(formatter.GitLabCodeQuality())
+	case "gitlab-sast":
+		factory.WithCustomFormatterFunc(formatter.GitLabSAST(metrics))
+	case "review-json":
+		factory.WithCustomFormatterFunc(formatter.ReviewJSON(fsRoot))
 	case "gif":
-		factory.WithCustomFormatterFunc(formatter.GifWithMetrics(metrics, codeTheme, !disableColours))
+		factory.WithCustomFormatterFunc(formatter.GifWithMetrics(metrics, opts.codeTheme, opts.colours))
 	case "markdown":
 		factory.WithCustomFormatterFunc(formatter.Markdown())
 	case "html":
-		factory.WithCustomFormatterFunc(formatter.HTML())
+		factory.WithCustomFormatterFunc(formatter.HTML(metrics, opts.codeTheme, opts.compliance))
+	case "statistics-json":
+		factory.WithCustomFormatterFunc(func(b formatters.ConfigurableFormatter, results scan.Results) error {
+			return computeStatistics(results, fsRoot, dir).WriteJSON(b.Writer())
+		})
+	case "statistics-csv":
+		factory.WithCustomFormatterFunc(func(b formatters.ConfigurableFormatter, results scan.Results) error {
+			return computeStatistics(results, fsRoot, dir).WriteCSV(b.Writer())
+		})
 	default:
 		return "", fmt.Errorf("invalid format specified: '%s'", format)
 	}
@@ -138,6 +188,13 @@ func outputFormat(w io.Writer, addExtension bool, baseFilename, format, fsRoot,
 	return outputPath, factory.Build().Output(results)
 }
 
+// computeStatistics counts the failed results, naming files as the other formats do
+func computeStatistics(results scan.Results, fsRoot, dir string) *statistics.Report {
+	return statistics.Compute(results, func(result scan.Result) string {
+		return result.RelativePathTo(fsRoot, dir, result.Metadata())
+	})
+}
+
 func getExtensionForFormat(format string) string {
 	switch format {
 	case "sarif":
@@ -146,6 +203,17 @@ func getExtensionForFormat(format string) string {
 		return ".default.txt"
 	case "checkstyle":
 		return ".checkstyle.xml"
+	case "gitlab-codequality":
+		// the names GitLab's documentation uses for these reports, so they are recognisable as artifacts
+		return ".gl-code-quality-report.json"
+	case "gitlab-sast":
+		return ".gl-sast-report.json"
+	case "review-json":
+		return ".review.json"
+	case "statistics-json":
+		return ".statistics.json"
+	case "statistics-csv":
+		return ".statistics.csv"
 	default:
 		return fmt.Sprintf(".%s", format)
 	}
@@ -17,6 +17,13 @@ func prerun(cmd *cobra.Command, args []string) error {
 
 	cmd.SilenceUsage = true
 
+	// a subcommand takes precedence over a directory of the same name, which is only scanned when given as a path
+	if cmd.HasParent() && !cmd.Parent().HasParent() && len(args) == 0 {
+		if info, err := os.Stat(cmd.Name()); err == nil && info.IsDir() {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "WARNING: Running the %[1]s command rather than scanning the %[1]s directory - use 'tfsec ./%[1]s' or 'tfsec -- %[1]s' to scan it\n", cmd.Name())
+		}
+	}
+
 	// disable colour if running on windows - colour formatting doesn't work
 	if disableColours || (runtime.GOOS == "windows" && os.Getenv("TERM") == "") {
 		tml.DisableFormatting()
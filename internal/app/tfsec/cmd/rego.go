@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/state"
+	"github.com/open-policy-agent/opa/rego"
+)
+
+type regoInputCollector struct {
+	filters []string
+	inputs  []interface{}
+}
+
+func newRegoInputCollector(filter string) *regoInputCollector {
+	var filters []string
+	for _, f := range strings.Split(filter, ",") {
+		if f = strings.ToLower(strings.TrimSpace(f)); f != "" {
+			filters = append(filters, f)
+		}
+	}
+	return &regoInputCollector{
+		filters: filters,
+	}
+}
+
+// Collect records the rego input for a single root module, applying any provider/service filters
+func (c *regoInputCollector) Collect(s *state.State) interface{} {
+	input := filterRegoInput(s.ToRego(), c.filters)
+	c.inputs = append(c.inputs, input)
+	return input
+}
+
+// Input returns the collected input - a single object when one root module was scanned, otherwise a list
+func (c *regoInputCollector) Input() interface{} {
+	if len(c.inputs) == 1 {
+		return c.inputs[0]
+	}
+	return c.inputs
+}
+
+func (c *regoInputCollector) WriteFile(path string) error {
+	data, err := json.MarshalIndent(c.Input(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// Eval runs the query against the input of each scanned root module and writes the results as JSON
+func (c *regoInputCollector) Eval(ctx context.Context, w io.Writer, query string) error {
+	prepared, err := rego.New(rego.Query(query)).PrepareForEval(ctx)
+	if err != nil {
+		return fmt.Errorf("invalid rego query: %w", err)
+	}
+	var outputs []interface{}
+	for _, input := range c.inputs {
+		resultSet, err := prepared.Eval(ctx, rego.EvalInput(input))
+		if err != nil {
+			return fmt.Errorf("rego evaluation failed: %w", err)
+		}
+		var values []interface{}
+		for _, result := range resultSet {
+			for _, expression := range result.Expressions {
+				values = append(values, expression.Value)
+			}
+			if len(result.Bindings) > 0 {
+				values = append(values, result.Bindings)
+			}
+		}
+		outputs = append(outputs, values)
+	}
+	var output interface{} = outputs
+	if len(outputs) == 1 {
+		output = outputs[0]
+	}
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// filterRegoInput reduces the input to the given providers (e.g. "aws") or services (e.g. "aws.s3")
+func filterRegoInput(input interface{}, filters []string) interface{} {
+	if len(filters) == 0 {
+		return input
+	}
+	providers, ok := input.(map[string]interface{})
+	if !ok {
+		return input
+	}
+	filtered := make(map[string]interface{})
+	for _, filter := range filters {
+		providerName, serviceName, hasService := strings.Cut(filter, ".")
+		provider, ok := providers[providerName]
+		if !ok {
+			continue
+		}
+		if !hasService {
+			filtered[providerName] = provider
+			continue
+		}
+		services, ok := provider.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		service, ok := services[serviceName]
+		if !ok {
+			continue
+		}
+		existing, ok := filtered[providerName].(map[string]interface{})
+		if !ok {
+			existing = make(map[string]interface{})
+			filtered[providerName] = existing
+		}
+		existing[serviceName] = service
+	}
+	return filtered
+}
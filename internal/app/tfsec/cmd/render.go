@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/scan"
+	scanner "github.com/aquasecurity/defsec/pkg/scanners/terraform"
+	"github.com/aquasecurity/defsec/pkg/severity"
+	"github.com/aquasecurity/tfsec/internal/pkg/report"
+	"github.com/spf13/cobra"
+)
+
+var renderInput string
+
+func renderCommand() *cobra.Command {
+	renderCmd := &cobra.Command{
+		Use:   "render",
+		Short: "Write the results of a report written with --format json in other formats, without scanning again",
+		Long: `Write the results of a report written with --format json in other formats, without scanning again.
+
+Every result in the report is rendered, including any passed and ignored results it holds. Code snippets are read
+from the files named in the report, and are left out when those files are not there.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flat, err := report.Load(renderInput)
+			if err != nil {
+				return err
+			}
+
+			workingDir, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("could not determine current directory: %w", err)
+			}
+			root, rel, err := splitRoot(workingDir)
+			if err != nil {
+				return err
+			}
+			results := report.Rehydrate(flat, root, os.DirFS(root))
+
+			// the report holds exactly the results which should be rendered
+			includePassed = true
+			includeIgnored = true
+
+			formats := strings.Split(format, ",")
+			if err := output(cmd, outputFlag, formats, root, rel, results, renderMetrics(results), outputOptionsFromFlags()); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+			return nil
+		},
+	}
+
+	renderCmd.Flags().StringVarP(&renderInput, "input", "i", "", "The report written with --format json to render")
+	renderCmd.Flags().StringVarP(&format, "format", "f", "lovely", "Select output format: lovely, json, csv, checkstyle, junit, sarif, gitlab-codequality, gitlab-sast, review-json, text, markdown, html, gif. To use multiple formats, separate with a comma and specify a base output filename with --out. A file will be written for each type. The first format will additionally be written stdout.")
+	renderCmd.Flags().StringVarP(&outputFlag, "out", "O", "", "Set output file. This filename will have a format descriptor appended if multiple formats are specified with --format")
+	renderCmd.Flags().BoolVar(&disableColours, "no-colour", false, "Disable coloured output")
+	renderCmd.Flags().BoolVar(&disableColours, "no-color", false, "Disable colored output (American style!)")
+	renderCmd.Flags().BoolVarP(&disableGrouping, "disable-grouping", "G", false, "Disable grouping of similar results")
+	renderCmd.Flags().BoolVar(&conciseOutput, "concise-output", false, "Reduce the amount of output and no statistics")
+	renderCmd.Flags().StringVar(&codeTheme, "code-theme", "dark", "Theme for annotated code. Either 'light' or 'dark'.")
+	renderCmd.Flags().BoolVar(&noCode, "no-code", false, "Don't include the code snippets in the output.")
+	_ = renderCmd.MarkFlagRequired("input")
+	return renderCmd
+}
+
+// renderMetrics counts the results of a report. The timings and parser counts of the scan are not in the report,
+// so they are left empty.
+func renderMetrics(results scan.Results) scanner.Metrics {
+	var metrics scanner.Metrics
+	for _, result := range results {
+		switch result.Status() {
+		case scan.StatusPassed:
+			metrics.Executor.Counts.Passed++
+		case scan.StatusIgnored:
+			metrics.Executor.Counts.Ignored++
+		default:
+			switch result.Severity() {
+			case severity.Critical:
+				metrics.Executor.Counts.Critical++
+			case severity.High:
+				metrics.Executor.Counts.High++
+			case severity.Medium:
+				metrics.Executor.Counts.Medium++
+			case severity.Low:
+				metrics.Executor.Counts.Low++
+			}
+		}
+	}
+	return metrics
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/liamg/tml"
+	"github.com/spf13/cobra"
+)
+
+var reviewClient = &http.Client{
+	Timeout: 60 * time.Second,
+}
+
+func validatePostFlags() error {
+	if postTo == "" {
+		return nil
+	}
+	if watch {
+		return fmt.Errorf("--post-to cannot be combined with --watch")
+	}
+	if printRegoInput || regoEval != "" {
+		return fmt.Errorf("--post-to cannot be combined with rego input flags")
+	}
+	return nil
+}
+
+// postReview sends the failed results of run to url as review comments, in the review-json format
+func postReview(ctx context.Context, cmd *cobra.Command, url string, run *scanRun) error {
+	payload := bytes.NewBuffer(nil)
+	if _, err := outputFormat(payload, false, "", "review-json", run.root, run.rel, run.results, run.metrics, outputOptionsFromFlags()); err != nil {
+		return err
+	}
+	var review struct {
+		Comments []json.RawMessage `json:"comments"`
+	}
+	if err := json.Unmarshal(payload.Bytes(), &review); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := reviewClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %s from %s: %s", resp.Status, url, bytes.TrimSpace(body))
+	}
+
+	_ = tml.Fprintf(cmd.ErrOrStderr(), "<bold>%d review comment(s) posted to %s\n", len(review.Comments), url)
+	return nil
+}
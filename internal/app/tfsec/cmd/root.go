@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,9 +11,14 @@ import (
 	"github.com/Masterminds/semver"
 	debugging "github.com/aquasecurity/defsec/pkg/debug"
 	"github.com/aquasecurity/defsec/pkg/extrafs"
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/defsec/pkg/scanners/options"
 	scanner "github.com/aquasecurity/defsec/pkg/scanners/terraform"
-	"github.com/aquasecurity/defsec/pkg/scanners/terraform/executor"
+	"github.com/aquasecurity/tfsec/internal/pkg/bundle"
+	"github.com/aquasecurity/tfsec/internal/pkg/compliance"
 	"github.com/aquasecurity/tfsec/internal/pkg/config"
+	"github.com/aquasecurity/tfsec/internal/pkg/custom"
+	tfsecmetrics "github.com/aquasecurity/tfsec/internal/pkg/metrics"
 	"github.com/aquasecurity/tfsec/version"
 	"github.com/spf13/cobra"
 )
@@ -62,49 +68,77 @@ func Root() *cobra.Command {
 
 			logger.Log("Determined path dir=%s", dir)
 
-			if len(tfvarsPaths) == 0 && unusedTfvarsPresent(dir) {
-				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "WARNING: A tfvars file was found but not automatically used. Did you mean to specify the --tfvars-file flag?\n")
+			sources, err := loadRemoteSources()
+			if err != nil {
+				return err
 			}
+			defer sources.Close()
+			policies, baseConfigs := sources.policies, sources.baseConfigs
 
-			root, rel, err := splitRoot(dir)
-			if err != nil {
+			if err := validateFixFlags(); err != nil {
+				return err
+			}
+			if err := validatePostFlags(); err != nil {
 				return err
 			}
 
-			logger.Log("Determined path root=%s", root)
-			logger.Log("Determined path rel=%s", rel)
+			if watch {
+				return watchDirectory(cmd, dir, baseConfigs, policies)
+			}
 
-			options, err := configureOptions(cmd, root, dir)
-			if err != nil {
-				return fmt.Errorf("invalid option: %w", err)
+			if cpuProfile != "" {
+				stopProfile, err := startCPUProfile(cpuProfile)
+				if err != nil {
+					return fmt.Errorf("failed to start cpu profile: %w", err)
+				}
+				defer stopProfile()
 			}
 
-			scnr := scanner.New(options...)
-			results, metrics, err := scnr.ScanFSWithMetrics(context.TODO(), extrafs.OSDir(root), rel)
+			run, err := scanDirectory(context.TODO(), cmd, dir, baseConfigs, policies)
 			if err != nil {
-				return fmt.Errorf("scan failed: %w", err)
+				return err
 			}
 
-			if printRegoInput {
-				return nil
+			if fix {
+				if run, err = fixScan(context.TODO(), cmd, dir, run); err != nil {
+					return err
+				}
 			}
 
-			if runStatistics {
-				statistics := executor.Statistics{}
-				for _, result := range results {
-					statistics = executor.AddStatisticsCount(statistics, result)
+			if regoInputOut != "" {
+				if err := run.regoInput.WriteFile(regoInputOut); err != nil {
+					return fmt.Errorf("failed to write rego input: %w", err)
 				}
-				return statistics.PrintStatisticsTable(format, cmd.ErrOrStderr())
 			}
 
-			exitCode := getDetailedExitCode(metrics)
+			if regoEval != "" {
+				return run.regoInput.Eval(context.TODO(), cmd.OutOrStdout(), regoEval)
+			}
+
+			if printRegoInput {
+				return nil
+			}
+
+			exitCode := getDetailedExitCode(run.metrics)
 			logger.Log("Exit code based on results: %d", exitCode)
 
 			formats := strings.Split(format, ",")
-			if err := output(cmd, outputFlag, formats, root, rel, results, metrics); err != nil {
+			if err := output(cmd, outputFlag, formats, run.root, run.rel, run.results, run.metrics, run.outputOptions()); err != nil {
 				return fmt.Errorf("failed to write output: %w", err)
 			}
 
+			if metricsOut != "" {
+				if err := writeMetrics(metricsOut, run.metrics); err != nil {
+					return fmt.Errorf("failed to write metrics: %w", err)
+				}
+			}
+
+			if postTo != "" {
+				if err := postReview(context.TODO(), cmd, postTo, run); err != nil {
+					return fmt.Errorf("failed to post review comments: %w", err)
+				}
+			}
+
 			if exitCode != 0 && !softFail {
 				return &ExitCodeError{
 					code: exitCode,
@@ -115,10 +149,235 @@ func Root() *cobra.Command {
 		},
 	}
 
+	rootCmd.AddCommand(configCommand())
+	rootCmd.AddCommand(serveCommand())
+	rootCmd.AddCommand(lspCommand())
+	rootCmd.AddCommand(compareCommand())
+	rootCmd.AddCommand(renderCommand())
+	rootCmd.AddCommand(rulesCommand())
+	// subcommands share some of the flag variables, and registering their flags resets them to their defaults, so the
+	// root flags and the environment variables they are bound to are applied last
 	configureFlags(rootCmd)
 	return rootCmd
 }
 
+// remoteSources holds the config and policies downloaded for the --config-file-url and --policy-bundle flags
+type remoteSources struct {
+	configDir   string
+	policies    *bundle.Bundle
+	baseConfigs []string
+}
+
+// loadRemoteSources downloads the config file and policy bundle given by the flags, if any
+func loadRemoteSources() (*remoteSources, error) {
+	sources := &remoteSources{}
+
+	if configFileUrl != "" {
+		tempDir, err := downloadConfigFile()
+		if err != nil {
+			return nil, fmt.Errorf("failed to download config file: %w", err)
+		}
+		sources.configDir = tempDir
+	}
+
+	if policyBundle != "" {
+		if policyBundleCacheDir == "" {
+			policyBundleCacheDir = bundle.DefaultCacheDir()
+		}
+		policies, err := bundle.Fetch(context.TODO(), policyBundle, bundle.Options{
+			SHA256:    policyBundleSHA256,
+			PublicKey: policyBundlePublicKey,
+			Signature: policyBundleSignature,
+			CacheDir:  policyBundleCacheDir,
+		})
+		if err != nil {
+			sources.Close()
+			return nil, fmt.Errorf("failed to load policy bundle: %w", err)
+		}
+		logger.Log("Loaded policy bundle %s with digest sha256:%s", policies.Source, policies.Digest)
+		sources.policies = policies
+		if path := policies.ConfigFile(); path != "" {
+			sources.baseConfigs = append(sources.baseConfigs, path)
+		}
+	}
+
+	return sources, nil
+}
+
+// Close removes anything which was downloaded
+func (s *remoteSources) Close() {
+	if s.policies != nil {
+		_ = s.policies.Close()
+	}
+	if s.configDir != "" {
+		_ = os.RemoveAll(s.configDir)
+	}
+}
+
+// scanRun holds the outcome of a single scan of a directory
+type scanRun struct {
+	root      string
+	rel       string
+	results   scan.Results
+	metrics   scanner.Metrics
+	regoInput *regoInputCollector
+	// compliance is the outcome of each control of the framework given with --compliance, if any
+	compliance *compliance.Report
+	// prepared is the scan which produced the run, so the directory can be scanned again with the same config and
+	// custom checks
+	prepared *preparedScan
+}
+
+// outputOptions returns the output options of the flags, with the compliance report of the run, and its statistics
+// and the profile of its rules if they were asked for
+func (r *scanRun) outputOptions() outputOptions {
+	opts := outputOptionsFromFlags()
+	opts.compliance = r.compliance
+	if runStatistics {
+		opts.statistics = computeStatistics(r.results, r.root, r.rel)
+	}
+	if profileRules {
+		opts.profile = tfsecmetrics.RuleProfile(profileTop)
+	}
+	return opts
+}
+
+// scanDirectory resolves the config which applies to dir, configures a scanner from it and the flags, and scans dir
+func scanDirectory(ctx context.Context, cmd *cobra.Command, dir string, baseConfigs []string, policies *bundle.Bundle) (*scanRun, error) {
+	prepared, err := prepareScan(cmd, dir, baseConfigs, policies, false)
+	if err != nil {
+		return nil, err
+	}
+	return prepared.scan(ctx, extrafs.OSDir(prepared.root))
+}
+
+// preparedScan is a scanner configured for a directory, ready to scan it
+type preparedScan struct {
+	root      string
+	rel       string
+	options   []options.ScannerOption
+	regoInput *regoInputCollector
+	framework *compliance.Framework
+	// checks are the custom checks to register in a scope of its own for the scan, when they are scoped
+	checks *custom.Loader
+}
+
+// prepareScan resolves the config which applies to dir, and configures a scanner from it and the flags. The custom
+// checks are registered for every scan, unless scoped is set, in which case they only apply to the prepared scan.
+func prepareScan(cmd *cobra.Command, dir string, baseConfigs []string, policies *bundle.Bundle, scoped bool) (*preparedScan, error) {
+	resolved, err := resolveConfig(dir, baseConfigs...)
+	if err != nil {
+		if !ignoreConfigErrors {
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "WARNING: Ignoring invalid config: %s\n", err)
+	} else if resolved != nil {
+		if err := applyConfigToFlags(cmd, resolved.Config); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(tfvarsPaths) == 0 && unusedTfvarsPresent(dir) {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "WARNING: A tfvars file was found but not automatically used. Did you mean to specify the --tfvars-file flag?\n")
+	}
+
+	root, rel, err := splitRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Log("Determined path root=%s", root)
+	logger.Log("Determined path rel=%s", rel)
+
+	regoInput := newRegoInputCollector(regoInputFilter)
+	scannerOptions, err := configureOptions(cmd, root, dir, resolved, regoInput, policies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid option: %w", err)
+	}
+
+	checks, err := customCheckLoader(dir, resolved, policies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid option: %w", err)
+	}
+	if !scoped {
+		if err := checks.Register(); err != nil {
+			return nil, fmt.Errorf("invalid option: failed to register custom checks: %w", err)
+		}
+		checks = nil
+	}
+
+	if rulesTimed() {
+		tfsecmetrics.ClearSession()
+		scannerOptions = append(scannerOptions, options.ScannerWithFrameworks(tfsecmetrics.TimeRules()))
+	}
+
+	// resolved after configuring the scanner, so the frameworks custom checks declare are known
+	var framework *compliance.Framework
+	if complianceFramework != "" {
+		if framework, err = resolveFramework(resolved, complianceFramework); err != nil {
+			return nil, err
+		}
+		scannerOptions = append(scannerOptions, scanner.ScannerWithResultsFilter(compliance.Filter(*framework)))
+	}
+
+	if resolved != nil {
+		known := knownRuleIDs()
+		if checks != nil {
+			known = append(known, checks.LongIDs()...)
+		}
+		for _, problem := range config.ValidateRuleIDs(resolved.Config, known) {
+			if !ignoreConfigErrors {
+				return nil, fmt.Errorf("invalid config: %w", problem)
+			}
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "WARNING: Ignoring invalid config: %s\n", problem)
+		}
+	}
+
+	return &preparedScan{
+		root:      root,
+		rel:       rel,
+		options:   scannerOptions,
+		regoInput: regoInput,
+		framework: framework,
+		checks:    checks,
+	}, nil
+}
+
+// scan scans the prepared directory, reading files from fsys, which must be rooted at the prepared root
+func (p *preparedScan) scan(ctx context.Context, fsys fs.FS) (*scanRun, error) {
+	return p.scanDir(ctx, fsys, p.rel)
+}
+
+// scanDir scans rel, a directory of fsys at or below the prepared directory, with the prepared config
+func (p *preparedScan) scanDir(ctx context.Context, fsys fs.FS, rel string) (*scanRun, error) {
+	if p.checks != nil {
+		scope := custom.NewScope(fsys)
+		if err := p.checks.RegisterScoped(scope); err != nil {
+			return nil, fmt.Errorf("invalid option: failed to register custom checks: %w", err)
+		}
+		defer scope.Close()
+	}
+
+	scnr := scanner.New(p.options...)
+	results, metrics, err := scnr.ScanFSWithMetrics(ctx, fsys, rel)
+	if err != nil {
+		return nil, fmt.Errorf("scan failed: %w", err)
+	}
+
+	run := &scanRun{
+		root:      p.root,
+		rel:       p.rel,
+		results:   results,
+		metrics:   metrics,
+		regoInput: p.regoInput,
+		prepared:  p,
+	}
+	if p.framework != nil {
+		run.compliance = compliance.Evaluate(*p.framework, results)
+	}
+	return run, nil
+}
+
 func minVersionSatisfied(conf *config.Config) bool {
 
 	if conf.MinimumRequiredVersion == "" {
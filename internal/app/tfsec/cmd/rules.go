@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aquasecurity/tfsec/internal/pkg/catalogue"
+	"github.com/liamg/tml"
+	"github.com/spf13/cobra"
+)
+
+var rulesListFormat string
+var rulesExplainFormat string
+var rulesFilter catalogue.Filter
+
+func rulesCommand() *cobra.Command {
+	rulesCmd := &cobra.Command{
+		Use:   "rules",
+		Short: "List the available rules, and explain what they check",
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the built in rules, custom checks and rego policies, optionally filtered by provider, service and severity",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			rules, err := loadCatalogue()
+			if err != nil {
+				return err
+			}
+			rules = rulesFilter.Apply(rules)
+
+			switch strings.ToLower(rulesListFormat) {
+			case "table", "lovely", "default":
+				printRulesTable(cmd.OutOrStdout(), rules)
+			case "json":
+				if rules == nil {
+					rules = []catalogue.Rule{}
+				}
+				return writeRulesJSON(cmd.OutOrStdout(), rules)
+			default:
+				return fmt.Errorf("invalid format specified: '%s'", rulesListFormat)
+			}
+			return nil
+		},
+	}
+	listCmd.Flags().StringVarP(&rulesListFormat, "format", "f", "table", "Select output format: table, json")
+	listCmd.Flags().StringVar(&rulesFilter.Provider, "provider", "", "Only list the rules of this provider, such as aws")
+	listCmd.Flags().StringVar(&rulesFilter.Service, "service", "", "Only list the rules of this service, such as s3")
+	listCmd.Flags().StringVar(&rulesFilter.Severity, "severity", "", "Only list the rules of this severity. One of CRITICAL, HIGH, MEDIUM, LOW.")
+	addRuleSourceFlags(listCmd)
+
+	explainCmd := &cobra.Command{
+		Use:   "explain <id>",
+		Short: "Explain what a rule checks, and how to fix the problems it finds",
+		Long: `Explain what a rule checks, and how to fix the problems it finds.
+
+The rule can be given by its ID, its AVD ID or a legacy ID such as AWS017. Rego policies are identified by their package.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rules, err := loadCatalogue()
+			if err != nil {
+				return err
+			}
+			rule, ok := catalogue.Find(rules, args[0])
+			if !ok {
+				return fmt.Errorf("unknown rule '%s' - run 'tfsec rules list' to see the available rules", args[0])
+			}
+
+			switch strings.ToLower(rulesExplainFormat) {
+			case "lovely", "default":
+				printRuleExplanation(cmd.OutOrStdout(), rule)
+			case "json":
+				return writeRulesJSON(cmd.OutOrStdout(), rule)
+			default:
+				return fmt.Errorf("invalid format specified: '%s'", rulesExplainFormat)
+			}
+			return nil
+		},
+	}
+	explainCmd.Flags().StringVarP(&rulesExplainFormat, "format", "f", "lovely", "Select output format: lovely, json")
+	addRuleSourceFlags(explainCmd)
+
+	rulesCmd.AddCommand(listCmd)
+	rulesCmd.AddCommand(explainCmd)
+	return rulesCmd
+}
+
+func addRuleSourceFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&customCheckDirs, "custom-check-dir", nil, "Directory to load custom checks from, in addition to the .tfsec directory. Can be used multiple times")
+	cmd.Flags().StringVar(&regoPolicyDir, "rego-policy-dir", "", "Directory to load rego policies from (recursively).")
+	cmd.Flags().BoolVar(&disableColours, "no-colour", false, "Disable coloured output")
+	cmd.Flags().BoolVar(&disableColours, "no-color", false, "Disable colored output (American style!)")
+}
+
+// loadCatalogue returns the documentation of every rule a scan of the working directory could run: the built in
+// rules, the custom checks and the rego policies from the flags and the config files which apply to it
+func loadCatalogue() ([]catalogue.Rule, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("could not determine current directory: %w", err)
+	}
+	resolved, err := resolveConfig(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := loadCustomChecks(dir, resolved, nil); err != nil {
+		return nil, err
+	}
+	rules := catalogue.Registered()
+
+	policyDir := regoPolicyDir
+	if policyDir == "" && resolved != nil {
+		policyDir = resolved.Config.RegoPolicyDir
+	}
+	if policyDir != "" {
+		regoRules, err := catalogue.LoadRego(policyDir)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, regoRules...)
+		catalogue.Sort(rules)
+	}
+	return rules, nil
+}
+
+func writeRulesJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(v)
+}
+
+// ruleSeverityFormat colours severities as the lovely output does
+var ruleSeverityFormat = map[string]string{
+	"CRITICAL": "<bold><red>%s</red></bold>",
+	"HIGH":     "<red>%s</red>",
+	"MEDIUM":   "<yellow>%s</yellow>",
+	"LOW":      "<white>%s</white>",
+}
+
+func formatRuleSeverity(sev string, width int) string {
+	padded := fmt.Sprintf("%-*s", width, sev)
+	if format, ok := ruleSeverityFormat[sev]; ok {
+		return tml.Sprintf(format, padded)
+	}
+	return padded
+}
+
+func printRulesTable(w io.Writer, rules []catalogue.Rule) {
+	width := len("id")
+	for _, rule := range rules {
+		if len(rule.ID) > width {
+			width = len(rule.ID)
+		}
+	}
+
+	_ = tml.Fprintf(w, "\n  <bold>%-*s  %-9s %-8s %s</bold>\n", width, "id", "severity", "source", "summary")
+	for _, rule := range rules {
+		_ = tml.Fprintf(w, "  %-*s  %s <dim>%-8s</dim> %s\n", width, rule.ID, formatRuleSeverity(rule.Severity, 9), rule.Source, rule.Summary)
+	}
+	_ = tml.Fprintf(w, "\n  <bold>%d rule(s).\n\n", len(rules))
+}
+
+func printRuleExplanation(w io.Writer, rule catalogue.Rule) {
+	_ = tml.Fprintf(w, "\n  <bold>%s</bold>\n  %s\n", rule.ID, strings.Repeat("─", 42))
+	printRuleValue(w, "summary", rule.Summary)
+	printRuleValue(w, "severity", formatRuleSeverity(rule.Severity, 0))
+	printRuleValue(w, "provider", rule.Provider)
+	printRuleValue(w, "service", rule.Service)
+	printRuleValue(w, "source", string(rule.Source))
+	printRuleValue(w, "avd id", rule.AVDID)
+	printRuleValue(w, "legacy ids", strings.Join(rule.LegacyIDs, ", "))
+
+	printRuleSection(w, "explanation", rule.Explanation)
+	printRuleSection(w, "impact", rule.Impact)
+	printRuleSection(w, "resolution", rule.Resolution)
+	printRuleSection(w, "insecure example", indentCode(rule.BadExample))
+	printRuleSection(w, "secure example", indentCode(rule.GoodExample))
+
+	if len(rule.Links) > 0 {
+		_ = tml.Fprintf(w, "\n  <bold>links</bold>\n")
+		for _, link := range rule.Links {
+			_ = tml.Fprintf(w, "  - %s\n", link)
+		}
+	}
+	_, _ = fmt.Fprintln(w)
+}
+
+func printRuleValue(w io.Writer, key, value string) {
+	if value == "" {
+		return
+	}
+	_ = tml.Fprintf(w, "  <dim>%-20s</dim> %s\n", key, value)
+}
+
+func printRuleSection(w io.Writer, title, text string) {
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	_ = tml.Fprintf(w, "\n  <bold>%s</bold>\n", title)
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		_, _ = fmt.Fprintln(w, strings.TrimRight("  "+line, " \t"))
+	}
+}
+
+// indentCode removes the indentation the lines of an example share, and indents them all by two spaces instead
+func indentCode(code string) string {
+	lines := strings.Split(strings.TrimRight(code, " \t\n"), "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	margin := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if indent := len(line) - len(strings.TrimLeft(line, " \t")); margin < 0 || indent < margin {
+			margin = indent
+		}
+	}
+	for i, line := range lines {
+		if len(line) >= margin && margin > 0 {
+			line = line[margin:]
+		}
+		lines[i] = strings.TrimRight("  "+line, " \t")
+	}
+	return strings.Join(lines, "\n")
+}
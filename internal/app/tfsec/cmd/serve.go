@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func serveCommand() *cobra.Command {
+	var listen string
+	opts := serverOptions{}
+
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run an HTTP server which scans terraform on request, without starting a new process for each scan",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(opts.allowedPaths) == 0 {
+				workingDir, err := os.Getwd()
+				if err != nil {
+					return fmt.Errorf("could not determine current directory: %w", err)
+				}
+				opts.allowedPaths = []string{workingDir}
+			}
+
+			server, err := newScanServer(opts)
+			if err != nil {
+				return err
+			}
+
+			listener, err := net.Listen("tcp", listen)
+			if err != nil {
+				return fmt.Errorf("failed to listen on %s: %w", listen, err)
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			httpServer := &http.Server{
+				Handler:           server,
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), opts.scanTimeout)
+				defer cancel()
+				_ = httpServer.Shutdown(shutdownCtx)
+			}()
+
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Listening on http://%s\n", listener.Addr())
+			if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+	}
+
+	serveCmd.Flags().StringVar(&listen, "listen", "127.0.0.1:8080", "Address to listen on")
+	serveCmd.Flags().IntVar(&opts.maxConcurrentScans, "max-concurrent-scans", runtime.NumCPU(), "Maximum number of scans to run at once")
+	serveCmd.Flags().IntVar(&opts.maxQueuedScans, "max-queued-scans", 100, "Maximum number of scans to wait for a free slot, after which requests are rejected with 503")
+	serveCmd.Flags().Int64Var(&opts.maxUploadSize, "max-upload-size", 100<<20, "Maximum size in bytes of a scan request, including any uploaded tarball")
+	serveCmd.Flags().IntVar(&opts.maxCustomChecks, "max-custom-checks", 1000, "Maximum number of distinct custom check definitions to register for requests over the life of the server, after which requests with new ones are rejected with 507")
+	serveCmd.Flags().DurationVar(&opts.scanTimeout, "scan-timeout", 5*time.Minute, "Maximum time a scan may take")
+	serveCmd.Flags().StringSliceVar(&opts.allowedPaths, "allow-path", nil, "Directory which may be scanned by path, along with everything below it. Can be used multiple times (default is the current directory)")
+	return serveCmd
+}
@@ -0,0 +1,528 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aquasecurity/defsec/pkg/extrafs"
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/defsec/pkg/scanners/options"
+	scanner "github.com/aquasecurity/defsec/pkg/scanners/terraform"
+
+	"github.com/aquasecurity/tfsec/internal/pkg/bundle"
+	"github.com/aquasecurity/tfsec/internal/pkg/config"
+	"github.com/aquasecurity/tfsec/internal/pkg/custom"
+	"github.com/aquasecurity/tfsec/internal/pkg/legacy"
+)
+
+type serverOptions struct {
+	maxConcurrentScans int
+	maxQueuedScans     int
+	maxUploadSize      int64
+	scanTimeout        time.Duration
+	allowedPaths       []string
+	maxCustomChecks    int
+}
+
+// scanRequest is the body of a request to the scan endpoint. Scan settings which can also be set in a config file
+// are set through Config, which is merged on top of any config files in the scanned directory.
+type scanRequest struct {
+	Path                     string          `json:"path,omitempty"`
+	Format                   string          `json:"format,omitempty"`
+	Config                   *config.Config  `json:"config,omitempty"`
+	CustomChecks             []*custom.Check `json:"custom_checks,omitempty"`
+	IncludeIgnored           bool            `json:"include_ignored,omitempty"`
+	NoIgnores                bool            `json:"no_ignores,omitempty"`
+	ExcludeDownloadedModules bool            `json:"exclude_downloaded_modules,omitempty"`
+	ForceAllDirs             bool            `json:"force_all_dirs,omitempty"`
+	IgnoreHCLErrors          bool            `json:"ignore_hcl_errors,omitempty"`
+	RegoOnly                 bool            `json:"rego_only,omitempty"`
+	NoCode                   bool            `json:"no_code,omitempty"`
+}
+
+// httpError is an error which should be reported to the client with a particular status
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string {
+	return e.err.Error()
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &httpError{status: http.StatusBadRequest, err: fmt.Errorf(format, args...)}
+}
+
+// scanServer serves the scan API. Each scan has its own config and custom checks, so scans of different
+// directories can run at the same time.
+type scanServer struct {
+	opts    serverOptions
+	mux     *http.ServeMux
+	slots   chan struct{}
+	queued  atomic.Int64
+	running atomic.Int64
+	stats   serverStats
+	// rules bounds the custom checks which requests can add to the rule registry, which never shrinks
+	rules *custom.RuleLimit
+}
+
+type serverStats struct {
+	sync.Mutex
+	scans         map[string]int64
+	findings      map[scan.Status]int64
+	durationSum   float64
+	durationCount int64
+}
+
+func newScanServer(opts serverOptions) (*scanServer, error) {
+	if opts.maxConcurrentScans < 1 {
+		return nil, fmt.Errorf("--max-concurrent-scans must be at least 1")
+	}
+	var allowed []string
+	for _, path := range opts.allowedPaths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not determine absolute path for %s: %w", path, err)
+		}
+		allowed = append(allowed, abs)
+	}
+	opts.allowedPaths = allowed
+
+	s := &scanServer{
+		opts:  opts,
+		mux:   http.NewServeMux(),
+		slots: make(chan struct{}, opts.maxConcurrentScans),
+		rules: custom.NewRuleLimit(opts.maxCustomChecks),
+		stats: serverStats{
+			scans:    make(map[string]int64),
+			findings: make(map[scan.Status]int64),
+		},
+	}
+	s.mux.HandleFunc("POST /v1/scan", s.handleScan)
+	s.mux.HandleFunc("GET /healthz", s.handleHealth)
+	s.mux.HandleFunc("GET /metrics", s.handleMetrics)
+	return s, nil
+}
+
+func (s *scanServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *scanServer) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+func (s *scanServer) handleMetrics(w http.ResponseWriter, _ *http.Request) {
+	s.stats.Lock()
+	defer s.stats.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_, _ = fmt.Fprintf(w, "# HELP tfsec_scans_total Scan requests handled, by outcome.\n# TYPE tfsec_scans_total counter\n")
+	for _, outcome := range []string{"success", "error", "rejected"} {
+		_, _ = fmt.Fprintf(w, "tfsec_scans_total{outcome=%q} %d\n", outcome, s.stats.scans[outcome])
+	}
+	_, _ = fmt.Fprintf(w, "# HELP tfsec_scans_running Scans currently running.\n# TYPE tfsec_scans_running gauge\ntfsec_scans_running %d\n", s.running.Load())
+	_, _ = fmt.Fprintf(w, "# HELP tfsec_scans_queued Scans waiting for a free slot.\n# TYPE tfsec_scans_queued gauge\ntfsec_scans_queued %d\n", s.queued.Load())
+	_, _ = fmt.Fprintf(w, "# HELP tfsec_scan_duration_seconds Time taken by successful scans.\n# TYPE tfsec_scan_duration_seconds summary\n")
+	_, _ = fmt.Fprintf(w, "tfsec_scan_duration_seconds_sum %f\ntfsec_scan_duration_seconds_count %d\n", s.stats.durationSum, s.stats.durationCount)
+	_, _ = fmt.Fprintf(w, "# HELP tfsec_findings_total Results reported by successful scans, by status.\n# TYPE tfsec_findings_total counter\n")
+	for _, status := range []scan.Status{scan.StatusFailed, scan.StatusPassed, scan.StatusIgnored} {
+		_, _ = fmt.Fprintf(w, "tfsec_findings_total{status=%q} %d\n", statusName(status), s.stats.findings[status])
+	}
+}
+
+func statusName(status scan.Status) string {
+	switch status {
+	case scan.StatusPassed:
+		return "passed"
+	case scan.StatusIgnored:
+		return "ignored"
+	default:
+		return "failed"
+	}
+}
+
+func (s *scanServer) record(outcome string) {
+	s.stats.Lock()
+	defer s.stats.Unlock()
+	s.stats.scans[outcome]++
+}
+
+func (s *scanServer) recordScan(duration time.Duration, results scan.Results) {
+	s.stats.Lock()
+	defer s.stats.Unlock()
+	s.stats.scans["success"]++
+	s.stats.durationSum += duration.Seconds()
+	s.stats.durationCount++
+	for _, result := range results {
+		s.stats.findings[result.Status()]++
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+func (s *scanServer) handleScan(w http.ResponseWriter, r *http.Request) {
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		// every slot is in use, so wait for one unless too many other scans are already waiting
+		if s.queued.Add(1) > int64(s.opts.maxQueuedScans) {
+			s.queued.Add(-1)
+			s.record("rejected")
+			w.Header().Set("Retry-After", "1")
+			writeError(w, http.StatusServiceUnavailable, fmt.Errorf("too many scans are waiting, try again later"))
+			return
+		}
+		select {
+		case s.slots <- struct{}{}:
+			s.queued.Add(-1)
+		case <-r.Context().Done():
+			s.queued.Add(-1)
+			s.record("rejected")
+			return
+		}
+	}
+	s.running.Add(1)
+	defer func() {
+		s.running.Add(-1)
+		<-s.slots
+	}()
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.opts.maxUploadSize)
+	body, contentType, status, err := s.scan(r)
+	if err != nil {
+		s.record("error")
+		writeError(w, status, err)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write(body)
+}
+
+// scan handles a single scan request, returning the rendered results and their content type, or an error along with
+// the status to report it with
+func (s *scanServer) scan(r *http.Request) ([]byte, string, int, error) {
+	workDir, err := os.MkdirTemp("", "tfsec_serve_")
+	if err != nil {
+		return nil, "", http.StatusInternalServerError, err
+	}
+	defer func() { _ = os.RemoveAll(workDir) }()
+
+	req, dir, err := s.readRequest(r, workDir)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, "", http.StatusRequestEntityTooLarge, fmt.Errorf("request is larger than %d bytes", s.opts.maxUploadSize)
+		}
+		return nil, "", statusOf(err), err
+	}
+
+	format := req.Format
+	if format == "" {
+		format = "json"
+	}
+	contentType, ok := contentTypes[strings.ToLower(format)]
+	if !ok {
+		return nil, "", http.StatusBadRequest, fmt.Errorf("invalid format specified: '%s'", format)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.opts.scanTimeout)
+	defer cancel()
+
+	started := time.Now()
+	run, conf, err := serverScan(ctx, req, dir, workDir, s.rules)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, "", http.StatusGatewayTimeout, fmt.Errorf("scan did not complete within %s", s.opts.scanTimeout)
+		}
+		return nil, "", statusOf(err), err
+	}
+	s.recordScan(time.Since(started), run.results)
+
+	opts := outputOptions{
+		grouping:       true,
+		includePassed:  conf.IncludePassed != nil && *conf.IncludePassed,
+		includeIgnored: req.IncludeIgnored,
+		noCode:         req.NoCode,
+		codeTheme:      "dark",
+	}
+	buffer := bytes.NewBuffer(nil)
+	if _, err := outputFormat(buffer, false, "", format, run.root, run.rel, run.results, run.metrics, opts); err != nil {
+		return nil, "", http.StatusInternalServerError, fmt.Errorf("failed to write output: %w", err)
+	}
+	return buffer.Bytes(), contentType, http.StatusOK, nil
+}
+
+func statusOf(err error) int {
+	var httpErr *httpError
+	if errors.As(err, &httpErr) {
+		return httpErr.status
+	}
+	return http.StatusInternalServerError
+}
+
+var contentTypes = map[string]string{
+	"lovely":     "text/plain; charset=utf-8",
+	"default":    "text/plain; charset=utf-8",
+	"text":       "text/plain; charset=utf-8",
+	"json":       "application/json",
+	"sarif":      "application/sarif+json",
+	"csv":        "text/csv; charset=utf-8",
+	"checkstyle": "application/xml",
+	"junit":      "application/xml",
+	"markdown":   "text/markdown; charset=utf-8",
+	"html":       "text/html; charset=utf-8",
+	"gif":        "image/gif",
+
+	"gitlab-codequality": "application/json",
+	"gitlab-sast":        "application/json",
+	"review-json":        "application/json",
+}
+
+// readRequest parses a scan request, which is either JSON naming a path to scan, or a multipart form with the
+// request in a "request" field and a tarball to scan in a "tarball" field. Tarballs are extracted into workDir.
+// The directory to scan is returned along with the request.
+func (s *scanServer) readRequest(r *http.Request, workDir string) (*scanRequest, string, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, "", badRequest("invalid content type: %w", err)
+	}
+
+	req := &scanRequest{}
+	switch mediaType {
+	case "application/json":
+		if err := decodeScanRequest(r.Body, req); err != nil {
+			return nil, "", err
+		}
+		dir, err := s.allowedDir(req.Path)
+		if err != nil {
+			return nil, "", err
+		}
+		return req, dir, nil
+	case "multipart/form-data":
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			return nil, "", err
+		}
+		defer func() { _ = r.MultipartForm.RemoveAll() }()
+		if field := r.FormValue("request"); field != "" {
+			if err := decodeScanRequest(strings.NewReader(field), req); err != nil {
+				return nil, "", err
+			}
+		}
+		if req.Path != "" {
+			return nil, "", badRequest("path cannot be used when uploading a tarball")
+		}
+		file, _, err := r.FormFile("tarball")
+		if err != nil {
+			return nil, "", badRequest("a tarball must be uploaded in the 'tarball' field")
+		}
+		defer func() { _ = file.Close() }()
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return nil, "", err
+		}
+		dir := filepath.Join(workDir, "src")
+		if err := bundle.Extract(data, dir); err != nil {
+			return nil, "", badRequest("failed to extract tarball: %w", err)
+		}
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, "", err
+		}
+		return req, dir, nil
+	default:
+		return nil, "", &httpError{status: http.StatusUnsupportedMediaType, err: fmt.Errorf("unsupported content type '%s' - use application/json or multipart/form-data", mediaType)}
+	}
+}
+
+func decodeScanRequest(r io.Reader, req *scanRequest) error {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return err
+		}
+		return badRequest("invalid scan request: %w", err)
+	}
+	return nil
+}
+
+// allowedDir checks that path is a directory within one of the allowed paths, returning its absolute path
+func (s *scanServer) allowedDir(path string) (string, error) {
+	if path == "" {
+		return "", badRequest("a path to scan is required")
+	}
+	if !filepath.IsAbs(path) {
+		return "", badRequest("path must be absolute")
+	}
+	dir := filepath.Clean(path)
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	var allowed bool
+	for _, allowedPath := range s.opts.allowedPaths {
+		if resolved, err := filepath.EvalSymlinks(allowedPath); err == nil {
+			allowedPath = resolved
+		}
+		if isWithinDir(dir, allowedPath) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return "", &httpError{status: http.StatusForbidden, err: fmt.Errorf("scanning %s is not allowed", path)}
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", badRequest("%s is not a directory", path)
+	}
+	return dir, nil
+}
+
+// serverScan scans dir with the settings from a request. It mirrors configureOptions, but takes its settings from
+// the request rather than from the flags, and registers custom checks which only apply to this scan, within limit.
+func serverScan(ctx context.Context, req *scanRequest, dir string, workDir string, limit *custom.RuleLimit) (*scanRun, *config.Config, error) {
+	var paths []string
+	if req.Path != "" {
+		paths = config.DiscoverParents(dir)
+	} else if path := config.FindConfigFile(dir); path != "" {
+		paths = append(paths, path)
+	}
+	resolved, err := config.Resolve(paths...)
+	if err != nil {
+		return nil, nil, badRequest("invalid config: %w", err)
+	}
+	if req.Config != nil {
+		if req.Config.Out != "" || req.Config.Format != "" {
+			return nil, nil, badRequest("invalid config in request: use the format field of the request rather than format or out")
+		}
+		if err := config.Prepare(req.Config, "request", dir); err != nil {
+			return nil, nil, badRequest("invalid config in request: %w", err)
+		}
+		resolved.Add("request", req.Config)
+	}
+	conf := resolved.Config
+
+	root, rel, err := splitRoot(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	fsys := extrafs.OSDir(root)
+
+	workspaceName := conf.Workspace
+	if workspaceName == "" {
+		workspaceName = "default"
+	}
+	scannerOptions := []options.ScannerOption{
+		scanner.ScannerWithStopOnHCLError(!req.IgnoreHCLErrors),
+		scanner.ScannerWithSkipDownloaded(req.ExcludeDownloadedModules),
+		scanner.ScannerWithAllDirectories(req.ForceAllDirs),
+		scanner.ScannerWithWorkspaceName(workspaceName),
+		scanner.ScannerWithAlternativeIDProvider(legacy.FindIDs),
+		options.ScannerWithPolicyNamespaces("custom"),
+		scanner.ScannerWithDownloadsAllowed(conf.NoModuleDownloads == nil || !*conf.NoModuleDownloads),
+		options.ScannerWithRegoOnly(req.RegoOnly),
+		options.ScannerWithEmbeddedPolicies(true),
+	}
+
+	if len(conf.ExcludePaths) > 0 {
+		scannerOptions = append(scannerOptions, scanner.ScannerWithResultsFilter(excludeFunc(explodeGlob(conf.ExcludePaths, root, dir))))
+	}
+
+	if len(conf.TFVarsPaths) > 0 {
+		fixedPaths, err := makePathsRelativeToFSRoot(root, conf.TFVarsPaths)
+		if err != nil {
+			return nil, nil, badRequest("tfvars problem: %w", err)
+		}
+		scannerOptions = append(scannerOptions, scanner.ScannerWithTFVarsPaths(fixedPaths...))
+	}
+
+	if conf.RegoPolicyDir != "" {
+		fixedPath, err := makePathRelativeToFSRoot(root, conf.RegoPolicyDir)
+		if err != nil {
+			return nil, nil, badRequest("rego policy dir problem: %w", err)
+		}
+		scannerOptions = append(scannerOptions, options.ScannerWithPolicyDirs(fixedPath))
+	}
+
+	if req.NoIgnores {
+		scannerOptions = append(scannerOptions, scanner.ScannerWithNoIgnores())
+	}
+
+	scannerOptions, err = applyConfigFiles(scannerOptions, root, dir, resolved, configFlags{})
+	if err != nil {
+		return nil, nil, badRequest("%w", err)
+	}
+
+	scope, err := loadScopedCustomChecks(fsys, req, dir, resolved, workDir, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer scope.Close()
+
+	results, metrics, err := scanner.New(scannerOptions...).ScanFSWithMetrics(ctx, fsys, rel)
+	if err != nil {
+		return nil, nil, &httpError{status: http.StatusUnprocessableEntity, err: fmt.Errorf("scan failed: %w", err)}
+	}
+
+	return &scanRun{
+		root:    root,
+		rel:     rel,
+		results: results,
+		metrics: metrics,
+	}, conf, nil
+}
+
+// loadScopedCustomChecks loads the custom checks from the .tfsec directory of dir, the custom check sources of the
+// config and the request itself, registering them so they only apply to scans of fsys
+func loadScopedCustomChecks(fsys extrafs.FS, req *scanRequest, dir string, resolved *config.Resolved, workDir string, limit *custom.RuleLimit) (*custom.Scope, error) {
+	loader := custom.NewLoader()
+	sources := append([]string{filepath.Join(dir, ".tfsec")}, configCustomCheckSources(resolved)...)
+	if err := addCustomCheckSources(loader, sources); err != nil {
+		return nil, badRequest("%w", err)
+	}
+
+	if len(req.CustomChecks) > 0 {
+		checksDir := filepath.Join(workDir, "checks")
+		if err := os.MkdirAll(checksDir, 0o700); err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(custom.ChecksFile{Checks: req.CustomChecks})
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filepath.Join(checksDir, "request_tfchecks.json"), data, 0o600); err != nil {
+			return nil, err
+		}
+		if err := loader.Add("request", checksDir); err != nil {
+			return nil, badRequest("invalid custom checks in request: %w", err)
+		}
+	}
+
+	scope := custom.NewLimitedScope(fsys, limit)
+	if err := loader.RegisterScoped(scope); err != nil {
+		scope.Close()
+		if errors.Is(err, custom.ErrRuleLimit) {
+			return nil, &httpError{status: http.StatusInsufficientStorage, err: fmt.Errorf("failed to register custom checks: %w - restart the server or raise --max-custom-checks", err)}
+		}
+		return nil, badRequest("failed to register custom checks: %w", err)
+	}
+	return scope, nil
+}
@@ -0,0 +1,518 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aquasecurity/defsec/pkg/extrafs"
+	"github.com/aquasecurity/defsec/pkg/scan"
+	scanner "github.com/aquasecurity/defsec/pkg/scanners/terraform"
+	"github.com/fsnotify/fsnotify"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/liamg/tml"
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/aquasecurity/tfsec/internal/pkg/bundle"
+	"github.com/aquasecurity/tfsec/internal/pkg/compliance"
+	"github.com/aquasecurity/tfsec/internal/pkg/custom"
+	"github.com/aquasecurity/tfsec/internal/pkg/formatter"
+	"github.com/aquasecurity/tfsec/internal/pkg/metrics"
+)
+
+// watchDebounce is how long to wait after a change before rescanning, so that a burst of changes (e.g. an editor
+// saving several files, or a git checkout) only causes a single rescan
+var watchDebounce = 300 * time.Millisecond
+
+func validateWatchFlags(cmd *cobra.Command) error {
+	if outputFlag != "" {
+		return fmt.Errorf("--out cannot be used with --watch")
+	}
+	if cmd.Flags().Changed("format") && format != "lovely" {
+		return fmt.Errorf("--watch only supports the lovely format")
+	}
+	if printRegoInput || regoInputOut != "" || regoEval != "" {
+		return fmt.Errorf("--watch cannot be combined with rego input flags")
+	}
+	if metricsOut != "" {
+		return fmt.Errorf("--metrics-out cannot be used with --watch")
+	}
+	if profileRules || cpuProfile != "" {
+		return fmt.Errorf("--watch cannot be combined with profiling flags")
+	}
+	return nil
+}
+
+// watchDirectory scans dir, then rescans it whenever a relevant file changes, until the command's context is
+// cancelled or the process is interrupted. After each rescan, the findings which appeared or were resolved since
+// the previous scan are listed after the results.
+func watchDirectory(cmd *cobra.Command, dir string, baseConfigs []string, policies *bundle.Bundle) error {
+	if err := validateWatchFlags(cmd); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer stop()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch for changes: %w", err)
+	}
+	defer func() { _ = watcher.Close() }()
+
+	w := &scanWatcher{
+		cmd:         cmd,
+		dir:         dir,
+		baseConfigs: baseConfigs,
+		policies:    policies,
+	}
+
+	if err := w.rescan(ctx, nil); err != nil {
+		return err
+	}
+
+	for _, path := range w.watchPaths() {
+		if err := addWatchTree(watcher, path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+	}
+
+	_ = tml.Fprintf(cmd.ErrOrStderr(), "<dim>Watching %s for changes...</dim>\n", dir)
+
+	timer := time.NewTimer(watchDebounce)
+	if !timer.Stop() {
+		<-timer.C
+	}
+	changed := make(map[string]struct{})
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			logger.Log("Watch event %s", event)
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := addWatchTree(watcher, event.Name); err != nil {
+						logger.Log("Failed to watch %s: %s", event.Name, err)
+					}
+					continue
+				}
+			}
+			if !isWatchedFile(event.Name) {
+				continue
+			}
+			changed[event.Name] = struct{}{}
+			timer.Reset(watchDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "WARNING: Error watching for changes: %s\n", err)
+		case <-timer.C:
+			var paths []string
+			for path := range changed {
+				paths = append(paths, path)
+			}
+			changed = make(map[string]struct{})
+			// once watching, a broken config or check is reported rather than stopping the watch, so it can be fixed
+			if err := w.rescan(ctx, paths); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "ERROR: %s\n", err)
+			}
+		}
+	}
+}
+
+type scanWatcher struct {
+	cmd         *cobra.Command
+	dir         string
+	baseConfigs []string
+	policies    *bundle.Bundle
+	// prepared is the scanner configured from the config and flags, with the custom checks loaded
+	prepared *preparedScan
+	// modules is the latest scan of each root module, by its path relative to the root of the filesystem
+	modules  map[string]*scanRun
+	previous map[string]scan.Result
+}
+
+// rescan scans the root modules affected by the changed files, re-using the latest scan of every other module, or
+// scans every module if there was no previous scan, or a change could affect them all. The config is resolved and the
+// custom checks are reloaded only when a config or custom check file changed.
+func (w *scanWatcher) rescan(ctx context.Context, changed []string) error {
+	full := w.modules == nil
+	reload := w.prepared == nil
+	for _, path := range changed {
+		switch {
+		case isConfigFile(path) || strings.Contains(filepath.Base(path), "_tfchecks."):
+			reload = true
+		case filepath.Ext(path) != ".tf":
+			// rego policies and tfvars files apply to every module
+			full = true
+		}
+	}
+
+	if reload {
+		custom.UnregisterAll()
+		w.prepared = nil
+		prepared, err := prepareScan(w.cmd, w.dir, w.baseConfigs, w.policies, false)
+		if err != nil {
+			return err
+		}
+		w.prepared = prepared
+		full = true
+	}
+	metrics.ClearSession()
+
+	fsys := extrafs.OSDir(w.prepared.root)
+	roots := rootModules(fsys, filepath.ToSlash(w.prepared.rel))
+	affected := roots
+	if !full {
+		affected = w.affectedModules(fsys, roots, changed)
+		var names []string
+		for _, root := range affected {
+			names = append(names, relativePath(w.dir, filepath.Join(w.prepared.root, filepath.FromSlash(root))))
+		}
+		if len(names) > 0 {
+			_ = tml.Fprintf(w.cmd.ErrOrStderr(), "<dim>Rescanning %s...</dim>\n", strings.Join(names, ", "))
+		}
+	}
+
+	modules := make(map[string]*scanRun, len(roots))
+	for _, root := range roots {
+		modules[root] = w.modules[root]
+	}
+	for _, root := range affected {
+		run, err := w.prepared.scanDir(ctx, fsys, root)
+		if err != nil {
+			// the next change rescans every module, rather than leaving this one out of date
+			w.modules = nil
+			return err
+		}
+		modules[root] = run
+	}
+	w.modules = modules
+
+	run := w.prepared.merge(roots, modules)
+	if err := output(w.cmd, "", []string{"lovely"}, run.root, run.rel, run.results, run.metrics, run.outputOptions()); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	current := failedFindings(run.results)
+	if w.previous != nil {
+		printFindingChanges(w.cmd.OutOrStdout(), w.previous, current)
+	}
+	w.previous = current
+	return nil
+}
+
+// affectedModules returns the root modules which changed files are in, with those which call a local module in the
+// directory of a changed file, directly or through other local modules, and any new root modules
+func (w *scanWatcher) affectedModules(fsys fs.FS, roots []string, changed []string) []string {
+	dirs := make(map[string]struct{})
+	for _, path := range changed {
+		if rel, err := filepath.Rel(w.prepared.root, filepath.Dir(path)); err == nil {
+			dirs[filepath.ToSlash(rel)] = struct{}{}
+		}
+	}
+
+	var affected []string
+	for _, root := range roots {
+		previous, ok := w.modules[root]
+		if !ok || previous == nil {
+			affected = append(affected, root)
+			continue
+		}
+		if moduleAffected(root, moduleDirs(fsys, root), dirs) {
+			affected = append(affected, root)
+		}
+	}
+	return affected
+}
+
+func moduleAffected(root string, called map[string]struct{}, dirs map[string]struct{}) bool {
+	for dir := range dirs {
+		if root == "." || dir == root || strings.HasPrefix(dir, root+"/") {
+			return true
+		}
+		if _, ok := called[dir]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleDirs returns the directories of the local modules which root calls, directly or through other local modules,
+// read from the source of their module blocks. Like root, they are relative to the root of fsys.
+func moduleDirs(fsys fs.FS, root string) map[string]struct{} {
+	dirs := make(map[string]struct{})
+	parser := hclparse.NewParser()
+	pending := localModuleSources(fsys, parser, root)
+	for len(pending) > 0 {
+		dir := pending[0]
+		pending = pending[1:]
+		if _, ok := dirs[dir]; ok || dir == root {
+			continue
+		}
+		dirs[dir] = struct{}{}
+		pending = append(pending, localModuleSources(fsys, parser, dir)...)
+	}
+	return dirs
+}
+
+var moduleSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "module", LabelNames: []string{"name"}},
+	},
+}
+
+var moduleSourceSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "source"},
+	},
+}
+
+// localModuleSources returns the directories of the local modules called by the module blocks in dir. Files which
+// can't be parsed are skipped, as the scan reports them.
+func localModuleSources(fsys fs.FS, parser *hclparse.Parser, dir string) []string {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil
+	}
+	var sources []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		filename := path.Join(dir, entry.Name())
+		data, err := fs.ReadFile(fsys, filename)
+		if err != nil {
+			continue
+		}
+		var file *hcl.File
+		switch {
+		case strings.HasSuffix(filename, ".tf"):
+			file, _ = parser.ParseHCL(data, filename)
+		case strings.HasSuffix(filename, ".tf.json"):
+			file, _ = parser.ParseJSON(data, filename)
+		}
+		if file == nil {
+			continue
+		}
+		content, _, _ := file.Body.PartialContent(moduleSchema)
+		for _, block := range content.Blocks {
+			attributes, _, _ := block.Body.PartialContent(moduleSourceSchema)
+			attribute, ok := attributes.Attributes["source"]
+			if !ok {
+				continue
+			}
+			value, diags := attribute.Expr.Value(nil)
+			if diags.HasErrors() || value.IsNull() || !value.IsKnown() || value.Type() != cty.String {
+				continue
+			}
+			if source := value.AsString(); strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") {
+				sources = append(sources, path.Join(dir, source))
+			}
+		}
+	}
+	return sources
+}
+
+// merge combines the scans of the root modules into a single run of the prepared directory
+func (p *preparedScan) merge(roots []string, modules map[string]*scanRun) *scanRun {
+	run := &scanRun{
+		root:      p.root,
+		rel:       p.rel,
+		regoInput: p.regoInput,
+		prepared:  p,
+	}
+	for _, root := range roots {
+		module := modules[root]
+		run.results = append(run.results, module.results...)
+		addMetrics(&run.metrics, module.metrics)
+	}
+	if p.framework != nil {
+		run.compliance = compliance.Evaluate(*p.framework, run.results)
+	}
+	return run
+}
+
+func addMetrics(total *scanner.Metrics, metrics scanner.Metrics) {
+	total.Parser.Timings.DiskIODuration += metrics.Parser.Timings.DiskIODuration
+	total.Parser.Timings.ParseDuration += metrics.Parser.Timings.ParseDuration
+	total.Parser.Counts.Blocks += metrics.Parser.Counts.Blocks
+	total.Parser.Counts.Modules += metrics.Parser.Counts.Modules
+	total.Parser.Counts.Files += metrics.Parser.Counts.Files
+	total.Parser.Counts.ModuleDownloads += metrics.Parser.Counts.ModuleDownloads
+	total.Executor.Timings.Adaptation += metrics.Executor.Timings.Adaptation
+	total.Executor.Timings.RunningChecks += metrics.Executor.Timings.RunningChecks
+	total.Executor.Counts.Passed += metrics.Executor.Counts.Passed
+	total.Executor.Counts.Failed += metrics.Executor.Counts.Failed
+	total.Executor.Counts.Ignored += metrics.Executor.Counts.Ignored
+	total.Executor.Counts.Critical += metrics.Executor.Counts.Critical
+	total.Executor.Counts.High += metrics.Executor.Counts.High
+	total.Executor.Counts.Medium += metrics.Executor.Counts.Medium
+	total.Executor.Counts.Low += metrics.Executor.Counts.Low
+	total.Timings.Total += metrics.Timings.Total
+}
+
+// rootModules returns the shallowest directories below dir, a path relative to the root of fsys, which contain
+// terraform files. These are the root modules the scanner finds, and a scan of each of them together covers the same
+// files as a scan of dir, including with --force-all-dirs, which also scans the directories below them.
+func rootModules(fsys fs.FS, dir string) []string {
+	var roots []string
+	pending := []string{dir}
+	for len(pending) > 0 && len(roots) == 0 {
+		var next []string
+		for _, current := range pending {
+			entries, err := fs.ReadDir(fsys, current)
+			if err != nil {
+				continue
+			}
+			if containsTerraform(entries) {
+				roots = append(roots, current)
+				continue
+			}
+			for _, entry := range entries {
+				if entry.IsDir() {
+					next = append(next, path.Join(current, entry.Name()))
+				}
+			}
+		}
+		pending = next
+	}
+	sort.Strings(roots)
+	return roots
+}
+
+func containsTerraform(entries []fs.DirEntry) bool {
+	for _, entry := range entries {
+		if name := entry.Name(); !entry.IsDir() && (strings.HasSuffix(name, ".tf") || strings.HasSuffix(name, ".tf.json")) {
+			return true
+		}
+	}
+	return false
+}
+
+// watchPaths returns the directories which contain files that affect the scan
+func (w *scanWatcher) watchPaths() []string {
+	paths := []string{w.dir}
+	if regoPolicyDir != "" {
+		paths = append(paths, regoPolicyDir)
+	}
+	for _, path := range customCheckDirs {
+		if !strings.HasPrefix(path, "https://") && !strings.HasPrefix(path, "http://") {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// addWatchTree watches root and the directories below it, skipping hidden directories other than .tfsec
+func addWatchTree(watcher *fsnotify.Watcher, root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if name := info.Name(); path != root && strings.HasPrefix(name, ".") && name != ".tfsec" {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// isWatchedFile returns true if a change to the given file could change the results of a scan
+func isWatchedFile(path string) bool {
+	name := filepath.Base(path)
+	switch filepath.Ext(name) {
+	case ".tf", ".tfvars", ".rego":
+		return true
+	}
+	if strings.Contains(name, "_tfchecks.") {
+		return true
+	}
+	return isConfigFile(path)
+}
+
+// isConfigFile returns true if path is a config file in a .tfsec directory
+func isConfigFile(path string) bool {
+	if filepath.Base(filepath.Dir(path)) != ".tfsec" {
+		return false
+	}
+	switch filepath.Base(path) {
+	case "config.yml", "config.yaml", "config.json":
+		return true
+	}
+	return false
+}
+
+// failedFindings indexes the failed results by rule, file and resource instance, which unlike line numbers stay the
+// same when unrelated parts of a file are edited
+func failedFindings(results scan.Results) map[string]scan.Result {
+	findings := make(map[string]scan.Result)
+	for _, result := range results.GetFailed() {
+		findings[findingKey(result)] = result
+	}
+	return findings
+}
+
+func findingKey(result scan.Result) string {
+	return fmt.Sprintf("%s|%s|%s|%s", result.Rule().LongID(), result.Range().GetFilename(), formatter.Address(result), result.Metadata().Reference())
+}
+
+func printFindingChanges(w io.Writer, previous, current map[string]scan.Result) {
+	var appeared, resolved []string
+	for key, result := range current {
+		if _, ok := previous[key]; !ok {
+			appeared = append(appeared, describeFinding(result))
+		}
+	}
+	for key, result := range previous {
+		if _, ok := current[key]; !ok {
+			resolved = append(resolved, describeFinding(result))
+		}
+	}
+	sort.Strings(appeared)
+	sort.Strings(resolved)
+
+	if len(appeared) == 0 && len(resolved) == 0 {
+		_ = tml.Fprintf(w, "<dim>No change since the previous scan.</dim>\n")
+		return
+	}
+
+	_ = tml.Fprintf(w, "<bold>Changes since the previous scan:</bold>\n")
+	for _, finding := range appeared {
+		_ = tml.Fprintf(w, "  <red>+ %s</red>\n", finding)
+	}
+	for _, finding := range resolved {
+		_ = tml.Fprintf(w, "  <green>- %s</green>\n", finding)
+	}
+	_, _ = fmt.Fprintln(w)
+}
+
+func describeFinding(result scan.Result) string {
+	rng := result.Range()
+	reference := result.Metadata().Reference()
+	if address := formatter.Address(result); address != reference {
+		reference = fmt.Sprintf("%s in %s", reference, address)
+	}
+	return fmt.Sprintf("[%s] %s %s:%d (%s)", result.Severity(), result.Rule().LongID(), rng.GetFilename(), rng.GetStartLine(), reference)
+}
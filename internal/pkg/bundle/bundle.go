@@ -0,0 +1,120 @@
+package bundle
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Options configure how a bundle is fetched and verified
+type Options struct {
+	// SHA256 is the expected digest of the bundle: the archive itself, or the manifest of an OCI layout
+	SHA256 string
+	// PublicKey is the path to a PEM encoded public key. If set, the bundle signature must be valid for this key.
+	PublicKey string
+	// Signature is the path or URL of the bundle signature. Defaults to the bundle source with a .sig suffix.
+	Signature string
+	// CacheDir is where downloaded bundles are kept for reuse. Pinned bundles found here are not downloaded again.
+	CacheDir string
+}
+
+// Bundle is a policy bundle which has been fetched, verified and extracted to a local directory
+type Bundle struct {
+	Source string
+	Digest string
+	Dir    string
+}
+
+var configFilenames = []string{"config.json", "config.yml", "config.yaml"}
+
+// Fetch retrieves the bundle at source, which can be a URL of a tarball, the path of a local tarball, or an OCI
+// layout directory, and extracts it to a temporary directory. Call Close to remove the directory when done.
+func Fetch(ctx context.Context, source string, opts Options) (*Bundle, error) {
+	expected := strings.ToLower(strings.TrimPrefix(opts.SHA256, "sha256:"))
+
+	var signed []byte
+	var layers [][]byte
+	var digest string
+	if isOCILayout(source) {
+		manifest, manifestDigest, ociLayers, err := readOCILayout(source)
+		if err != nil {
+			return nil, err
+		}
+		signed, digest, layers = manifest, manifestDigest, ociLayers
+	} else {
+		data, err := readArchive(ctx, source, expected, opts.CacheDir)
+		if err != nil {
+			return nil, err
+		}
+		signed, digest, layers = data, sha256Hex(data), [][]byte{data}
+	}
+
+	if expected != "" && digest != expected {
+		return nil, fmt.Errorf("checksum mismatch for bundle %s: expected sha256:%s, got sha256:%s", source, expected, digest)
+	}
+
+	if opts.PublicKey != "" {
+		signaturePath := opts.Signature
+		if signaturePath == "" {
+			signaturePath = strings.TrimSuffix(source, "/") + ".sig"
+		}
+		signature, err := readSignature(ctx, signaturePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle signature: %w", err)
+		}
+		if err := Verify(signed, signature, opts.PublicKey); err != nil {
+			return nil, fmt.Errorf("bundle signature verification failed: %w", err)
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "tfsec_bundle_")
+	if err != nil {
+		return nil, err
+	}
+	for _, layer := range layers {
+		if err := Extract(layer, dir); err != nil {
+			_ = os.RemoveAll(dir)
+			return nil, fmt.Errorf("failed to extract bundle %s: %w", source, err)
+		}
+	}
+
+	return &Bundle{
+		Source: source,
+		Digest: digest,
+		Dir:    dir,
+	}, nil
+}
+
+// ConfigFile returns the path of the config file at the root of the bundle, or an empty string if there is none
+func (b *Bundle) ConfigFile() string {
+	for _, filename := range configFilenames {
+		path := filepath.Join(b.Dir, filename)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// PolicyDir returns the path of the directory of rego policies in the bundle, or an empty string if there is none
+func (b *Bundle) PolicyDir() string {
+	path := filepath.Join(b.Dir, "policies")
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return path
+	}
+	return ""
+}
+
+// Close removes the extracted bundle
+func (b *Bundle) Close() error {
+	return os.RemoveAll(b.Dir)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,192 @@
+package bundle_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aquasecurity/tfsec/internal/pkg/bundle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var bundleFiles = map[string]string{
+	"config.yml":          "exclude:\n  - aws-s3-enable-versioning\n",
+	"policies/test.rego":  "package custom.test\n",
+	"checks_tfchecks.yml": "checks: []\n",
+}
+
+func TestFetchLocalArchive(t *testing.T) {
+	archive := writeFile(t, "bundle.tar.gz", buildArchive(t, bundleFiles))
+
+	b, err := bundle.Fetch(context.TODO(), archive, bundle.Options{SHA256: digestOf(t, archive)})
+	require.NoError(t, err)
+	defer func() { _ = b.Close() }()
+
+	assert.Equal(t, filepath.Join(b.Dir, "config.yml"), b.ConfigFile())
+	assert.Equal(t, filepath.Join(b.Dir, "policies"), b.PolicyDir())
+	assert.FileExists(t, filepath.Join(b.Dir, "checks_tfchecks.yml"))
+
+	require.NoError(t, b.Close())
+	assert.NoDirExists(t, b.Dir)
+}
+
+func TestFetchChecksumMismatch(t *testing.T) {
+	archive := writeFile(t, "bundle.tar.gz", buildArchive(t, bundleFiles))
+
+	_, err := bundle.Fetch(context.TODO(), archive, bundle.Options{SHA256: hex.EncodeToString(make([]byte, 32))})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum mismatch")
+}
+
+func TestFetchRejectsPathsOutsideBundle(t *testing.T) {
+	archive := writeFile(t, "bundle.tar.gz", buildArchive(t, map[string]string{"../escaped.yml": "exclude: []\n"}))
+
+	_, err := bundle.Fetch(context.TODO(), archive, bundle.Options{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "outside of the bundle")
+}
+
+func TestFetchRemoteIsCachedWhenPinned(t *testing.T) {
+	data := buildArchive(t, bundleFiles)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bundle.tar.gz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	sum := sha256.Sum256(data)
+	opts := bundle.Options{
+		SHA256:   hex.EncodeToString(sum[:]),
+		CacheDir: t.TempDir(),
+	}
+
+	b, err := bundle.Fetch(context.TODO(), server.URL+"/bundle.tar.gz", opts)
+	require.NoError(t, err)
+	_ = b.Close()
+
+	_, err = bundle.Fetch(context.TODO(), server.URL+"/missing.tar.gz", bundle.Options{CacheDir: opts.CacheDir})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+
+	url := server.URL + "/bundle.tar.gz"
+	server.Close()
+
+	b, err = bundle.Fetch(context.TODO(), url, opts)
+	require.NoError(t, err)
+	defer func() { _ = b.Close() }()
+	assert.NotEmpty(t, b.ConfigFile())
+}
+
+func TestFetchOCILayout(t *testing.T) {
+	dir := t.TempDir()
+	layer := buildArchive(t, bundleFiles)
+	layerDigest := writeBlob(t, dir, layer)
+	manifest, err := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"layers": []map[string]interface{}{
+			{"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "digest": "sha256:" + layerDigest, "size": len(layer)},
+		},
+	})
+	require.NoError(t, err)
+	manifestDigest := writeBlob(t, dir, manifest)
+	index := fmt.Sprintf(`{"schemaVersion": 2, "manifests": [{"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:%s", "size": %d}]}`, manifestDigest, len(manifest))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.json"), []byte(index), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "oci-layout"), []byte(`{"imageLayoutVersion": "1.0.0"}`), 0o600))
+
+	b, err := bundle.Fetch(context.TODO(), dir, bundle.Options{SHA256: "sha256:" + manifestDigest})
+	require.NoError(t, err)
+	defer func() { _ = b.Close() }()
+	assert.Equal(t, manifestDigest, b.Digest)
+	assert.NotEmpty(t, b.ConfigFile())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "blobs", "sha256", layerDigest), []byte("tampered"), 0o600))
+	_, err = bundle.Fetch(context.TODO(), dir, bundle.Options{})
+	require.Error(t, err)
+}
+
+func TestFetchVerifiesSignature(t *testing.T) {
+	data := buildArchive(t, bundleFiles)
+	archive := writeFile(t, "bundle.tar.gz", data)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	keyPath := writeFile(t, "key.pub", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}))
+
+	digest := sha256.Sum256(data)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(archive+".sig", []byte(base64.StdEncoding.EncodeToString(signature)), 0o600))
+
+	b, err := bundle.Fetch(context.TODO(), archive, bundle.Options{PublicKey: keyPath})
+	require.NoError(t, err)
+	_ = b.Close()
+
+	otherDigest := sha256.Sum256([]byte("something else"))
+	otherSignature, err := ecdsa.SignASN1(rand.Reader, key, otherDigest[:])
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(archive+".sig", otherSignature, 0o600))
+
+	_, err = bundle.Fetch(context.TODO(), archive, bundle.Options{PublicKey: keyPath})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "signature verification failed")
+}
+
+func buildArchive(t *testing.T, files map[string]string) []byte {
+	buffer := bytes.NewBuffer(nil)
+	gz := gzip.NewWriter(buffer)
+	archive := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, archive.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o600,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := archive.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, archive.Close())
+	require.NoError(t, gz.Close())
+	return buffer.Bytes()
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func writeBlob(t *testing.T, dir string, data []byte) string {
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "blobs", "sha256"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "blobs", "sha256", digest), data, 0o600))
+	return digest
+}
+
+func digestOf(t *testing.T, path string) string {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
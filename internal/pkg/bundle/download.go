@@ -0,0 +1,101 @@
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxDownloadSize is the largest file which will be downloaded, to protect against a misbehaving server
+const maxDownloadSize = 256 << 20
+
+var httpClient = &http.Client{
+	Timeout: 60 * time.Second,
+}
+
+// Download fetches the file at url, failing if the server does not respond with 200 OK
+func Download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s from %s", resp.Status, url)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", url, err)
+	}
+	if len(data) > maxDownloadSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", url, maxDownloadSize)
+	}
+	return data, nil
+}
+
+// DefaultCacheDir returns the directory bundles are cached in when no other is configured
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "tfsec", "bundles")
+}
+
+func isRemote(source string) bool {
+	return strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://")
+}
+
+// readArchive reads a local archive, or downloads a remote one. A pinned remote archive is served from the cache
+// when present, and added to the cache once downloaded and verified.
+func readArchive(ctx context.Context, source string, expected string, cacheDir string) ([]byte, error) {
+	if !isRemote(source) {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		return data, nil
+	}
+
+	var cachePath string
+	if expected != "" && cacheDir != "" {
+		cachePath = filepath.Join(cacheDir, expected+".bundle")
+		if data, err := os.ReadFile(cachePath); err == nil && sha256Hex(data) == expected {
+			return data, nil
+		}
+	}
+
+	data, err := Download(ctx, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download bundle: %w", err)
+	}
+
+	if cachePath != "" && sha256Hex(data) == expected {
+		if err := os.MkdirAll(cacheDir, 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create bundle cache: %w", err)
+		}
+		if err := os.WriteFile(cachePath, data, 0o600); err != nil {
+			return nil, fmt.Errorf("failed to cache bundle: %w", err)
+		}
+	}
+
+	return data, nil
+}
+
+func readSignature(ctx context.Context, path string) ([]byte, error) {
+	if isRemote(path) {
+		return Download(ctx, path)
+	}
+	return os.ReadFile(path)
+}
@@ -0,0 +1,77 @@
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+type ociIndex struct {
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+func isOCILayout(source string) bool {
+	info, err := os.Stat(filepath.Join(source, "oci-layout"))
+	return err == nil && !info.IsDir()
+}
+
+// readOCILayout reads the single manifest of an OCI layout directory and the layers it refers to, verifying the
+// digest of each blob. The manifest digest identifies the bundle.
+func readOCILayout(dir string) (manifest []byte, digest string, layers [][]byte, err error) {
+	indexData, err := os.ReadFile(filepath.Join(dir, "index.json"))
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to read OCI index: %w", err)
+	}
+	var index ociIndex
+	if err := json.Unmarshal(indexData, &index); err != nil {
+		return nil, "", nil, fmt.Errorf("failed to parse OCI index: %w", err)
+	}
+	if len(index.Manifests) != 1 {
+		return nil, "", nil, fmt.Errorf("OCI layout %s should contain exactly one manifest, found %d", dir, len(index.Manifests))
+	}
+
+	manifest, err = readBlob(dir, index.Manifests[0])
+	if err != nil {
+		return nil, "", nil, err
+	}
+	var parsed ociManifest
+	if err := json.Unmarshal(manifest, &parsed); err != nil {
+		return nil, "", nil, fmt.Errorf("failed to parse OCI manifest: %w", err)
+	}
+	for _, layer := range parsed.Layers {
+		data, err := readBlob(dir, layer)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		layers = append(layers, data)
+	}
+
+	return manifest, sha256Hex(manifest), layers, nil
+}
+
+func readBlob(dir string, descriptor ociDescriptor) ([]byte, error) {
+	algorithm, hash, ok := strings.Cut(descriptor.Digest, ":")
+	if !ok || algorithm != "sha256" || strings.ContainsAny(hash, `/\.`) {
+		return nil, fmt.Errorf("unsupported OCI digest '%s'", descriptor.Digest)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "blobs", algorithm, hash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OCI blob: %w", err)
+	}
+	if actual := sha256Hex(data); actual != hash {
+		return nil, fmt.Errorf("OCI blob %s has digest sha256:%s", descriptor.Digest, actual)
+	}
+	return data, nil
+}
@@ -0,0 +1,133 @@
+package catalogue
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/rules"
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/tfsec/internal/pkg/custom"
+	"github.com/aquasecurity/tfsec/internal/pkg/legacy"
+)
+
+// Source says where a rule was defined
+type Source string
+
+const (
+	SourceBuiltin Source = "builtin"
+	SourceCustom  Source = "custom"
+	SourceRego    Source = "rego"
+)
+
+// Rule is the documentation of a rule, as generated by tfsec-docs and shown by tfsec rules
+type Rule struct {
+	ID          string   `json:"id"`
+	AVDID       string   `json:"avd_id,omitempty"`
+	ShortCode   string   `json:"short_code,omitempty"`
+	LegacyIDs   []string `json:"legacy_ids,omitempty"`
+	Source      Source   `json:"source"`
+	Provider    string   `json:"provider"`
+	Service     string   `json:"service"`
+	Severity    string   `json:"severity"`
+	Summary     string   `json:"summary"`
+	Explanation string   `json:"explanation,omitempty"`
+	Impact      string   `json:"impact,omitempty"`
+	Resolution  string   `json:"resolution,omitempty"`
+	BadExample  string   `json:"bad_example,omitempty"`
+	GoodExample string   `json:"good_example,omitempty"`
+	Links       []string `json:"links,omitempty"`
+}
+
+// Registered returns the built in rules and registered custom checks which a scan runs by default, ordered by ID
+func Registered() []Rule {
+	var catalogue []Rule
+	for _, registered := range rules.GetRegistered() {
+		rule := registered.Rule()
+		if isCustom, active := custom.IsCustom(rule); isCustom {
+			if active {
+				catalogue = append(catalogue, FromRule(rule, SourceCustom))
+			}
+			continue
+		}
+		if rule.Terraform != nil {
+			catalogue = append(catalogue, FromRule(rule, SourceBuiltin))
+		}
+	}
+	Sort(catalogue)
+	return catalogue
+}
+
+// FromRule returns the documentation of a rule
+func FromRule(rule scan.Rule, source Source) Rule {
+	legacyIDs := append([]string(nil), legacy.FindIDs(rule.LongID())...)
+	sort.Strings(legacyIDs)
+	documented := Rule{
+		ID:          rule.LongID(),
+		AVDID:       rule.AVDID,
+		ShortCode:   rule.ShortCode,
+		LegacyIDs:   legacyIDs,
+		Source:      source,
+		Provider:    string(rule.Provider),
+		Service:     rule.Service,
+		Severity:    string(rule.Severity),
+		Summary:     rule.Summary,
+		Explanation: rule.Explanation,
+		Impact:      rule.Impact,
+		Resolution:  rule.Resolution,
+		Links:       rule.Links,
+	}
+	if rule.Terraform != nil {
+		if len(rule.Terraform.BadExamples) > 0 {
+			documented.BadExample = rule.Terraform.BadExamples[0]
+		}
+		if len(rule.Terraform.GoodExamples) > 0 {
+			documented.GoodExample = rule.Terraform.GoodExamples[0]
+		}
+		documented.Links = append(append([]string(nil), rule.Terraform.Links...), rule.Links...)
+	}
+	return documented
+}
+
+// Sort orders rules by ID
+func Sort(catalogue []Rule) {
+	sort.Slice(catalogue, func(i, j int) bool {
+		return catalogue[i].ID < catalogue[j].ID
+	})
+}
+
+// Find returns the rule with the given ID, AVD ID or legacy ID
+func Find(catalogue []Rule, id string) (Rule, bool) {
+	for _, rule := range catalogue {
+		if strings.EqualFold(rule.ID, id) || (rule.AVDID != "" && strings.EqualFold(rule.AVDID, id)) {
+			return rule, true
+		}
+		for _, legacyID := range rule.LegacyIDs {
+			if strings.EqualFold(legacyID, id) {
+				return rule, true
+			}
+		}
+	}
+	return Rule{}, false
+}
+
+// Filter selects rules by provider, service and severity. Empty values match every rule.
+type Filter struct {
+	Provider string
+	Service  string
+	Severity string
+}
+
+// Apply returns the rules which match the filter
+func (f Filter) Apply(catalogue []Rule) []Rule {
+	var filtered []Rule
+	for _, rule := range catalogue {
+		if matches(f.Provider, rule.Provider) && matches(f.Service, rule.Service) && matches(f.Severity, rule.Severity) {
+			filtered = append(filtered, rule)
+		}
+	}
+	return filtered
+}
+
+func matches(want, value string) bool {
+	return want == "" || strings.EqualFold(want, value)
+}
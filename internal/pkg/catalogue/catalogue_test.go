@@ -0,0 +1,165 @@
+package catalogue
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/tfsec/internal/pkg/custom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Registered(t *testing.T) {
+	rules := Registered()
+	require.NotEmpty(t, rules)
+
+	rule, ok := Find(rules, "aws-s3-enable-bucket-encryption")
+	require.True(t, ok)
+	assert.Equal(t, SourceBuiltin, rule.Source)
+	assert.Equal(t, "aws", rule.Provider)
+	assert.Equal(t, "s3", rule.Service)
+	assert.Equal(t, "HIGH", rule.Severity)
+	assert.Equal(t, []string{"AWS017"}, rule.LegacyIDs)
+	assert.NotEmpty(t, rule.BadExample)
+	assert.NotEmpty(t, rule.GoodExample)
+	assert.NotEmpty(t, rule.Links)
+
+	for i := 1; i < len(rules); i++ {
+		assert.LessOrEqual(t, rules[i-1].ID, rules[i].ID)
+	}
+}
+
+func Test_Find(t *testing.T) {
+	rules := Registered()
+	for _, id := range []string{"AWS017", "aws017", "AVD-AWS-0088", "AWS-S3-ENABLE-BUCKET-ENCRYPTION"} {
+		rule, ok := Find(rules, id)
+		require.True(t, ok, id)
+		assert.Equal(t, "aws-s3-enable-bucket-encryption", rule.ID)
+	}
+	_, ok := Find(rules, "aws-s3-does-not-exist")
+	assert.False(t, ok)
+}
+
+func Test_FromRuleWithoutExamples(t *testing.T) {
+	rule := FromRule(scan.Rule{
+		Provider:  "custom",
+		Service:   "custom",
+		ShortCode: "CUS001",
+		Severity:  "LOW",
+		Links:     []string{"https://example.com"},
+	}, SourceCustom)
+	assert.Equal(t, "custom-custom-cus001", rule.ID)
+	assert.Empty(t, rule.BadExample)
+	assert.Empty(t, rule.LegacyIDs)
+	assert.Equal(t, []string{"https://example.com"}, rule.Links)
+}
+
+func Test_Filter(t *testing.T) {
+	rules := []Rule{
+		{ID: "aws-s3-a", Provider: "aws", Service: "s3", Severity: "HIGH"},
+		{ID: "aws-s3-b", Provider: "aws", Service: "s3", Severity: "LOW"},
+		{ID: "aws-ec2-a", Provider: "aws", Service: "ec2", Severity: "HIGH"},
+		{ID: "google-gke-a", Provider: "google", Service: "gke", Severity: "HIGH"},
+	}
+	assert.Len(t, Filter{}.Apply(rules), 4)
+	assert.Len(t, Filter{Provider: "AWS"}.Apply(rules), 3)
+	assert.Len(t, Filter{Provider: "aws", Severity: "high"}.Apply(rules), 2)
+	assert.Equal(t, "aws-s3-b", Filter{Service: "s3", Severity: "LOW"}.Apply(rules)[0].ID)
+	assert.Empty(t, Filter{Provider: "azure"}.Apply(rules))
+}
+
+func Test_LoadRego(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "lib"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "buckets.rego"), []byte(`# METADATA
+# title: Buckets must be named
+# description: Every bucket needs a name.
+# related_resources:
+# - https://example.com/buckets
+# custom:
+#   severity: MEDIUM
+#   short_code: named-buckets
+#   recommended_actions: Name the bucket.
+#   input:
+#     selector:
+#     - type: cloud
+package custom.buckets.named
+
+deny[res] {
+    bucket := input.aws.s3.buckets[_]
+    bucket.name.value == ""
+    res := result.new("Bucket has no name", bucket)
+}
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib", "helpers.rego"), []byte(`package lib.helpers
+
+is_empty(value) {
+    value == ""
+}
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "buckets_test.rego"), []byte(`package custom.buckets.named
+
+test_nothing {
+    true
+}
+`), 0o600))
+
+	rules, err := LoadRego(dir)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+
+	rule := rules[0]
+	assert.Equal(t, "custom.buckets.named", rule.ID)
+	assert.Equal(t, SourceRego, rule.Source)
+	assert.Equal(t, "Buckets must be named", rule.Summary)
+	assert.Equal(t, "Every bucket needs a name.", rule.Explanation)
+	assert.Equal(t, "MEDIUM", rule.Severity)
+	assert.Equal(t, "Name the bucket.", rule.Resolution)
+	assert.Equal(t, []string{"https://example.com/buckets"}, rule.Links)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.rego"), []byte("package custom.broken\n\ndeny[res] {\n"), 0o600))
+	_, err = LoadRego(dir)
+	assert.Error(t, err)
+}
+
+func Test_RegisteredCustomCheckExamples(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docs_tfchecks.yaml"), []byte(`checks:
+  - code: DOC001
+    description: Buckets must have an owner tag
+    requiredTypes: [resource]
+    requiredLabels: [aws_s3_bucket]
+    severity: MEDIUM
+    matchSpec:
+      name: tags
+      action: contains
+      value: owner
+    errorMessage: No owner
+    badExample: |
+      resource "aws_s3_bucket" "bad" {
+      }
+    goodExample: |
+      resource "aws_s3_bucket" "good" {
+        tags = { owner = "me" }
+      }
+`), 0o600))
+	require.NoError(t, custom.Load(dir))
+
+	rule, ok := Find(Registered(), "custom-custom-doc001")
+	require.True(t, ok)
+	assert.Equal(t, SourceCustom, rule.Source)
+	assert.Empty(t, rule.AVDID)
+	assert.Contains(t, rule.BadExample, `resource "aws_s3_bucket" "bad"`)
+	assert.Contains(t, rule.GoodExample, `owner = "me"`)
+
+	rule, ok = Find(Registered(), "aws-ssm-avoid-leaks-via-http")
+	require.True(t, ok)
+	assert.Equal(t, SourceBuiltin, rule.Source)
+
+	// unregistered checks stay in the rule registry, but are not listed
+	custom.UnregisterAll()
+	_, ok = Find(Registered(), "custom-custom-doc001")
+	assert.False(t, ok)
+}
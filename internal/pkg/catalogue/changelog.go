@@ -0,0 +1,66 @@
+package catalogue
+
+import "sort"
+
+// SeverityChange is a rule which is in both exports, but with a different severity
+type SeverityChange struct {
+	Rule             Rule   `json:"rule"`
+	PreviousSeverity string `json:"previous_severity"`
+}
+
+// Changelog is the difference between the rules of two exports
+type Changelog struct {
+	Added           []Rule           `json:"added"`
+	Removed         []Rule           `json:"removed"`
+	SeverityChanges []SeverityChange `json:"severity_changes"`
+}
+
+// Diff matches the rules of the before and after exports by their IDs. Rules which only appear after were added, those
+// which only appear before were removed, and those in both are reported if their severity has changed.
+func Diff(before, after []Rule) *Changelog {
+	oldRules := make(map[string]Rule, len(before))
+	for _, rule := range before {
+		oldRules[rule.ID] = rule
+	}
+	newRules := make(map[string]struct{}, len(after))
+
+	changelog := &Changelog{
+		Added:           []Rule{},
+		Removed:         []Rule{},
+		SeverityChanges: []SeverityChange{},
+	}
+	for _, rule := range after {
+		newRules[rule.ID] = struct{}{}
+		previous, ok := oldRules[rule.ID]
+		switch {
+		case !ok:
+			changelog.Added = append(changelog.Added, rule)
+		case previous.Severity != rule.Severity:
+			changelog.SeverityChanges = append(changelog.SeverityChanges, SeverityChange{
+				Rule:             rule,
+				PreviousSeverity: previous.Severity,
+			})
+		}
+	}
+	for _, rule := range before {
+		if _, ok := newRules[rule.ID]; !ok {
+			changelog.Removed = append(changelog.Removed, rule)
+		}
+	}
+
+	Sort(changelog.Added)
+	Sort(changelog.Removed)
+	sortSeverityChanges(changelog.SeverityChanges)
+	return changelog
+}
+
+// Empty is true when neither export has a rule the other doesn't, and no severities have changed
+func (c *Changelog) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.SeverityChanges) == 0
+}
+
+func sortSeverityChanges(changes []SeverityChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Rule.ID < changes[j].Rule.ID
+	})
+}
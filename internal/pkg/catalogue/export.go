@@ -0,0 +1,141 @@
+package catalogue
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// csvHeader is the columns of a CSV export. Lists, such as the legacy IDs and links, are separated by new lines
+// within their cell.
+var csvHeader = []string{
+	"id",
+	"avd_id",
+	"legacy_ids",
+	"source",
+	"provider",
+	"service",
+	"severity",
+	"summary",
+	"explanation",
+	"impact",
+	"resolution",
+	"links",
+	"bad_example",
+	"good_example",
+}
+
+// WriteJSON exports the rules as a JSON array
+func WriteJSON(w io.Writer, rules []Rule) error {
+	if rules == nil {
+		rules = []Rule{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(rules)
+}
+
+// WriteCSV exports the rules as CSV, with a header row
+func WriteCSV(w io.Writer, rules []Rule) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		if err := writer.Write([]string{
+			rule.ID,
+			rule.AVDID,
+			strings.Join(rule.LegacyIDs, "\n"),
+			string(rule.Source),
+			rule.Provider,
+			rule.Service,
+			rule.Severity,
+			rule.Summary,
+			rule.Explanation,
+			rule.Impact,
+			rule.Resolution,
+			strings.Join(rule.Links, "\n"),
+			rule.BadExample,
+			rule.GoodExample,
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ReadExport reads the rules from a JSON or CSV export, going by the extension of the file
+func ReadExport(path string) ([]Rule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var rules []Rule
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		rules, err = readCSV(f)
+	} else {
+		err = json.NewDecoder(f).Decode(&rules)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rule export %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+func readCSV(r io.Reader) ([]Rule, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("missing header row")
+	}
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[name] = i
+	}
+	if _, ok := columns["id"]; !ok {
+		return nil, fmt.Errorf("missing id column")
+	}
+
+	var rules []Rule
+	for _, record := range records[1:] {
+		value := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+		rules = append(rules, Rule{
+			ID:          value("id"),
+			AVDID:       value("avd_id"),
+			LegacyIDs:   splitLines(value("legacy_ids")),
+			Source:      Source(value("source")),
+			Provider:    value("provider"),
+			Service:     value("service"),
+			Severity:    value("severity"),
+			Summary:     value("summary"),
+			Explanation: value("explanation"),
+			Impact:      value("impact"),
+			Resolution:  value("resolution"),
+			Links:       splitLines(value("links")),
+			BadExample:  value("bad_example"),
+			GoodExample: value("good_example"),
+		})
+	}
+	return rules, nil
+}
+
+func splitLines(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, "\n")
+}
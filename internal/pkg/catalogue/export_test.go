@@ -0,0 +1,97 @@
+package catalogue
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var exportedRules = []Rule{
+	{
+		ID:          "aws-s3-a",
+		AVDID:       "AVD-AWS-9001",
+		LegacyIDs:   []string{"AWS901", "AWS902"},
+		Source:      SourceBuiltin,
+		Provider:    "aws",
+		Service:     "s3",
+		Severity:    "HIGH",
+		Summary:     "Buckets, with commas",
+		Impact:      "Data could be \"read\"",
+		Resolution:  "Fix it",
+		Links:       []string{"https://example.com/a", "https://example.com/b"},
+		BadExample:  "resource \"aws_s3_bucket\" \"bad\" {\n}\n",
+		GoodExample: "resource \"aws_s3_bucket\" \"good\" {\n}\n",
+	},
+	{
+		ID:       "custom.rego.policy",
+		Source:   SourceRego,
+		Provider: "generic",
+		Service:  "general",
+		Severity: "UNKNOWN",
+	},
+}
+
+func Test_ExportRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	var jsonExport bytes.Buffer
+	require.NoError(t, WriteJSON(&jsonExport, exportedRules))
+	jsonPath := filepath.Join(dir, "rules.json")
+	require.NoError(t, os.WriteFile(jsonPath, jsonExport.Bytes(), 0o600))
+
+	var csvExport bytes.Buffer
+	require.NoError(t, WriteCSV(&csvExport, exportedRules))
+	csvPath := filepath.Join(dir, "rules.csv")
+	require.NoError(t, os.WriteFile(csvPath, csvExport.Bytes(), 0o600))
+
+	for _, path := range []string{jsonPath, csvPath} {
+		rules, err := ReadExport(path)
+		require.NoError(t, err, path)
+		assert.Equal(t, exportedRules, rules, path)
+	}
+}
+
+func Test_WriteJSONEmpty(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, WriteJSON(&buffer, nil))
+	assert.Equal(t, "[]\n", buffer.String())
+}
+
+func Test_ReadExportInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.csv")
+	require.NoError(t, os.WriteFile(path, []byte("summary,severity\nthing,HIGH\n"), 0o600))
+	_, err := ReadExport(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing id column")
+}
+
+func Test_Diff(t *testing.T) {
+	before := []Rule{
+		{ID: "aws-s3-a", Severity: "HIGH"},
+		{ID: "aws-s3-b", Severity: "LOW"},
+		{ID: "aws-s3-removed", Severity: "MEDIUM"},
+	}
+	after := []Rule{
+		{ID: "aws-s3-b", Severity: "CRITICAL"},
+		{ID: "aws-s3-z-added", Severity: "LOW"},
+		{ID: "aws-s3-a", Severity: "HIGH"},
+		{ID: "aws-s3-added", Severity: "HIGH"},
+	}
+
+	changelog := Diff(before, after)
+	require.Len(t, changelog.Added, 2)
+	assert.Equal(t, "aws-s3-added", changelog.Added[0].ID)
+	assert.Equal(t, "aws-s3-z-added", changelog.Added[1].ID)
+	require.Len(t, changelog.Removed, 1)
+	assert.Equal(t, "aws-s3-removed", changelog.Removed[0].ID)
+	require.Len(t, changelog.SeverityChanges, 1)
+	assert.Equal(t, "aws-s3-b", changelog.SeverityChanges[0].Rule.ID)
+	assert.Equal(t, "LOW", changelog.SeverityChanges[0].PreviousSeverity)
+	assert.False(t, changelog.Empty())
+
+	assert.True(t, Diff(before, before).Empty())
+}
@@ -0,0 +1,83 @@
+package catalogue
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/rego"
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// LoadRego returns the rules of the rego policies in dir and its subdirectories. Rego rules are identified by their
+// package, as they are in config files, and libraries without deny, warn or violation rules are left out.
+func LoadRego(dir string) ([]Rule, error) {
+	modules := make(map[string]*ast.Module)
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || filepath.Ext(path) != ".rego" || strings.HasSuffix(path, "_test.rego") {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		module, err := ast.ParseModuleWithOpts(path, string(data), ast.ParserOptions{ProcessAnnotation: true})
+		if err != nil {
+			return err
+		}
+		modules[path] = module
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rego policies from %s: %w", dir, err)
+	}
+
+	schemaSet, _, _ := rego.BuildSchemaSetFromPolicies(modules, nil, nil)
+	compiler := ast.NewCompiler().WithSchemas(schemaSet)
+	compiler.Compile(modules)
+	if compiler.Failed() {
+		return nil, fmt.Errorf("failed to compile rego policies from %s: %w", dir, compiler.Errors)
+	}
+
+	retriever := rego.NewMetadataRetriever(compiler)
+	var catalogue []Rule
+	for _, module := range modules {
+		if !isEnforced(module) {
+			continue
+		}
+		metadata, err := retriever.RetrieveMetadata(context.TODO(), module)
+		if err != nil {
+			return nil, err
+		}
+		if metadata.Library {
+			continue
+		}
+		rule := FromRule(metadata.ToRule(), SourceRego)
+		rule.ID = strings.TrimPrefix(metadata.Package, "data.")
+		if rule.Summary == "N/A" {
+			rule.Summary = ""
+		}
+		catalogue = append(catalogue, rule)
+	}
+	Sort(catalogue)
+	return catalogue, nil
+}
+
+// isEnforced returns whether the module has any rules which produce results
+func isEnforced(module *ast.Module) bool {
+	for _, rule := range module.Rules {
+		name := rule.Head.Name.String()
+		for _, prefix := range []string{"deny", "warn", "violation"} {
+			if name == prefix || strings.HasPrefix(name, prefix+"_") {
+				return true
+			}
+		}
+	}
+	return false
+}
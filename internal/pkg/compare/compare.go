@@ -0,0 +1,179 @@
+package compare
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/defsec/pkg/severity"
+	"github.com/aquasecurity/tfsec/internal/pkg/config"
+)
+
+// Severities are the severities findings are counted by, most severe first
+var Severities = []severity.Severity{
+	severity.Critical,
+	severity.High,
+	severity.Medium,
+	severity.Low,
+}
+
+// Finding is a failed result from one of the compared reports
+type Finding struct {
+	Fingerprint string          `json:"fingerprint"`
+	Path        string          `json:"path"`
+	Result      scan.FlatResult `json:"result"`
+	// PreviousSeverity is the severity of an unchanged finding in the old report, when it has changed
+	PreviousSeverity severity.Severity `json:"previous_severity,omitempty"`
+}
+
+// SeverityDelta is the number of findings of a severity in each report
+type SeverityDelta struct {
+	Severity severity.Severity `json:"severity"`
+	Old      int               `json:"old"`
+	New      int               `json:"new"`
+	Delta    int               `json:"delta"`
+}
+
+// Comparison is the difference between the failed results of two reports
+type Comparison struct {
+	New        []Finding       `json:"new"`
+	Fixed      []Finding       `json:"fixed"`
+	Unchanged  []Finding       `json:"unchanged"`
+	Severities []SeverityDelta `json:"severities"`
+}
+
+// Options control how results are matched
+type Options struct {
+	// TrimPrefixes are removed from the start of filenames before they are compared, so that reports from scans of
+	// different checkouts of the same code can be matched
+	TrimPrefixes []string
+}
+
+// Compare matches the failed results of the before and after reports by their fingerprints. Results which only
+// appear after are new findings, those which only appear before are fixed, and those in both are unchanged.
+func Compare(before, after []scan.FlatResult, opts Options) *Comparison {
+	oldFindings := findingsByFingerprint(before, opts)
+	newFindings := findingsByFingerprint(after, opts)
+
+	comparison := &Comparison{
+		New:       []Finding{},
+		Fixed:     []Finding{},
+		Unchanged: []Finding{},
+	}
+	for key, findings := range newFindings {
+		previous := oldFindings[key]
+		// results with the same fingerprint, such as several results for one resource, are paired up in line order
+		for i, finding := range findings {
+			if i >= len(previous) {
+				comparison.New = append(comparison.New, finding)
+				continue
+			}
+			if previous[i].Result.Severity != finding.Result.Severity {
+				finding.PreviousSeverity = previous[i].Result.Severity
+			}
+			comparison.Unchanged = append(comparison.Unchanged, finding)
+		}
+	}
+	for key, findings := range oldFindings {
+		if len(findings) > len(newFindings[key]) {
+			comparison.Fixed = append(comparison.Fixed, findings[len(newFindings[key]):]...)
+		}
+	}
+	sortFindings(comparison.New)
+	sortFindings(comparison.Fixed)
+	sortFindings(comparison.Unchanged)
+
+	for _, sev := range Severities {
+		delta := SeverityDelta{
+			Severity: sev,
+			Old:      countSeverity(before, sev),
+			New:      countSeverity(after, sev),
+		}
+		delta.Delta = delta.New - delta.Old
+		comparison.Severities = append(comparison.Severities, delta)
+	}
+	return comparison
+}
+
+// CountNew returns the number of new findings with a severity of at least minimum
+func (c *Comparison) CountNew(minimum severity.Severity) int {
+	var count int
+	for _, finding := range c.New {
+		if config.SeverityOrdinal(finding.Result.Severity) >= config.SeverityOrdinal(minimum) {
+			count++
+		}
+	}
+	return count
+}
+
+// Fingerprint identifies a result by its rule, resource and file, so that it can be matched across reports even
+// when lines move
+func Fingerprint(result scan.FlatResult, opts Options) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%s", result.LongID, result.Resource, normalisePath(result.Location.Filename, opts))))
+	return hex.EncodeToString(hash[:])
+}
+
+func normalisePath(path string, opts Options) string {
+	path = filepath.ToSlash(path)
+	for _, prefix := range opts.TrimPrefixes {
+		prefix = filepath.ToSlash(prefix)
+		if prefix != "" && !strings.HasSuffix(prefix, "/") {
+			prefix += "/"
+		}
+		if strings.HasPrefix(path, prefix) {
+			return strings.TrimPrefix(path, prefix)
+		}
+	}
+	return path
+}
+
+func findingsByFingerprint(results []scan.FlatResult, opts Options) map[string][]Finding {
+	findings := make(map[string][]Finding)
+	for _, result := range results {
+		if result.Status != scan.StatusFailed {
+			continue
+		}
+		key := Fingerprint(result, opts)
+		findings[key] = append(findings[key], Finding{
+			Fingerprint: key,
+			Path:        normalisePath(result.Location.Filename, opts),
+			Result:      result,
+		})
+	}
+	for _, group := range findings {
+		sort.SliceStable(group, func(i, j int) bool {
+			return group[i].Result.Location.StartLine < group[j].Result.Location.StartLine
+		})
+	}
+	return findings
+}
+
+func sortFindings(findings []Finding) {
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if config.SeverityOrdinal(a.Result.Severity) != config.SeverityOrdinal(b.Result.Severity) {
+			return config.SeverityOrdinal(a.Result.Severity) > config.SeverityOrdinal(b.Result.Severity)
+		}
+		if a.Result.LongID != b.Result.LongID {
+			return a.Result.LongID < b.Result.LongID
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Result.Location.StartLine < b.Result.Location.StartLine
+	})
+}
+
+func countSeverity(results []scan.FlatResult, sev severity.Severity) int {
+	var count int
+	for _, result := range results {
+		if result.Status == scan.StatusFailed && result.Severity == sev {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,105 @@
+package compare
+
+import (
+	"testing"
+
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/defsec/pkg/severity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func result(longID, resource, filename string, line int, sev severity.Severity) scan.FlatResult {
+	return scan.FlatResult{
+		LongID:   longID,
+		Resource: resource,
+		Severity: sev,
+		Status:   scan.StatusFailed,
+		Location: scan.FlatRange{
+			Filename:  filename,
+			StartLine: line,
+			EndLine:   line + 2,
+		},
+	}
+}
+
+func Test_Compare(t *testing.T) {
+	before := []scan.FlatResult{
+		result("aws-s3-enable-bucket-encryption", "aws_s3_bucket.a", "/src/main.tf", 1, severity.High),
+		result("aws-kms-auto-rotate-keys", "aws_kms_key.key", "/src/main.tf", 10, severity.Medium),
+		result("aws-ec2-no-public-ip", "aws_instance.web", "/src/main.tf", 20, severity.High),
+	}
+	after := []scan.FlatResult{
+		// moved down by an edit, but unchanged
+		result("aws-s3-enable-bucket-encryption", "aws_s3_bucket.a", "/src/main.tf", 5, severity.High),
+		// unchanged, but the rule severity has changed
+		result("aws-ec2-no-public-ip", "aws_instance.web", "/src/main.tf", 25, severity.Critical),
+		result("aws-s3-enable-bucket-encryption", "aws_s3_bucket.b", "/src/main.tf", 40, severity.High),
+		result("aws-s3-enable-versioning", "aws_s3_bucket.b", "/src/main.tf", 40, severity.Low),
+	}
+	passed := result("aws-kms-auto-rotate-keys", "aws_kms_key.key", "/src/main.tf", 10, severity.Medium)
+	passed.Status = scan.StatusPassed
+	after = append(after, passed)
+
+	comparison := Compare(before, after, Options{})
+
+	require.Len(t, comparison.New, 2)
+	assert.Equal(t, "aws_s3_bucket.b", comparison.New[0].Result.Resource)
+	assert.Equal(t, "aws-s3-enable-versioning", comparison.New[1].Result.LongID)
+
+	require.Len(t, comparison.Fixed, 1)
+	assert.Equal(t, "aws-kms-auto-rotate-keys", comparison.Fixed[0].Result.LongID)
+
+	require.Len(t, comparison.Unchanged, 2)
+	assert.Equal(t, "aws-ec2-no-public-ip", comparison.Unchanged[0].Result.LongID)
+	assert.Equal(t, severity.High, comparison.Unchanged[0].PreviousSeverity)
+	assert.Equal(t, 5, comparison.Unchanged[1].Result.Location.StartLine)
+	assert.Equal(t, severity.None, comparison.Unchanged[1].PreviousSeverity)
+
+	assert.Equal(t, []SeverityDelta{
+		{Severity: severity.Critical, Old: 0, New: 1, Delta: 1},
+		{Severity: severity.High, Old: 2, New: 2, Delta: 0},
+		{Severity: severity.Medium, Old: 1, New: 0, Delta: -1},
+		{Severity: severity.Low, Old: 0, New: 1, Delta: 1},
+	}, comparison.Severities)
+
+	assert.Equal(t, 2, comparison.CountNew(severity.Low))
+	assert.Equal(t, 1, comparison.CountNew(severity.High))
+	assert.Equal(t, 0, comparison.CountNew(severity.Critical))
+}
+
+func Test_CompareDuplicateFingerprints(t *testing.T) {
+	before := []scan.FlatResult{
+		result("aws-ec2-no-public-ingress-sgr", "aws_security_group.sg", "/src/main.tf", 3, severity.Critical),
+	}
+	after := []scan.FlatResult{
+		result("aws-ec2-no-public-ingress-sgr", "aws_security_group.sg", "/src/main.tf", 9, severity.Critical),
+		result("aws-ec2-no-public-ingress-sgr", "aws_security_group.sg", "/src/main.tf", 3, severity.Critical),
+	}
+
+	comparison := Compare(before, after, Options{})
+	require.Len(t, comparison.Unchanged, 1)
+	assert.Equal(t, 3, comparison.Unchanged[0].Result.Location.StartLine)
+	require.Len(t, comparison.New, 1)
+	assert.Equal(t, 9, comparison.New[0].Result.Location.StartLine)
+	assert.Empty(t, comparison.Fixed)
+}
+
+func Test_CompareTrimPrefixes(t *testing.T) {
+	before := []scan.FlatResult{
+		result("aws-s3-enable-bucket-encryption", "aws_s3_bucket.a", "/builds/1/repo/main.tf", 1, severity.High),
+	}
+	after := []scan.FlatResult{
+		result("aws-s3-enable-bucket-encryption", "aws_s3_bucket.a", "/builds/2/repo/main.tf", 1, severity.High),
+	}
+
+	comparison := Compare(before, after, Options{})
+	assert.Len(t, comparison.New, 1)
+	assert.Len(t, comparison.Fixed, 1)
+
+	comparison = Compare(before, after, Options{TrimPrefixes: []string{"/builds/1", "/builds/2/"}})
+	assert.Empty(t, comparison.New)
+	assert.Empty(t, comparison.Fixed)
+	require.Len(t, comparison.Unchanged, 1)
+	assert.Equal(t, "repo/main.tf", comparison.Unchanged[0].Path)
+}
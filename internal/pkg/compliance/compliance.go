@@ -0,0 +1,212 @@
+package compliance
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/framework"
+	"github.com/aquasecurity/defsec/pkg/rules"
+	"gopkg.in/yaml.v2"
+)
+
+// Framework is a compliance framework, such as a CIS benchmark, with the checks which cover each of its controls
+type Framework struct {
+	ID       string    `json:"id" yaml:"id"`
+	Name     string    `json:"name,omitempty" yaml:"name,omitempty"`
+	Controls []Control `json:"controls,omitempty" yaml:"controls,omitempty"`
+}
+
+// Control is a single requirement of a framework. It is satisfied when none of its checks fail.
+type Control struct {
+	ID     string   `json:"id" yaml:"id"`
+	Title  string   `json:"title,omitempty" yaml:"title,omitempty"`
+	Checks []string `json:"checks,omitempty" yaml:"checks,omitempty"`
+}
+
+//go:embed frameworks/*.yaml
+var builtinFiles embed.FS
+
+// Frameworks returns the built in frameworks, including the controls registered rules declare they cover, with the
+// given frameworks merged on top of them
+func Frameworks(extra ...Framework) ([]Framework, error) {
+	builtin, err := loadBuiltin()
+	if err != nil {
+		return nil, err
+	}
+	frameworks := Merge(append(append(builtin, fromRules()...), extra...)...)
+	for i, fw := range frameworks {
+		// the built in frameworks name controls which no check covers, so they can be titled if a check declares it
+		// covers them, but there is nothing to report for them otherwise
+		var covered []Control
+		for _, control := range fw.Controls {
+			if len(control.Checks) > 0 {
+				covered = append(covered, control)
+			}
+		}
+		frameworks[i].Controls = covered
+	}
+	return frameworks, nil
+}
+
+// Find returns the framework with the given ID
+func Find(frameworks []Framework, id string) (Framework, bool) {
+	for _, fw := range frameworks {
+		if strings.EqualFold(fw.ID, id) {
+			return fw, true
+		}
+	}
+	return Framework{}, false
+}
+
+// IDs returns the IDs of the given frameworks
+func IDs(frameworks []Framework) []string {
+	var ids []string
+	for _, fw := range frameworks {
+		ids = append(ids, fw.ID)
+	}
+	return ids
+}
+
+// Merge combines frameworks with the same ID. Later names and control titles replace earlier ones, and the checks of
+// controls with the same ID are combined. The frameworks are returned ordered by ID, with their controls in order.
+func Merge(frameworks ...Framework) []Framework {
+	byID := make(map[string]*Framework)
+	var ids []string
+	for _, fw := range frameworks {
+		id := strings.ToLower(fw.ID)
+		merged, ok := byID[id]
+		if !ok {
+			merged = &Framework{ID: id}
+			byID[id] = merged
+			ids = append(ids, id)
+		}
+		if fw.Name != "" {
+			merged.Name = fw.Name
+		}
+		for _, control := range fw.Controls {
+			merged.add(control)
+		}
+	}
+	sort.Strings(ids)
+
+	var result []Framework
+	for _, id := range ids {
+		fw := byID[id]
+		sort.SliceStable(fw.Controls, func(i, j int) bool {
+			return controlLess(fw.Controls[i].ID, fw.Controls[j].ID)
+		})
+		result = append(result, *fw)
+	}
+	return result
+}
+
+func (f *Framework) add(control Control) {
+	for i := range f.Controls {
+		existing := &f.Controls[i]
+		if existing.ID != control.ID {
+			continue
+		}
+		if control.Title != "" {
+			existing.Title = control.Title
+		}
+		existing.Checks = appendUnique(existing.Checks, control.Checks...)
+		return
+	}
+	f.Controls = append(f.Controls, Control{
+		ID:     control.ID,
+		Title:  control.Title,
+		Checks: appendUnique(nil, control.Checks...),
+	})
+}
+
+// Checks returns the IDs of every check which covers a control of the framework
+func (f Framework) Checks() []string {
+	var checks []string
+	for _, control := range f.Controls {
+		checks = appendUnique(checks, control.Checks...)
+	}
+	sort.Strings(checks)
+	return checks
+}
+
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		item = strings.ToLower(item)
+		var found bool
+		for _, existing := range list {
+			if existing == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// controlLess orders control IDs by each of their dotted parts, numerically where they are numbers, so 1.2 comes
+// before 1.10
+func controlLess(a, b string) bool {
+	partsA, partsB := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		if partsA[i] == partsB[i] {
+			continue
+		}
+		numberA, errA := strconv.Atoi(partsA[i])
+		numberB, errB := strconv.Atoi(partsB[i])
+		if errA == nil && errB == nil {
+			return numberA < numberB
+		}
+		return partsA[i] < partsB[i]
+	}
+	return len(partsA) < len(partsB)
+}
+
+func loadBuiltin() ([]Framework, error) {
+	paths, err := fs.Glob(builtinFiles, "frameworks/*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	var frameworks []Framework
+	for _, path := range paths {
+		data, err := builtinFiles.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var fw Framework
+		if err := yaml.UnmarshalStrict(data, &fw); err != nil {
+			return nil, fmt.Errorf("failed to load built in framework %s: %w", path, err)
+		}
+		frameworks = append(frameworks, fw)
+	}
+	return frameworks, nil
+}
+
+// fromRules returns the controls which registered rules, including custom checks, declare they cover
+func fromRules() []Framework {
+	var frameworks []Framework
+	for _, registered := range rules.GetRegistered(framework.ALL) {
+		rule := registered.Rule()
+		for fw, controls := range rule.Frameworks {
+			switch fw {
+			case framework.Default, framework.Experimental, framework.ALL:
+				continue
+			}
+			if len(controls) == 0 {
+				continue
+			}
+			mapped := Framework{ID: string(fw)}
+			for _, control := range controls {
+				mapped.Controls = append(mapped.Controls, Control{ID: control, Checks: []string{rule.LongID()}})
+			}
+			frameworks = append(frameworks, mapped)
+		}
+	}
+	return frameworks
+}
@@ -0,0 +1,147 @@
+package compliance
+
+import (
+	"testing"
+
+	"github.com/aquasecurity/defsec/pkg/scan"
+	_ "github.com/aquasecurity/defsec/pkg/scanners/terraform"
+	defsecTypes "github.com/aquasecurity/defsec/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Frameworks(t *testing.T) {
+	frameworks, err := Frameworks()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cis-aws-1.2", "cis-aws-1.4", "cis-azure-1.3", "cis-gcp-1.2"}, IDs(frameworks))
+
+	for _, fw := range frameworks {
+		assert.NotEmpty(t, fw.Name, fw.ID)
+		for _, control := range fw.Controls {
+			assert.NotEmpty(t, control.Checks, "%s %s", fw.ID, control.ID)
+		}
+	}
+
+	aws, ok := Find(frameworks, "CIS-AWS-1.4")
+	require.True(t, ok)
+	assert.Contains(t, aws.Checks(), "aws-cloudtrail-enable-all-regions")
+}
+
+func Test_FrameworksExtendsBuiltin(t *testing.T) {
+	frameworks, err := Frameworks(
+		Framework{ID: "cis-aws-1.4", Controls: []Control{{ID: "1.4", Checks: []string{"custom-root-access-keys"}}}},
+		Framework{ID: "internal", Name: "Internal", Controls: []Control{{ID: "A1"}}},
+	)
+	require.NoError(t, err)
+
+	aws, ok := Find(frameworks, "cis-aws-1.4")
+	require.True(t, ok)
+	require.Equal(t, "1.4", aws.Controls[0].ID)
+	assert.Equal(t, "Ensure no 'root' user account access key exists", aws.Controls[0].Title)
+	assert.Contains(t, aws.Controls[0].Checks, "custom-root-access-keys")
+
+	internal, ok := Find(frameworks, "internal")
+	require.True(t, ok)
+	assert.Empty(t, internal.Controls)
+}
+
+func Test_Merge(t *testing.T) {
+	merged := Merge(
+		Framework{ID: "b", Controls: []Control{{ID: "1.10", Checks: []string{"x"}}, {ID: "1.2", Title: "old", Checks: []string{"Y"}}}},
+		Framework{ID: "A", Name: "First"},
+		Framework{ID: "B", Name: "Second", Controls: []Control{{ID: "1.2", Title: "new", Checks: []string{"y", "z"}}, {ID: "1.1.1"}}},
+	)
+	require.Len(t, merged, 2)
+	assert.Equal(t, Framework{ID: "a", Name: "First"}, merged[0])
+	assert.Equal(t, Framework{
+		ID:   "b",
+		Name: "Second",
+		Controls: []Control{
+			{ID: "1.1.1"},
+			{ID: "1.2", Title: "new", Checks: []string{"y", "z"}},
+			{ID: "1.10", Checks: []string{"x"}},
+		},
+	}, merged[1])
+}
+
+func Test_ControlLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		less bool
+	}{
+		{"1.2", "1.10", true},
+		{"1.10", "1.2", false},
+		{"1", "1.1", true},
+		{"2.1", "10", true},
+		{"A1", "B1", true},
+		{"1.2", "1.2", false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.less, controlLess(test.a, test.b), "%s < %s", test.a, test.b)
+	}
+}
+
+func testResults(id string, passed, failed, ignored int) scan.Results {
+	var results scan.Results
+	source := struct{ Metadata defsecTypes.Metadata }{defsecTypes.NewTestMetadata()}
+	for i := 0; i < passed; i++ {
+		results.AddPassed(source)
+	}
+	for i := 0; i < failed; i++ {
+		results.Add("failed", source)
+	}
+	for i := 0; i < ignored; i++ {
+		results.AddIgnored(source)
+	}
+	results.SetRule(scan.Rule{Provider: "test", Service: "svc", ShortCode: id})
+	return results
+}
+
+func Test_Evaluate(t *testing.T) {
+	fw := Framework{
+		ID:   "test",
+		Name: "Test",
+		Controls: []Control{
+			{ID: "1", Title: "Passes", Checks: []string{"test-svc-pass"}},
+			{ID: "2", Title: "Fails", Checks: []string{"test-svc-pass", "test-svc-fail"}},
+			{ID: "3", Title: "Ignored", Checks: []string{"test-svc-ignored"}},
+			{ID: "4", Title: "Nothing", Checks: []string{"test-svc-missing"}},
+		},
+	}
+	var results scan.Results
+	results = append(results, testResults("pass", 2, 0, 0)...)
+	results = append(results, testResults("fail", 1, 1, 0)...)
+	results = append(results, testResults("ignored", 0, 0, 1)...)
+	results = append(results, testResults("other", 0, 3, 0)...)
+
+	report := Evaluate(fw, results)
+	assert.Equal(t, "test", report.Framework)
+	assert.Equal(t, "Test", report.Name)
+	assert.Equal(t, 1, report.Passed)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, 2, report.NotEvaluated)
+
+	require.Len(t, report.Controls, 4)
+	assert.Equal(t, StatusPassed, report.Controls[0].Status)
+	assert.Equal(t, StatusFailed, report.Controls[1].Status)
+	assert.Equal(t, []CheckResult{
+		{ID: "test-svc-pass", Passed: 2},
+		{ID: "test-svc-fail", Passed: 1, Failed: 1},
+	}, report.Controls[1].Checks)
+	assert.Equal(t, StatusNotEvaluated, report.Controls[2].Status)
+	assert.Equal(t, 1, report.Controls[2].Checks[0].Ignored)
+	assert.Equal(t, StatusNotEvaluated, report.Controls[3].Status)
+}
+
+func Test_Filter(t *testing.T) {
+	fw := Framework{ID: "test", Controls: []Control{{ID: "1", Checks: []string{"TEST-SVC-KEEP"}}}}
+	var results scan.Results
+	results = append(results, testResults("keep", 1, 1, 0)...)
+	results = append(results, testResults("drop", 1, 1, 0)...)
+
+	filtered := Filter(fw)(results)
+	require.Len(t, filtered, 2)
+	for _, result := range filtered {
+		assert.Equal(t, "test-svc-keep", result.Rule().LongID())
+	}
+}
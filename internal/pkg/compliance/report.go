@@ -0,0 +1,121 @@
+package compliance
+
+import (
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/scan"
+)
+
+// Status is the outcome of a control
+type Status string
+
+const (
+	// StatusPassed means at least one of the checks of the control passed, and none failed
+	StatusPassed Status = "passed"
+	// StatusFailed means at least one of the checks of the control failed
+	StatusFailed Status = "failed"
+	// StatusNotEvaluated means the checks of the control produced no results, usually because none of the resources
+	// they apply to were found
+	StatusNotEvaluated Status = "not evaluated"
+)
+
+// Report is the outcome of each control of a framework for a set of results
+type Report struct {
+	Framework    string          `json:"framework"`
+	Name         string          `json:"name,omitempty"`
+	Passed       int             `json:"passed"`
+	Failed       int             `json:"failed"`
+	NotEvaluated int             `json:"not_evaluated"`
+	Controls     []ControlResult `json:"controls"`
+}
+
+// ControlResult is the outcome of a single control
+type ControlResult struct {
+	ID     string        `json:"id"`
+	Title  string        `json:"title,omitempty"`
+	Status Status        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// CheckResult counts the results of one of the checks of a control. Ignored results neither pass nor fail a control.
+type CheckResult struct {
+	ID      string `json:"id"`
+	Passed  int    `json:"passed"`
+	Failed  int    `json:"failed"`
+	Ignored int    `json:"ignored"`
+}
+
+// Evaluate works out the status of each control of the framework from the results of a scan
+func Evaluate(fw Framework, results scan.Results) *Report {
+	counts := make(map[string]*CheckResult)
+	for _, result := range results {
+		id := strings.ToLower(result.Rule().LongID())
+		count, ok := counts[id]
+		if !ok {
+			count = &CheckResult{ID: id}
+			counts[id] = count
+		}
+		switch result.Status() {
+		case scan.StatusPassed:
+			count.Passed++
+		case scan.StatusIgnored:
+			count.Ignored++
+		default:
+			count.Failed++
+		}
+	}
+
+	report := &Report{
+		Framework: fw.ID,
+		Name:      fw.Name,
+		Controls:  []ControlResult{},
+	}
+	for _, control := range fw.Controls {
+		outcome := ControlResult{
+			ID:     control.ID,
+			Title:  control.Title,
+			Status: StatusNotEvaluated,
+			Checks: []CheckResult{},
+		}
+		for _, check := range control.Checks {
+			count := CheckResult{ID: check}
+			if counted, ok := counts[check]; ok {
+				count = *counted
+			}
+			switch {
+			case count.Failed > 0:
+				outcome.Status = StatusFailed
+			case count.Passed > 0 && outcome.Status != StatusFailed:
+				outcome.Status = StatusPassed
+			}
+			outcome.Checks = append(outcome.Checks, count)
+		}
+		switch outcome.Status {
+		case StatusPassed:
+			report.Passed++
+		case StatusFailed:
+			report.Failed++
+		default:
+			report.NotEvaluated++
+		}
+		report.Controls = append(report.Controls, outcome)
+	}
+	return report
+}
+
+// Filter returns a results filter which keeps only the results of the checks of the framework
+func Filter(fw Framework) func(scan.Results) scan.Results {
+	checks := make(map[string]struct{})
+	for _, check := range fw.Checks() {
+		checks[check] = struct{}{}
+	}
+	return func(results scan.Results) scan.Results {
+		var filtered scan.Results
+		for _, result := range results {
+			if _, ok := checks[strings.ToLower(result.Rule().LongID())]; ok {
+				filtered = append(filtered, result)
+			}
+		}
+		return filtered
+	}
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"github.com/aquasecurity/defsec/pkg/severity"
+	"github.com/aquasecurity/tfsec/internal/pkg/compliance"
 	"gopkg.in/yaml.v2"
 )
 
@@ -19,6 +21,48 @@ type Config struct {
 	IncludedChecks         []string          `json:"include,omitempty" yaml:"include,omitempty"`
 	ExcludeIgnores         []string          `json:"exclude_ignores,omitempty" yaml:"exclude_ignores,omitempty"`
 	MinimumRequiredVersion string            `json:"min_required_version" yaml:"min_required_version,omitempty"`
+	ExcludePaths           []string          `json:"exclude_paths,omitempty" yaml:"exclude_paths,omitempty"`
+	TFVarsPaths            []string          `json:"tfvars,omitempty" yaml:"tfvars,omitempty"`
+	Workspace              string            `json:"workspace,omitempty" yaml:"workspace,omitempty"`
+	RegoPolicyDir          string            `json:"rego_policy_dir,omitempty" yaml:"rego_policy_dir,omitempty"`
+	IncludePassed          *bool             `json:"include_passed,omitempty" yaml:"include_passed,omitempty"`
+	Format                 string            `json:"format,omitempty" yaml:"format,omitempty"`
+	Out                    string            `json:"out,omitempty" yaml:"out,omitempty"`
+	NoModuleDownloads      *bool             `json:"no_module_downloads,omitempty" yaml:"no_module_downloads,omitempty"`
+	CustomCheckDir         string            `json:"custom_check_dir,omitempty" yaml:"custom_check_dir,omitempty"`
+	CustomCheckSources     []string          `json:"custom_check_sources,omitempty" yaml:"custom_check_sources,omitempty"`
+	Overrides              []PathOverride    `json:"overrides,omitempty" yaml:"overrides,omitempty"`
+	// Frameworks are compliance frameworks, which extend the built in frameworks with the same ID
+	Frameworks []compliance.Framework `json:"frameworks,omitempty" yaml:"frameworks,omitempty"`
+}
+
+// PathOverride configures rules differently for files matching a glob, relative to the directory the config applies to
+type PathOverride struct {
+	Path              string            `json:"path" yaml:"path"`
+	ExcludedChecks    []string          `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+	SeverityOverrides map[string]string `json:"severity_overrides,omitempty" yaml:"severity_overrides,omitempty"`
+	MinimumSeverity   string            `json:"minimum_severity,omitempty" yaml:"minimum_severity,omitempty"`
+	baseDir           string
+	source            string
+}
+
+// Glob returns the absolute path glob which the override applies to
+func (o PathOverride) Glob() string {
+	return filepath.Join(o.baseDir, o.Path)
+}
+
+// Source returns the config file which defined the override
+func (o PathOverride) Source() string {
+	return o.source
+}
+
+// Config returns the rule configuration of the override, in a form that can be applied like any other config
+func (o PathOverride) Config() *Config {
+	return &Config{
+		ExcludedChecks:    o.ExcludedChecks,
+		SeverityOverrides: o.SeverityOverrides,
+		MinimumSeverity:   o.MinimumSeverity,
+	}
 }
 
 func LoadConfig(configFilePath string) (*Config, error) {
@@ -36,12 +80,14 @@ func LoadConfig(configFilePath string) (*Config, error) {
 	ext := filepath.Ext(configFilePath)
 	switch strings.ToLower(ext) {
 	case ".json":
-		err = json.Unmarshal(configFileContent, config)
+		decoder := json.NewDecoder(bytes.NewReader(configFileContent))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(config)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load config file '%s': %w", configFilePath, err)
 		}
 	case ".yaml", ".yml":
-		err = yaml.Unmarshal(configFileContent, config)
+		err = yaml.UnmarshalStrict(configFileContent, config)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load config file '%s': %w", configFilePath, err)
 		}
@@ -49,11 +95,38 @@ func LoadConfig(configFilePath string) (*Config, error) {
 		return nil, fmt.Errorf("couldn't process the file %s", configFilePath)
 	}
 
-	rewriteSeverityOverrides(config)
+	baseDir := filepath.Dir(configFilePath)
+	if filepath.Base(baseDir) == ".tfsec" {
+		baseDir = filepath.Dir(baseDir)
+	}
+	if err := prepare(config, configFilePath, baseDir); err != nil {
+		return nil, fmt.Errorf("invalid config file '%s': %w", configFilePath, err)
+	}
 
 	return config, nil
 }
 
+// Prepare validates a config which was not loaded from a file, such as one supplied through the API, and makes the
+// relative paths in it relative to dir. Source is used in place of a filename to describe where the config came from.
+func Prepare(config *Config, source string, dir string) error {
+	return prepare(config, source, dir)
+}
+
+func prepare(config *Config, source string, baseDir string) error {
+	if err := validateSeverities(config); err != nil {
+		return err
+	}
+	if err := validateFrameworks(config); err != nil {
+		return err
+	}
+	rewriteSeverityOverrides(config)
+	resolveRelativePaths(config, baseDir)
+	for i := range config.Overrides {
+		config.Overrides[i].source = source
+	}
+	return nil
+}
+
 func (c *Config) GetValidExcludedChecks() (excludedChecks []string) {
 	for _, check := range c.ExcludedChecks {
 		if strings.Contains(check, ":") {
@@ -73,8 +146,74 @@ func (c *Config) GetValidExcludedChecks() (excludedChecks []string) {
 	return excludedChecks
 }
 
+func validateSeverities(config *Config) error {
+	if config.MinimumSeverity != "" && severity.StringToSeverity(config.MinimumSeverity) == severity.None {
+		return fmt.Errorf("minimum_severity: '%s' is not a valid severity - should be one of CRITICAL, HIGH, MEDIUM, LOW", config.MinimumSeverity)
+	}
+	for id, s := range config.SeverityOverrides {
+		if severity.StringToSeverity(s) == severity.None {
+			return fmt.Errorf("severity_overrides.%s: '%s' is not a valid severity - should be one of CRITICAL, HIGH, MEDIUM, LOW", id, s)
+		}
+	}
+	for _, override := range config.Overrides {
+		if override.Path == "" {
+			return fmt.Errorf("overrides: every override must have a path")
+		}
+		if err := validateSeverities(override.Config()); err != nil {
+			return fmt.Errorf("overrides[%s].%w", override.Path, err)
+		}
+	}
+	return nil
+}
+
+func validateFrameworks(config *Config) error {
+	for i, fw := range config.Frameworks {
+		if fw.ID == "" {
+			return fmt.Errorf("frameworks[%d]: every framework must have an id", i)
+		}
+		for j, control := range fw.Controls {
+			if control.ID == "" {
+				return fmt.Errorf("frameworks[%s].controls[%d]: every control must have an id", fw.ID, j)
+			}
+		}
+	}
+	return nil
+}
+
 func rewriteSeverityOverrides(config *Config) {
 	for k, s := range config.SeverityOverrides {
 		config.SeverityOverrides[k] = string(severity.StringToSeverity(s))
 	}
+	for _, override := range config.Overrides {
+		for k, s := range override.SeverityOverrides {
+			override.SeverityOverrides[k] = string(severity.StringToSeverity(s))
+		}
+	}
+}
+
+// resolveRelativePaths makes file paths in the config relative to the directory the config applies to: the parent
+// of the .tfsec directory for discovered config files, otherwise the directory containing the config file.
+// Excluded paths are left alone, as they are always relative to the scanned directory. Override globs keep their
+// original form, but record the directory they are relative to.
+func resolveRelativePaths(config *Config, baseDir string) {
+	resolve := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(baseDir, path)
+	}
+	for i, path := range config.TFVarsPaths {
+		config.TFVarsPaths[i] = resolve(path)
+	}
+	config.RegoPolicyDir = resolve(config.RegoPolicyDir)
+	config.CustomCheckDir = resolve(config.CustomCheckDir)
+	for i, source := range config.CustomCheckSources {
+		if !strings.HasPrefix(source, "https://") && !strings.HasPrefix(source, "http://") {
+			config.CustomCheckSources[i] = resolve(source)
+		}
+	}
+	config.Out = resolve(config.Out)
+	for i := range config.Overrides {
+		config.Overrides[i].baseDir = baseDir
+	}
 }
@@ -3,8 +3,10 @@ package config_test
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/aquasecurity/defsec/pkg/severity"
 	"github.com/aquasecurity/tfsec/internal/pkg/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -139,6 +141,240 @@ func TestWarningIsRewrittenAsMedium(t *testing.T) {
 	assert.Equal(t, "MEDIUM", sev)
 }
 
+func TestScanOptionsFromYAML(t *testing.T) {
+	content := `
+exclude_paths:
+  - modules/legacy
+tfvars:
+  - prod.tfvars
+workspace: production
+rego_policy_dir: policies
+include_passed: true
+format: json
+out: results.json
+no_module_downloads: false
+custom_check_dir: /opt/checks
+custom_check_sources:
+  - org-checks
+  - https://example.com/team_tfchecks.yaml
+`
+	c := load(t, "config.yaml", content)
+
+	assert.Equal(t, []string{"modules/legacy"}, c.ExcludePaths)
+	assert.Equal(t, "production", c.Workspace)
+	assert.Equal(t, "json", c.Format)
+	require.NotNil(t, c.IncludePassed)
+	assert.True(t, *c.IncludePassed)
+	require.NotNil(t, c.NoModuleDownloads)
+	assert.False(t, *c.NoModuleDownloads)
+	assert.Equal(t, "/opt/checks", c.CustomCheckDir)
+	require.Len(t, c.CustomCheckSources, 2)
+	assert.True(t, filepath.IsAbs(c.CustomCheckSources[0]))
+	assert.Equal(t, "https://example.com/team_tfchecks.yaml", c.CustomCheckSources[1])
+	require.Len(t, c.TFVarsPaths, 1)
+	assert.True(t, filepath.IsAbs(c.TFVarsPaths[0]))
+	assert.Equal(t, "prod.tfvars", filepath.Base(c.TFVarsPaths[0]))
+	assert.True(t, filepath.IsAbs(c.RegoPolicyDir))
+	assert.True(t, filepath.IsAbs(c.Out))
+}
+
+func TestUnknownKeysAreRejected(t *testing.T) {
+	for filename, content := range map[string]string{
+		"config.yaml": "exclude:\n  - DP001\nexlcude_paths:\n  - modules\n",
+		"config.json": `{"exclude": ["DP001"], "exlcude_paths": ["modules"]}`,
+	} {
+		t.Run(filename, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, filename)
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+			_, err := config.LoadConfig(path)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "exlcude_paths")
+		})
+	}
+}
+
+func TestInvalidSeveritiesAreRejected(t *testing.T) {
+	for name, content := range map[string]string{
+		"override": "severity_overrides:\n  aws-s3-enable-versioning: SEVERE\n",
+		"minimum":  "minimum_severity: HIHG\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yml")
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+			_, err := config.LoadConfig(path)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "is not a valid severity")
+		})
+	}
+}
+
+func TestValidateRuleIDsSuggestsNearMatches(t *testing.T) {
+	conf := &config.Config{
+		ExcludedChecks:    []string{"aws-s3-enable-versoning:3099-01-01", "AWS002", "custom.rego.policy"},
+		IncludedChecks:    []string{"AWS-S3-ENABLE-BUCKET-LOGGING"},
+		SeverityOverrides: map[string]string{"something-else-entirely": "HIGH"},
+	}
+	known := []string{"aws-s3-enable-versioning", "aws-s3-enable-bucket-logging", "AWS002"}
+
+	problems := config.ValidateRuleIDs(conf, known)
+	require.Len(t, problems, 2)
+	assert.Equal(t, "exclude", problems[0].Key)
+	assert.Equal(t, "aws-s3-enable-versoning", problems[0].ID)
+	assert.Equal(t, "aws-s3-enable-versioning", problems[0].Suggestion)
+	assert.Equal(t, "severity_overrides", problems[1].Key)
+	assert.Empty(t, problems[1].Suggestion)
+	assert.Equal(t, "exclude: unknown rule 'aws-s3-enable-versoning' - did you mean 'aws-s3-enable-versioning'?", problems[0].Error())
+}
+
+func TestPathOverridesFromYAML(t *testing.T) {
+	content := `
+overrides:
+  - path: sandbox/**
+    exclude:
+      - aws-s3-enable-versioning
+    severity_overrides:
+      aws-s3-enable-bucket-logging: WARNING
+    minimum_severity: HIGH
+`
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".tfsec"), 0o755))
+	path := filepath.Join(dir, ".tfsec", "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	c, err := config.LoadConfig(path)
+	require.NoError(t, err)
+	require.Len(t, c.Overrides, 1)
+
+	override := c.Overrides[0]
+	assert.Equal(t, "sandbox/**", override.Path)
+	assert.Equal(t, filepath.Join(dir, "sandbox", "**"), override.Glob())
+	assert.Equal(t, path, override.Source())
+	assert.Equal(t, []string{"aws-s3-enable-versioning"}, override.Config().ExcludedChecks)
+	assert.Equal(t, "MEDIUM", override.Config().SeverityOverrides["aws-s3-enable-bucket-logging"])
+	assert.Equal(t, "HIGH", override.Config().MinimumSeverity)
+
+	require.NoError(t, os.WriteFile(path, []byte("overrides:\n  - exclude:\n      - aws-s3-enable-versioning\n"), 0o600))
+	_, err = config.LoadConfig(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "every override must have a path")
+}
+
+func TestFrameworksFromYAML(t *testing.T) {
+	content := `
+frameworks:
+  - id: cis-aws-1.4
+    controls:
+      - id: "1.4"
+        checks:
+          - custom-root-access-keys
+  - id: internal
+    name: Internal Standard
+    controls:
+      - id: SEC-1
+        title: Buckets are encrypted
+        checks:
+          - aws-s3-enable-bucket-encryption
+`
+	c := load(t, "config.yaml", content)
+	require.Len(t, c.Frameworks, 2)
+	assert.Equal(t, "internal", c.Frameworks[1].ID)
+	assert.Equal(t, "Internal Standard", c.Frameworks[1].Name)
+	assert.Equal(t, []string{"aws-s3-enable-bucket-encryption"}, c.Frameworks[1].Controls[0].Checks)
+
+	problems := config.ValidateRuleIDs(c, []string{"aws-s3-enable-bucket-encryption"})
+	require.Len(t, problems, 1)
+	assert.Equal(t, "frameworks[cis-aws-1.4].controls[1.4]", problems[0].Key)
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte("frameworks:\n  - id: internal\n    controls:\n      - title: Missing\n"), 0o600))
+	_, err := config.LoadConfig(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "every control must have an id")
+}
+
+func TestMergeAppendsListsAndTightensSeverities(t *testing.T) {
+	base := &config.Config{
+		MinimumSeverity:        "HIGH",
+		MinimumRequiredVersion: "v1.2.0",
+		SeverityOverrides: map[string]string{
+			"aws-s3-enable-versioning": "HIGH",
+			"aws-s3-enable-logging":    "LOW",
+		},
+		ExcludedChecks: []string{"DP001"},
+	}
+	override := &config.Config{
+		MinimumSeverity:        "LOW",
+		MinimumRequiredVersion: "v1.1.0",
+		SeverityOverrides: map[string]string{
+			"aws-s3-enable-versioning": "LOW",
+			"aws-s3-enable-logging":    "CRITICAL",
+		},
+		ExcludedChecks: []string{"DP001", "DP002"},
+	}
+
+	merged := config.Merge(base, override)
+
+	assert.Equal(t, "LOW", merged.MinimumSeverity)
+	assert.Equal(t, "v1.2.0", merged.MinimumRequiredVersion)
+	assert.Equal(t, "HIGH", merged.SeverityOverrides["aws-s3-enable-versioning"])
+	assert.Equal(t, "CRITICAL", merged.SeverityOverrides["aws-s3-enable-logging"])
+	assert.Equal(t, []string{"DP001", "DP002"}, merged.ExcludedChecks)
+}
+
+func TestDiscoverParentsOrdersOutermostFirst(t *testing.T) {
+	root, err := os.MkdirTemp("", "")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(root) }()
+
+	child := filepath.Join(root, "team", "service")
+	require.NoError(t, os.MkdirAll(filepath.Join(child, ".tfsec"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".tfsec"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".git"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".tfsec", "config.yml"), []byte("exclude:\n  - DP001\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(child, ".tfsec", "config.json"), []byte(`{"exclude": ["DP002"]}`), 0o600))
+
+	paths := config.DiscoverParents(child)
+	require.Len(t, paths, 2)
+	assert.Equal(t, filepath.Join(root, ".tfsec", "config.yml"), paths[0])
+	assert.Equal(t, filepath.Join(child, ".tfsec", "config.json"), paths[1])
+
+	resolved, err := config.Resolve(paths...)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DP001", "DP002"}, resolved.Config.ExcludedChecks)
+	assert.Equal(t, paths[0], resolved.Source("exclude.DP001"))
+	assert.Equal(t, paths[1], resolved.Source("exclude.DP002"))
+
+	children := config.DiscoverChildren(root)
+	assert.Equal(t, map[string]string{child: paths[1]}, children)
+}
+
+func TestDiscoverParentsStopsAtRepositoryRoot(t *testing.T) {
+	outside := t.TempDir()
+	repo := filepath.Join(outside, "repo")
+	child := filepath.Join(repo, "service")
+	for _, dir := range []string{outside, repo, child} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, ".tfsec"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".tfsec", "config.yml"), []byte("exclude:\n  - DP001\n"), 0o600))
+	}
+
+	// outside a repository, only the directory's own config is used
+	assert.Equal(t, []string{filepath.Join(child, ".tfsec", "config.yml")}, config.DiscoverParents(child))
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo, ".git"), []byte("gitdir: elsewhere\n"), 0o600))
+	assert.Equal(t, []string{
+		filepath.Join(repo, ".tfsec", "config.yml"),
+		filepath.Join(child, ".tfsec", "config.yml"),
+	}, config.DiscoverParents(child))
+}
+
+func TestSeverityOrdinal(t *testing.T) {
+	assert.Greater(t, config.SeverityOrdinal(severity.Critical), config.SeverityOrdinal(severity.High))
+	assert.Greater(t, config.SeverityOrdinal(severity.High), config.SeverityOrdinal(severity.Medium))
+	assert.Greater(t, config.SeverityOrdinal(severity.Medium), config.SeverityOrdinal(severity.Low))
+	assert.Greater(t, config.SeverityOrdinal(severity.Low), config.SeverityOrdinal(severity.None))
+}
+
 func load(t *testing.T, filename, content string) *config.Config {
 	dir, err := os.MkdirTemp("", "")
 	require.NoError(t, err)
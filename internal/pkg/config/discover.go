@@ -0,0 +1,97 @@
+package config
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var configFilenames = []string{"config.json", "config.yml", "config.yaml"}
+
+// FindConfigFile returns the path of the config file in the .tfsec directory of dir, or an empty string if there is none
+func FindConfigFile(dir string) string {
+	configDir := filepath.Join(dir, ".tfsec")
+	for _, filename := range configFilenames {
+		path := filepath.Join(configDir, filename)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// DiscoverParents returns the config files found in dir and each of its parents up to the root of the repository it is
+// in, the first directory containing .git, ordered from the outermost directory inwards. When dir is not in a
+// repository, only its own config file is returned, so config files elsewhere on the machine are never picked up.
+func DiscoverParents(dir string) []string {
+	root := repositoryRoot(dir)
+	if root == "" {
+		root = dir
+	}
+	var paths []string
+	for {
+		if path := FindConfigFile(dir); path != "" {
+			paths = append([]string{path}, paths...)
+		}
+		parent := filepath.Dir(dir)
+		if dir == root || parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return paths
+}
+
+// repositoryRoot returns the first of dir and its parents which contains .git, or an empty string if there is none
+func repositoryRoot(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// DiscoverChildren returns the config files found in directories below dir, keyed by the directory they apply to
+func DiscoverChildren(dir string) map[string]string {
+	children := make(map[string]string)
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if path == dir {
+			return nil
+		}
+		if name := d.Name(); strings.HasPrefix(name, ".") {
+			return filepath.SkipDir
+		}
+		if configPath := FindConfigFile(path); configPath != "" {
+			children[path] = configPath
+		}
+		return nil
+	})
+	return children
+}
+
+// SortedDirs returns the directories of the given discovered children, with parents before their descendants
+func SortedDirs(children map[string]string) []string {
+	var dirs []string
+	for dir := range children {
+		dirs = append(dirs, dir)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		depthI := strings.Count(dirs[i], string(filepath.Separator))
+		depthJ := strings.Count(dirs[j], string(filepath.Separator))
+		if depthI == depthJ {
+			return dirs[i] < dirs[j]
+		}
+		return depthI < depthJ
+	})
+	return dirs
+}
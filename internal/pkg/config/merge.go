@@ -0,0 +1,231 @@
+package config
+
+import (
+	"sort"
+
+	"github.com/Masterminds/semver"
+	"github.com/aquasecurity/defsec/pkg/severity"
+	"github.com/aquasecurity/tfsec/internal/pkg/compliance"
+)
+
+// Resolved is the result of merging one or more config files, in order of increasing precedence
+type Resolved struct {
+	Config  *Config
+	Files   []string
+	sources map[string]string
+}
+
+// Resolve loads each of the given config files and merges them in order, later files taking precedence
+func Resolve(paths ...string) (*Resolved, error) {
+	resolved := &Resolved{
+		Config:  &Config{},
+		sources: make(map[string]string),
+	}
+	for _, path := range paths {
+		conf, err := LoadConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		resolved.Add(path, conf)
+	}
+	return resolved, nil
+}
+
+// Add merges the given config into the resolved config, recording path as the source of any value it supplies
+func (r *Resolved) Add(path string, conf *Config) {
+	r.Files = append(r.Files, path)
+	merged := Merge(r.Config, conf)
+	if merged.MinimumSeverity != r.Config.MinimumSeverity {
+		r.sources["minimum_severity"] = path
+	}
+	if merged.MinimumRequiredVersion != r.Config.MinimumRequiredVersion {
+		r.sources["min_required_version"] = path
+	}
+	for key, value := range map[string]string{
+		"workspace":        conf.Workspace,
+		"rego_policy_dir":  conf.RegoPolicyDir,
+		"format":           conf.Format,
+		"out":              conf.Out,
+		"custom_check_dir": conf.CustomCheckDir,
+	} {
+		if value != "" {
+			r.sources[key] = path
+		}
+	}
+	for key, value := range map[string]*bool{
+		"include_passed":      conf.IncludePassed,
+		"no_module_downloads": conf.NoModuleDownloads,
+	} {
+		if value != nil {
+			r.sources[key] = path
+		}
+	}
+	for id, sev := range merged.SeverityOverrides {
+		if existing, ok := r.Config.SeverityOverrides[id]; !ok || existing != sev {
+			r.sources["severity_overrides."+id] = path
+		}
+	}
+	for _, list := range []struct {
+		key   string
+		items []string
+	}{
+		{key: "exclude", items: conf.ExcludedChecks},
+		{key: "include", items: conf.IncludedChecks},
+		{key: "exclude_ignores", items: conf.ExcludeIgnores},
+		{key: "exclude_paths", items: conf.ExcludePaths},
+		{key: "tfvars", items: conf.TFVarsPaths},
+		{key: "custom_check_sources", items: conf.CustomCheckSources},
+	} {
+		for _, item := range list.items {
+			if _, ok := r.sources[list.key+"."+item]; !ok {
+				r.sources[list.key+"."+item] = path
+			}
+		}
+	}
+	for _, override := range conf.Overrides {
+		r.sources["overrides."+override.Path] = path
+	}
+	for _, fw := range conf.Frameworks {
+		r.sources["frameworks."+fw.ID] = path
+	}
+	r.Config = merged
+}
+
+// Source returns the config file which supplied the value for the given key, e.g. "severity_overrides.aws-s3-enable-versioning"
+func (r *Resolved) Source(key string) string {
+	return r.sources[key]
+}
+
+// Keys returns every key which has a recorded source, in sorted order
+func (r *Resolved) Keys() []string {
+	var keys []string
+	for key := range r.sources {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Merge combines two configs, with values from override taking precedence over those from base.
+// Lists (including path overrides, which are applied in order) are appended, maps are merged key by key, other values are replaced, and severities are only ever tightened:
+// the lowest minimum severity wins, a severity override can only raise a severity set by base,
+// and the highest minimum required version wins.
+func Merge(base *Config, override *Config) *Config {
+	merged := &Config{
+		MinimumSeverity:        tightenMinimumSeverity(base.MinimumSeverity, override.MinimumSeverity),
+		MinimumRequiredVersion: highestVersion(base.MinimumRequiredVersion, override.MinimumRequiredVersion),
+		ExcludedChecks:         appendUnique(base.ExcludedChecks, override.ExcludedChecks),
+		IncludedChecks:         appendUnique(base.IncludedChecks, override.IncludedChecks),
+		ExcludeIgnores:         appendUnique(base.ExcludeIgnores, override.ExcludeIgnores),
+		ExcludePaths:           appendUnique(base.ExcludePaths, override.ExcludePaths),
+		TFVarsPaths:            appendUnique(base.TFVarsPaths, override.TFVarsPaths),
+		CustomCheckSources:     appendUnique(base.CustomCheckSources, override.CustomCheckSources),
+		Workspace:              overrideString(base.Workspace, override.Workspace),
+		RegoPolicyDir:          overrideString(base.RegoPolicyDir, override.RegoPolicyDir),
+		IncludePassed:          overrideBool(base.IncludePassed, override.IncludePassed),
+		Format:                 overrideString(base.Format, override.Format),
+		Out:                    overrideString(base.Out, override.Out),
+		NoModuleDownloads:      overrideBool(base.NoModuleDownloads, override.NoModuleDownloads),
+		CustomCheckDir:         overrideString(base.CustomCheckDir, override.CustomCheckDir),
+		Overrides:              append(append([]PathOverride{}, base.Overrides...), override.Overrides...),
+		Frameworks:             append(append([]compliance.Framework{}, base.Frameworks...), override.Frameworks...),
+	}
+	if len(merged.Overrides) == 0 {
+		merged.Overrides = nil
+	}
+	if len(merged.Frameworks) == 0 {
+		merged.Frameworks = nil
+	}
+
+	if len(base.SeverityOverrides) > 0 || len(override.SeverityOverrides) > 0 {
+		merged.SeverityOverrides = make(map[string]string)
+		for id, sev := range base.SeverityOverrides {
+			merged.SeverityOverrides[id] = sev
+		}
+		for id, sev := range override.SeverityOverrides {
+			if existing, ok := merged.SeverityOverrides[id]; ok && SeverityOrdinal(severity.StringToSeverity(existing)) > SeverityOrdinal(severity.StringToSeverity(sev)) {
+				continue
+			}
+			merged.SeverityOverrides[id] = sev
+		}
+	}
+
+	return merged
+}
+
+func overrideString(base string, override string) string {
+	if override != "" {
+		return override
+	}
+	return base
+}
+
+func overrideBool(base *bool, override *bool) *bool {
+	if override != nil {
+		return override
+	}
+	return base
+}
+
+func appendUnique(base []string, extra []string) []string {
+	var output []string
+	seen := make(map[string]struct{})
+	for _, item := range append(append([]string{}, base...), extra...) {
+		if _, ok := seen[item]; ok {
+			continue
+		}
+		seen[item] = struct{}{}
+		output = append(output, item)
+	}
+	return output
+}
+
+func tightenMinimumSeverity(base string, override string) string {
+	switch {
+	case base == "":
+		return override
+	case override == "":
+		return base
+	case SeverityOrdinal(severity.StringToSeverity(override)) < SeverityOrdinal(severity.StringToSeverity(base)):
+		return override
+	default:
+		return base
+	}
+}
+
+func highestVersion(base string, override string) string {
+	if base == "" {
+		return override
+	}
+	if override == "" {
+		return base
+	}
+	baseVersion, err := semver.NewVersion(base)
+	if err != nil {
+		return override
+	}
+	overrideVersion, err := semver.NewVersion(override)
+	if err != nil {
+		return base
+	}
+	if overrideVersion.GreaterThan(baseVersion) {
+		return override
+	}
+	return base
+}
+
+// SeverityOrdinal ranks a severity from 0 for none up to 4 for critical, so severities can be compared
+func SeverityOrdinal(sev severity.Severity) int {
+	switch sev {
+	case severity.Critical:
+		return 4
+	case severity.High:
+		return 3
+	case severity.Medium:
+		return 2
+	case severity.Low:
+		return 1
+	default:
+		return 0
+	}
+}
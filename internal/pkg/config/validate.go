@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/agnivade/levenshtein"
+)
+
+// RuleIDProblem describes a rule ID referenced by a config file which does not exist
+type RuleIDProblem struct {
+	Key        string
+	ID         string
+	Suggestion string
+}
+
+func (p RuleIDProblem) Error() string {
+	if p.Suggestion != "" {
+		return fmt.Sprintf("%s: unknown rule '%s' - did you mean '%s'?", p.Key, p.ID, p.Suggestion)
+	}
+	return fmt.Sprintf("%s: unknown rule '%s'", p.Key, p.ID)
+}
+
+// ValidateRuleIDs checks that every rule referenced by exclude, include, severity_overrides (including those of
+// path overrides) and the controls of frameworks is one of the known IDs, suggesting the nearest match for any that
+// are not. IDs containing a '.' are assumed to refer to rego policies, which are not known until the scan runs, and
+// are not checked.
+func ValidateRuleIDs(conf *Config, known []string) []RuleIDProblem {
+	knownSet := make(map[string]struct{}, len(known))
+	for _, id := range known {
+		knownSet[strings.ToLower(id)] = struct{}{}
+	}
+
+	var problems []RuleIDProblem
+	check := func(key string, id string) {
+		if strings.Contains(id, ".") {
+			return
+		}
+		if _, ok := knownSet[strings.ToLower(id)]; ok {
+			return
+		}
+		problems = append(problems, RuleIDProblem{
+			Key:        key,
+			ID:         id,
+			Suggestion: nearestMatch(id, known),
+		})
+	}
+
+	for _, excluded := range conf.ExcludedChecks {
+		id, _, _ := strings.Cut(excluded, ":")
+		check("exclude", id)
+	}
+	for _, id := range conf.IncludedChecks {
+		check("include", id)
+	}
+	for _, id := range sortedKeys(conf.SeverityOverrides) {
+		check("severity_overrides", id)
+	}
+	for _, override := range conf.Overrides {
+		prefix := fmt.Sprintf("overrides[%s].", override.Path)
+		for _, excluded := range override.ExcludedChecks {
+			id, _, _ := strings.Cut(excluded, ":")
+			check(prefix+"exclude", id)
+		}
+		for _, id := range sortedKeys(override.SeverityOverrides) {
+			check(prefix+"severity_overrides", id)
+		}
+	}
+	for _, fw := range conf.Frameworks {
+		for _, control := range fw.Controls {
+			for _, id := range control.Checks {
+				check(fmt.Sprintf("frameworks[%s].controls[%s]", fw.ID, control.ID), id)
+			}
+		}
+	}
+
+	return problems
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// nearestMatch returns the known ID which is the smallest edit distance from id, provided it is close enough
+// to plausibly be a typo
+func nearestMatch(id string, known []string) string {
+	id = strings.ToLower(id)
+	threshold := len(id) / 3
+	if threshold < 2 {
+		threshold = 2
+	}
+	var best string
+	bestDistance := threshold + 1
+	for _, candidate := range known {
+		if distance := levenshtein.ComputeDistance(id, strings.ToLower(candidate)); distance < bestDistance {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+	return best
+}
@@ -2,6 +2,7 @@ package custom
 
 import (
 	"github.com/aquasecurity/defsec/pkg/severity"
+	"github.com/aquasecurity/tfsec/internal/pkg/remediation"
 )
 
 type MatchType string
@@ -122,19 +123,25 @@ type MatchSpec struct {
 
 // Check specifies the check definition represented in json/yaml
 type Check struct {
-	Code            string            `json:"code" yaml:"code"`
-	Provider        string            `json:"provider,omitempty" yaml:"provider,omitempty"`
-	Service         string            `json:"service,omitempty" yaml:"service,omitempty"`
-	Description     string            `json:"description" yaml:"description"`
-	RequiredTypes   []string          `json:"requiredTypes" yaml:"requiredTypes"`
-	RequiredLabels  []string          `json:"requiredLabels" yaml:"requiredLabels"`
-	RequiredSources []string          `json:"requiredSources" yaml:"requiredSources,omitempty"`
-	Severity        severity.Severity `json:"severity" yaml:"severity"`
-	ErrorMessage    string            `json:"errorMessage,omitempty" yaml:"errorMessage,omitempty"`
-	MatchSpec       *MatchSpec        `json:"matchSpec" yaml:"matchSpec"`
-	RelatedLinks    []string          `json:"relatedLinks,omitempty" yaml:"relatedLinks,omitempty"`
-	Impact          string            `json:"impact,omitempty" yaml:"impact,omitempty"`
-	Resolution      string            `json:"resolution,omitempty" yaml:"resolution,omitempty"`
+	Code            string             `json:"code" yaml:"code"`
+	Provider        string             `json:"provider,omitempty" yaml:"provider,omitempty"`
+	Service         string             `json:"service,omitempty" yaml:"service,omitempty"`
+	Description     string             `json:"description" yaml:"description"`
+	RequiredTypes   []string           `json:"requiredTypes" yaml:"requiredTypes"`
+	RequiredLabels  []string           `json:"requiredLabels" yaml:"requiredLabels"`
+	RequiredSources []string           `json:"requiredSources" yaml:"requiredSources,omitempty"`
+	Severity        severity.Severity  `json:"severity" yaml:"severity"`
+	ErrorMessage    string             `json:"errorMessage,omitempty" yaml:"errorMessage,omitempty"`
+	MatchSpec       *MatchSpec         `json:"matchSpec" yaml:"matchSpec"`
+	RelatedLinks    []string           `json:"relatedLinks,omitempty" yaml:"relatedLinks,omitempty"`
+	Impact          string             `json:"impact,omitempty" yaml:"impact,omitempty"`
+	Resolution      string             `json:"resolution,omitempty" yaml:"resolution,omitempty"`
+	Fix             []remediation.Edit `json:"fix,omitempty" yaml:"fix,omitempty"`
+	// Frameworks maps the IDs of compliance frameworks, such as cis-aws-1.4, to the controls of each which the check covers
+	Frameworks map[string][]string `json:"frameworks,omitempty" yaml:"frameworks,omitempty"`
+	// BadExample and GoodExample are terraform which fails and passes the check, for its documentation
+	BadExample  string `json:"badExample,omitempty" yaml:"badExample,omitempty"`
+	GoodExample string `json:"goodExample,omitempty" yaml:"goodExample,omitempty"`
 }
 
 func (action *CheckAction) isValid() bool {
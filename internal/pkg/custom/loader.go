@@ -15,26 +15,61 @@ import (
 
 type ChecksFile struct {
 	Checks []*Check `json:"checks" yaml:"checks"`
+	path   string
 }
 
 func Load(customCheckDir string) error {
-	_, err := os.Stat(customCheckDir)
+	loader := NewLoader()
+	loadErr := loader.Add(customCheckDir, customCheckDir)
+	if err := loader.Register(); err != nil {
+		return errors.Join(loadErr, err)
+	}
+	return loadErr
+}
+
+// Loader loads custom checks from several sources, so that they can be checked for conflicts before any of
+// them are registered
+type Loader struct {
+	files       []ChecksFile
+	loadedFiles map[string]struct{}
+	definitions map[string]definition
+}
+
+type definition struct {
+	source string
+	path   string
+}
+
+func NewLoader() *Loader {
+	return &Loader{
+		loadedFiles: make(map[string]struct{}),
+		definitions: make(map[string]definition),
+	}
+}
+
+// Add loads the custom check files found in dir, which came from source (a directory or URL). Files which have
+// already been loaded from another source are skipped, and an error is returned if a check defines a code
+// which another source has already defined. A directory which does not exist is ignored.
+func (l *Loader) Add(source string, dir string) error {
+	_, err := os.Stat(dir)
 	if os.IsNotExist(err) {
 		return nil
 	} else if err != nil {
 		return err
 	}
 
-	return loadCustomChecks(customCheckDir)
-}
-
-func loadCustomChecks(customCheckDir string) error {
-	checkFiles, err := listFiles(customCheckDir, ".*_tfchecks.*")
+	checkFiles, err := listFiles(dir, ".*_tfchecks.*")
 	if err != nil {
 		return err
 	}
 	var errorList []string
 	for _, checkFilePath := range checkFiles {
+		if abs, err := filepath.Abs(checkFilePath); err == nil {
+			if _, ok := l.loadedFiles[abs]; ok {
+				continue
+			}
+			l.loadedFiles[abs] = struct{}{}
+		}
 		err = Validate(checkFilePath)
 		if err != nil {
 			errorList = append(errorList, err.Error())
@@ -46,7 +81,23 @@ func loadCustomChecks(customCheckDir string) error {
 			continue
 		}
 
-		ProcessFoundChecks(checks)
+		var conflicted bool
+		for _, check := range checks.Checks {
+			if existing, ok := l.definitions[check.Code]; ok && existing.source != source {
+				errorList = append(errorList, fmt.Sprintf("custom check '%s' in %s conflicts with the check of the same code in %s", check.Code, checkFilePath, existing.path))
+				conflicted = true
+				continue
+			}
+			l.definitions[check.Code] = definition{
+				source: source,
+				path:   checkFilePath,
+			}
+		}
+		if conflicted {
+			continue
+		}
+
+		l.files = append(l.files, checks)
 	}
 
 	if len(errorList) > 0 {
@@ -55,6 +106,30 @@ func loadCustomChecks(customCheckDir string) error {
 	return nil
 }
 
+// LongIDs returns the IDs of the checks which have been loaded but not yet registered
+func (l *Loader) LongIDs() []string {
+	var ids []string
+	for _, checks := range l.files {
+		for _, customCheck := range checks.Checks {
+			provider, service := providerAndService(customCheck)
+			ids = append(ids, strings.ToLower(fmt.Sprintf("%s-%s-%s", provider, service, customCheck.Code)))
+		}
+	}
+	return ids
+}
+
+// Register registers the checks from every file which was loaded without error
+func (l *Loader) Register() error {
+	var errs []error
+	for _, checks := range l.files {
+		if err := ProcessFoundChecks(checks); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	l.files = nil
+	return errors.Join(errs...)
+}
+
 func LoadCheckFile(checkFilePath string) (ChecksFile, error) {
 	var checks ChecksFile
 	checkFileContent, err := os.ReadFile(checkFilePath)
@@ -80,6 +155,7 @@ func LoadCheckFile(checkFilePath string) (ChecksFile, error) {
 	for _, check := range checks.Checks {
 		check.Severity = severity.StringToSeverity(string(check.Severity))
 	}
+	checks.path = checkFilePath
 	return checks, nil
 }
 
@@ -0,0 +1,48 @@
+package custom
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoaderRejectsConflictingCodesFromDifferentSources(t *testing.T) {
+	org := writeCheckFile(t, "org_tfchecks.yaml", "LDR001")
+	team := writeCheckFile(t, "team_tfchecks.yaml", "LDR002")
+	conflict := writeCheckFile(t, "conflict_tfchecks.yaml", "LDR001")
+
+	loader := NewLoader()
+	require.NoError(t, loader.Add("org", org))
+	require.NoError(t, loader.Add("team", team))
+	require.NoError(t, loader.Add("org again", org), "files which were already loaded should be skipped")
+
+	err := loader.Add("conflict", conflict)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "custom check 'LDR001'")
+	assert.Contains(t, err.Error(), filepath.Join(org, "org_tfchecks.yaml"))
+	assert.Len(t, loader.files, 2)
+}
+
+func writeCheckFile(t *testing.T, filename string, code string) string {
+	dir := t.TempDir()
+	content := `---
+checks:
+  - code: ` + code + `
+    description: Special resources must be ok
+    requiredTypes:
+      - resource
+    requiredLabels:
+      - special
+    severity: HIGH
+    matchSpec:
+      name: ok
+      action: equals
+      value: true
+    errorMessage: Not ok
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, filename), []byte(content), 0o600))
+	return dir
+}
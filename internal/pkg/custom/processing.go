@@ -1,17 +1,19 @@
 package custom
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
 
+	"github.com/aquasecurity/defsec/pkg/framework"
 	"github.com/aquasecurity/defsec/pkg/providers"
 	"github.com/zclconf/go-cty/cty"
 	"github.com/zclconf/go-cty/cty/gocty"
 
-	"github.com/aquasecurity/defsec/pkg/rules"
 	"github.com/aquasecurity/defsec/pkg/scan"
 	"github.com/aquasecurity/defsec/pkg/terraform"
+	"github.com/aquasecurity/tfsec/internal/pkg/remediation"
 )
 
 var matchFunctions = map[CheckAction]func(*terraform.Block, *MatchSpec, *customContext) bool{
@@ -235,50 +237,116 @@ var AttrMatchFunctions = map[CheckAction]func(*terraform.Attribute, *MatchSpec,
 	},
 }
 
-func ProcessFoundChecks(checks ChecksFile) {
+// ProcessFoundChecks registers the given checks. Checks which duplicate another in the same file, or one already
+// loaded from a different file, are not registered, and are reported in the returned error.
+func ProcessFoundChecks(checks ChecksFile) error {
+	var errorList []string
+	seen := make(map[string]struct{})
 	for _, customCheck := range checks.Checks {
-		provider := providers.CustomProvider
-		service := "custom"
-
-		if customCheck.Service != "" {
-			service = customCheck.Service
-		}
-
-		if customCheck.Provider != "" {
-			provider = providers.Provider(customCheck.Provider)
-		}
-
-		func(customCheck Check) {
-			rules.Register(scan.Rule{
-				Service:    service,
-				ShortCode:  customCheck.Code,
-				Summary:    customCheck.Description,
-				Impact:     customCheck.Impact,
-				Resolution: customCheck.Resolution,
-				Provider:   provider,
-				Links:      customCheck.RelatedLinks,
-				Severity:   customCheck.Severity,
-				CustomChecks: scan.CustomChecks{
-					Terraform: &scan.TerraformCustomCheck{
-						RequiredTypes:   customCheck.RequiredTypes,
-						RequiredLabels:  customCheck.RequiredLabels,
-						RequiredSources: customCheck.RequiredSources,
-						Check: func(rootBlock *terraform.Block, module *terraform.Module) (results scan.Results) {
-							matchSpec := customCheck.MatchSpec
-							if !evalMatchSpec(rootBlock, matchSpec, NewCustomContext(module)) {
-								results.Add(
-									fmt.Sprintf("Custom check failed for resource %s. %s", rootBlock.FullName(), customCheck.ErrorMessage),
-									rootBlock,
-								)
-							} else {
-								results.AddPassed(rootBlock)
-							}
-							return
-						},
-					},
+		provider, service := providerAndService(customCheck)
+		key := strings.ToLower(fmt.Sprintf("%s-%s-%s", provider, service, customCheck.Code))
+		if _, ok := seen[key]; ok {
+			errorList = append(errorList, fmt.Sprintf("custom check '%s' is defined more than once in %s", customCheck.Code, describePath(checks.path)))
+			continue
+		}
+		seen[key] = struct{}{}
+
+		registered, err := track(customCheck, service, provider, checks.path)
+		if err != nil {
+			errorList = append(errorList, err.Error())
+			continue
+		}
+
+		if len(customCheck.Fix) > 0 {
+			remediation.Register(registered.LongID(), remediation.Fix{
+				BlockTypes: customCheck.RequiredLabels,
+				Edits:      customCheck.Fix,
+			})
+		} else {
+			remediation.Unregister(registered.LongID())
+		}
+	}
+
+	if len(errorList) > 0 {
+		return errors.New(strings.Join(errorList, "\n"))
+	}
+	return nil
+}
+
+func providerAndService(customCheck *Check) (providers.Provider, string) {
+	provider := providers.CustomProvider
+	service := "custom"
+
+	if customCheck.Service != "" {
+		service = customCheck.Service
+	}
+
+	if customCheck.Provider != "" {
+		provider = providers.Provider(customCheck.Provider)
+	}
+	return provider, service
+}
+
+// ruleFrameworks returns the frameworks of a custom check in the form rules declare them. Rules are only run by
+// default when they are part of the default framework, so the check is always added to it.
+func ruleFrameworks(frameworks map[string][]string) map[framework.Framework][]string {
+	ruleFrameworks := map[framework.Framework][]string{framework.Default: nil}
+	for id, controls := range frameworks {
+		ruleFrameworks[framework.Framework(strings.ToLower(id))] = controls
+	}
+	return ruleFrameworks
+}
+
+// ruleExamples returns the examples of a custom check in the form rules declare them, or nil if it has none
+func ruleExamples(customCheck Check) *scan.EngineMetadata {
+	if customCheck.BadExample == "" && customCheck.GoodExample == "" {
+		return nil
+	}
+	examples := &scan.EngineMetadata{}
+	if customCheck.BadExample != "" {
+		examples.BadExamples = []string{customCheck.BadExample}
+	}
+	if customCheck.GoodExample != "" {
+		examples.GoodExamples = []string{customCheck.GoodExample}
+	}
+	return examples
+}
+
+// newRule builds the rule for a custom check. The check only runs against blocks for which enabled returns true.
+func newRule(customCheck Check, service string, provider providers.Provider, enabled func(*terraform.Block) bool) scan.Rule {
+	return scan.Rule{
+		Service:    service,
+		ShortCode:  customCheck.Code,
+		Summary:    customCheck.Description,
+		Impact:     customCheck.Impact,
+		Resolution: customCheck.Resolution,
+		Provider:   provider,
+		Links:      customCheck.RelatedLinks,
+		Severity:   customCheck.Severity,
+		Frameworks: ruleFrameworks(customCheck.Frameworks),
+		Terraform:  ruleExamples(customCheck),
+		CustomChecks: scan.CustomChecks{
+			Terraform: &scan.TerraformCustomCheck{
+				RequiredTypes:   customCheck.RequiredTypes,
+				RequiredLabels:  customCheck.RequiredLabels,
+				RequiredSources: customCheck.RequiredSources,
+				Check: func(rootBlock *terraform.Block, module *terraform.Module) (results scan.Results) {
+					if !enabled(rootBlock) {
+						return nil
+					}
+					matchSpec := customCheck.MatchSpec
+					if !evalMatchSpec(rootBlock, matchSpec, NewCustomContext(module)) {
+						results.Add(
+							fmt.Sprintf("Custom check failed for resource %s. %s", rootBlock.FullName(), customCheck.ErrorMessage),
+							rootBlock,
+						)
+					} else {
+						results.AddPassed(rootBlock)
+					}
+					return
 				},
-			}, nil)
-		}(*customCheck)
+			},
+		},
 	}
 }
 
@@ -978,7 +978,9 @@ func givenCheck(jsonContent string) {
 	if err != nil {
 		panic(err)
 	}
-	ProcessFoundChecks(checksfile)
+	if err := ProcessFoundChecks(checksfile); err != nil {
+		panic(err)
+	}
 }
 
 func scanTerraform(t *testing.T, mainTf string) scan.Results {
@@ -0,0 +1,210 @@
+package custom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aquasecurity/defsec/pkg/providers"
+	"github.com/aquasecurity/defsec/pkg/rules"
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/defsec/pkg/terraform"
+	"github.com/aquasecurity/tfsec/internal/pkg/remediation"
+)
+
+// RegisteredCheck is a custom check which has been registered with the rule registry
+type RegisteredCheck struct {
+	Code     string
+	Service  string
+	Provider string
+	// Path is the file the check was loaded from, if known
+	Path string
+	slot *checkSlot
+}
+
+// LongID returns the ID of the rule the check was registered as
+func (c RegisteredCheck) LongID() string {
+	return strings.ToLower(fmt.Sprintf("%s-%s-%s", c.Provider, c.Service, c.Code))
+}
+
+// checkSlot is the registered rule for one definition of a custom check. The rule registry does not support removing
+// rules, so each distinct definition is registered once, and loading, reloading and unregistering checks only changes
+// where the slots of their definitions are enabled: for every scan, or for the scans of some scopes. A check which is
+// reloaded without changes, or loaded again after being unregistered, re-uses its slot rather than adding a rule.
+type checkSlot struct {
+	sync.RWMutex
+	// active is set while the check is loaded for every scan
+	active atomic.Bool
+	scopes map[*Scope]struct{}
+}
+
+var checkSlots = struct {
+	sync.Mutex
+	slots map[string]*checkSlot
+	// byCheck finds the slot of a rule from the rule registry
+	byCheck map[*scan.TerraformCustomCheck]*checkSlot
+}{
+	slots:   make(map[string]*checkSlot),
+	byCheck: make(map[*scan.TerraformCustomCheck]*checkSlot),
+}
+
+// slotFor returns the slot of the check's definition, registering a rule for it the first time the definition is seen.
+// A definition which is not yet registered counts towards limit, if there is one.
+func slotFor(customCheck *Check, service string, provider providers.Provider, limit *RuleLimit) (*checkSlot, error) {
+	definition, err := json.Marshal(customCheck)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(definition)
+	longID := strings.ToLower(fmt.Sprintf("%s-%s-%s", provider, service, customCheck.Code))
+	key := longID + "@" + hex.EncodeToString(digest[:])
+
+	checkSlots.Lock()
+	defer checkSlots.Unlock()
+	slot, ok := checkSlots.slots[key]
+	if !ok {
+		if limit != nil {
+			if limit.added >= limit.max {
+				return nil, ErrRuleLimit
+			}
+			limit.added++
+		}
+		slot = &checkSlot{
+			scopes: make(map[*Scope]struct{}),
+		}
+		checkSlots.slots[key] = slot
+		rule := newRule(*customCheck, service, provider, slot.enabled)
+		checkSlots.byCheck[rule.CustomChecks.Terraform] = slot
+		rules.Register(rule, nil)
+	}
+	return slot, nil
+}
+
+func (s *checkSlot) enabled(block *terraform.Block) bool {
+	if s.active.Load() {
+		return true
+	}
+	fsys := block.GetMetadata().Range().GetFS()
+	s.RLock()
+	defer s.RUnlock()
+	for scope := range s.scopes {
+		if scope.fsys == fsys {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCustom returns whether a rule from the rule registry is a custom check, and if so, whether it is currently loaded
+// for every scan. Custom checks which have been unregistered or replaced stay in the rule registry, but are inactive.
+func IsCustom(rule scan.Rule) (custom bool, active bool) {
+	if rule.CustomChecks.Terraform == nil {
+		return false, false
+	}
+	checkSlots.Lock()
+	slot, ok := checkSlots.byCheck[rule.CustomChecks.Terraform]
+	checkSlots.Unlock()
+	if !ok {
+		return false, false
+	}
+	return true, slot.active.Load()
+}
+
+var registry = struct {
+	sync.Mutex
+	checks map[string]*RegisteredCheck
+}{
+	checks: make(map[string]*RegisteredCheck),
+}
+
+// track activates the slot of a check which is being loaded for every scan. A check with the same code, service and
+// provider which was loaded from the same file is replaced, so check files can be reloaded, but one loaded from a
+// different file is a duplicate and is rejected.
+func track(customCheck *Check, service string, provider providers.Provider, path string) (*RegisteredCheck, error) {
+	if path != "" {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+	}
+	check := &RegisteredCheck{
+		Code:     customCheck.Code,
+		Service:  service,
+		Provider: string(provider),
+		Path:     path,
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	existing, ok := registry.checks[check.LongID()]
+	if ok && (existing.Path != path || path == "") {
+		return nil, fmt.Errorf("custom check '%s' in %s duplicates the check '%s' already loaded from %s", customCheck.Code, describePath(path), existing.Code, describePath(existing.Path))
+	}
+
+	slot, err := slotFor(customCheck, service, provider, nil)
+	if err != nil {
+		return nil, fmt.Errorf("custom check '%s' in %s: %w", customCheck.Code, describePath(path), err)
+	}
+	if ok {
+		existing.slot.active.Store(false)
+	}
+	check.slot = slot
+	slot.active.Store(true)
+	registry.checks[check.LongID()] = check
+	return check, nil
+}
+
+func describePath(path string) string {
+	if path == "" {
+		return "an unknown file"
+	}
+	return path
+}
+
+// Registered returns the custom checks which are currently registered, ordered by ID
+func Registered() []RegisteredCheck {
+	registry.Lock()
+	defer registry.Unlock()
+
+	var checks []RegisteredCheck
+	for _, check := range registry.checks {
+		checks = append(checks, *check)
+	}
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].LongID() < checks[j].LongID()
+	})
+	return checks
+}
+
+// Unregister deactivates the custom check with the given ID, returning false if there was no such check
+func Unregister(longID string) bool {
+	registry.Lock()
+	defer registry.Unlock()
+
+	check, ok := registry.checks[strings.ToLower(longID)]
+	if !ok {
+		return false
+	}
+	check.slot.active.Store(false)
+	delete(registry.checks, check.LongID())
+	remediation.Unregister(check.LongID())
+	return true
+}
+
+// UnregisterAll deactivates every custom check, so that they can be loaded again from scratch
+func UnregisterAll() {
+	registry.Lock()
+	defer registry.Unlock()
+
+	for id, check := range registry.checks {
+		check.slot.active.Store(false)
+		delete(registry.checks, id)
+		remediation.Unregister(id)
+	}
+}
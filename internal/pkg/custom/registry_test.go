@@ -0,0 +1,114 @@
+package custom
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/aquasecurity/defsec/pkg/rules"
+	"github.com/aquasecurity/defsec/pkg/scan"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadingTheSameFileReplacesItsChecks(t *testing.T) {
+	dir := writeCheckFile(t, "reload_tfchecks.yaml", "REG001")
+	require.NoError(t, Load(dir))
+	require.NoError(t, Load(dir))
+
+	results := scanTerraform(t, `resource "special" "thing" { ok = false }`)
+	assert.Len(t, failuresFor(results, "custom-custom-reg001"), 1)
+}
+
+func TestDuplicateChecksFromDifferentFilesAreRejected(t *testing.T) {
+	first := writeCheckFile(t, "first_tfchecks.yaml", "REG002")
+	second := writeCheckFile(t, "second_tfchecks.yaml", "REG002")
+	require.NoError(t, Load(first))
+
+	err := Load(second)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), filepath.Join(first, "first_tfchecks.yaml"))
+	assert.Contains(t, err.Error(), filepath.Join(second, "second_tfchecks.yaml"))
+}
+
+func TestDuplicateChecksInOneFileAreRejected(t *testing.T) {
+	check := &Check{
+		Code:           "REG003",
+		RequiredTypes:  []string{"resource"},
+		RequiredLabels: []string{"unmatched"},
+		MatchSpec:      &MatchSpec{Name: "ok", Action: IsPresent},
+	}
+	checks := ChecksFile{
+		Checks: []*Check{check, check},
+		path:   "duplicates_tfchecks.yaml",
+	}
+	err := ProcessFoundChecks(checks)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "custom check 'REG003' is defined more than once in duplicates_tfchecks.yaml")
+}
+
+func TestUnregisteredChecksProduceNoResults(t *testing.T) {
+	dir := writeCheckFile(t, "unregister_tfchecks.yaml", "REG004")
+	require.NoError(t, Load(dir))
+
+	var found bool
+	for _, check := range Registered() {
+		if check.LongID() == "custom-custom-reg004" {
+			found = true
+			assert.Equal(t, filepath.Join(dir, "unregister_tfchecks.yaml"), check.Path)
+		}
+	}
+	assert.True(t, found)
+
+	require.True(t, Unregister("custom-custom-REG004"))
+	assert.False(t, Unregister("custom-custom-REG004"))
+
+	results := scanTerraform(t, `resource "special" "thing" { ok = false }`)
+	assert.Empty(t, failuresFor(results, "custom-custom-reg004"))
+
+	require.NoError(t, Load(dir), "an unregistered check can be loaded again")
+}
+
+func TestReloadingChecksDoesNotGrowTheRuleRegistry(t *testing.T) {
+	dir := writeCheckFile(t, "growth_tfchecks.yaml", "REG005")
+	require.NoError(t, Load(dir))
+	registered := len(rules.GetRegistered())
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, Load(dir))
+		UnregisterAll()
+		require.NoError(t, Load(dir))
+	}
+	assert.Len(t, rules.GetRegistered(), registered)
+
+	results := scanTerraform(t, `resource "special" "thing" { ok = false }`)
+	assert.Len(t, failuresFor(results, "custom-custom-reg005"), 1)
+
+	custom, active := IsCustom(ruleFor(t, "custom-custom-reg005"))
+	assert.True(t, custom)
+	assert.True(t, active)
+
+	UnregisterAll()
+	_, active = IsCustom(ruleFor(t, "custom-custom-reg005"))
+	assert.False(t, active)
+}
+
+func ruleFor(t *testing.T, longID string) scan.Rule {
+	for _, rule := range rules.GetRegistered() {
+		if rule.Rule().LongID() == longID {
+			return rule.Rule()
+		}
+	}
+	t.Fatalf("rule %s is not registered", longID)
+	return scan.Rule{}
+}
+
+func failuresFor(results scan.Results, longID string) scan.Results {
+	var matching scan.Results
+	for _, result := range results.GetFailed() {
+		if result.Rule().LongID() == longID {
+			matching = append(matching, result)
+		}
+	}
+	return matching
+}
@@ -0,0 +1,111 @@
+package custom
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// Scope is a set of custom checks which only apply to a single scan, so that checks can be loaded for one scan
+// without affecting any others running at the same time. Checks in a scope only run against blocks which were read
+// from the scope's filesystem, so the filesystem must be comparable and must not be shared with other scans, such
+// as one returned by extrafs.OSDir.
+type Scope struct {
+	fsys  fs.FS
+	slots []*checkSlot
+	limit *RuleLimit
+}
+
+// NewScope creates an empty scope for scans of fsys
+func NewScope(fsys fs.FS) *Scope {
+	return &Scope{
+		fsys: fsys,
+	}
+}
+
+// NewLimitedScope creates an empty scope for scans of fsys, whose checks may only add rules to the rule registry
+// while limit allows it
+func NewLimitedScope(fsys fs.FS, limit *RuleLimit) *Scope {
+	return &Scope{
+		fsys:  fsys,
+		limit: limit,
+	}
+}
+
+// ErrRuleLimit is returned when registering a scoped check would add more rules to the rule registry than the
+// scope's RuleLimit allows
+var ErrRuleLimit = errors.New("too many distinct custom checks have been registered")
+
+// RuleLimit bounds the number of rules which the scopes sharing it can add to the rule registry. Rules can't be removed
+// from the registry, so each distinct definition of a check stays registered after its scope is closed, and a
+// long-running process which registers checks sent to it would otherwise grow without bound. Definitions which are
+// already registered can still be used once the limit has been reached.
+type RuleLimit struct {
+	max int
+	// added is guarded by the lock of checkSlots
+	added int
+}
+
+// NewRuleLimit creates a limit allowing max rules to be added to the rule registry
+func NewRuleLimit(max int) *RuleLimit {
+	return &RuleLimit{
+		max: max,
+	}
+}
+
+// FS returns the filesystem which the scope's checks apply to
+func (s *Scope) FS() fs.FS {
+	return s.fsys
+}
+
+// Close removes the scope's checks, which will no longer run in any scan
+func (s *Scope) Close() {
+	for _, slot := range s.slots {
+		slot.Lock()
+		delete(slot.scopes, s)
+		slot.Unlock()
+	}
+	s.slots = nil
+}
+
+// RegisterScoped registers the checks from every file which was loaded without error, so that they only apply to
+// scans of the scope's filesystem. Checks which duplicate another in the scope are not registered.
+func (l *Loader) RegisterScoped(scope *Scope) error {
+	var errorList []string
+	seen := make(map[string]string)
+	for _, checks := range l.files {
+		for _, customCheck := range checks.Checks {
+			provider, service := providerAndService(customCheck)
+			longID := strings.ToLower(fmt.Sprintf("%s-%s-%s", provider, service, customCheck.Code))
+			if existing, ok := seen[longID]; ok {
+				errorList = append(errorList, fmt.Sprintf("custom check '%s' in %s duplicates the check '%s' already loaded from %s", customCheck.Code, describePath(checks.path), customCheck.Code, describePath(existing)))
+				continue
+			}
+			seen[longID] = checks.path
+
+			// scopes share the slots of checks with exactly the same definition, which keeps the rule registry from
+			// growing with every scan
+			slot, err := slotFor(customCheck, service, provider, scope.limit)
+			if errors.Is(err, ErrRuleLimit) {
+				l.files = nil
+				return fmt.Errorf("custom check '%s' in %s: %w", customCheck.Code, describePath(checks.path), err)
+			}
+			if err != nil {
+				errorList = append(errorList, fmt.Sprintf("custom check '%s' in %s: %s", customCheck.Code, describePath(checks.path), err))
+				continue
+			}
+
+			slot.Lock()
+			slot.scopes[scope] = struct{}{}
+			slot.Unlock()
+			scope.slots = append(scope.slots, slot)
+		}
+	}
+	l.files = nil
+
+	if len(errorList) > 0 {
+		return errors.New(strings.Join(errorList, "\n"))
+	}
+	return nil
+}
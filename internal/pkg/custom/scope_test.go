@@ -0,0 +1,109 @@
+package custom
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+
+	"github.com/aquasecurity/defsec/pkg/framework"
+	"github.com/aquasecurity/defsec/pkg/rules"
+	"github.com/aquasecurity/defsec/pkg/scan"
+	scanner "github.com/aquasecurity/defsec/pkg/scanners/terraform"
+	"github.com/liamg/memoryfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopedChecksOnlyApplyToTheirOwnScans(t *testing.T) {
+	first := scopedFS(t)
+	second := scopedFS(t)
+
+	firstScope := NewScope(first)
+	defer firstScope.Close()
+	loader := NewLoader()
+	require.NoError(t, loader.Add("first", writeCheckFile(t, "first_tfchecks.yaml", "SCP001")))
+	require.NoError(t, loader.RegisterScoped(firstScope))
+
+	assert.Len(t, failuresFor(scanScope(t, first), "custom-custom-scp001"), 1)
+	assert.Empty(t, failuresFor(scanScope(t, second), "custom-custom-scp001"))
+	assert.Empty(t, failuresFor(scanTerraform(t, `resource "special" "thing" { ok = false }`), "custom-custom-scp001"))
+}
+
+func TestScopesShareRulesForIdenticalChecks(t *testing.T) {
+	first := scopedFS(t)
+	second := scopedFS(t)
+
+	firstScope := NewScope(first)
+	defer firstScope.Close()
+	firstLoader := NewLoader()
+	require.NoError(t, firstLoader.Add("first", writeCheckFile(t, "first_tfchecks.yaml", "SCP002")))
+	require.NoError(t, firstLoader.RegisterScoped(firstScope))
+
+	secondScope := NewScope(second)
+	defer secondScope.Close()
+	secondLoader := NewLoader()
+	require.NoError(t, secondLoader.Add("second", writeCheckFile(t, "second_tfchecks.yaml", "SCP002")))
+	require.NoError(t, secondLoader.RegisterScoped(secondScope))
+
+	assert.Len(t, failuresFor(scanScope(t, first), "custom-custom-scp002"), 1)
+	assert.Len(t, failuresFor(scanScope(t, second), "custom-custom-scp002"), 1)
+
+	var registered int
+	for _, rule := range rules.GetRegistered(framework.ALL) {
+		if rule.Rule().LongID() == "custom-custom-scp002" {
+			registered++
+		}
+	}
+	assert.Equal(t, 1, registered)
+}
+
+func TestClosedScopesProduceNoResults(t *testing.T) {
+	fsys := scopedFS(t)
+
+	scope := NewScope(fsys)
+	loader := NewLoader()
+	require.NoError(t, loader.Add("closed", writeCheckFile(t, "closed_tfchecks.yaml", "SCP003")))
+	require.NoError(t, loader.RegisterScoped(scope))
+	scope.Close()
+
+	assert.Empty(t, failuresFor(scanScope(t, fsys), "custom-custom-scp003"))
+}
+
+func TestRuleLimitBoundsNewDefinitions(t *testing.T) {
+	limit := NewRuleLimit(1)
+
+	first := NewLimitedScope(scopedFS(t), limit)
+	defer first.Close()
+	firstLoader := NewLoader()
+	require.NoError(t, firstLoader.Add("first", writeCheckFile(t, "first_tfchecks.yaml", "SCP004")))
+	require.NoError(t, firstLoader.RegisterScoped(first))
+
+	// the same definition re-uses its rule, so doesn't count towards the limit again
+	second := NewLimitedScope(scopedFS(t), limit)
+	defer second.Close()
+	secondLoader := NewLoader()
+	require.NoError(t, secondLoader.Add("second", writeCheckFile(t, "second_tfchecks.yaml", "SCP004")))
+	require.NoError(t, secondLoader.RegisterScoped(second))
+
+	third := NewLimitedScope(scopedFS(t), limit)
+	defer third.Close()
+	thirdLoader := NewLoader()
+	require.NoError(t, thirdLoader.Add("third", writeCheckFile(t, "third_tfchecks.yaml", "SCP005")))
+	assert.ErrorIs(t, thirdLoader.RegisterScoped(third), ErrRuleLimit)
+
+	for _, rule := range rules.GetRegistered(framework.ALL) {
+		assert.NotEqual(t, "custom-custom-scp005", rule.Rule().LongID())
+	}
+}
+
+func scopedFS(t *testing.T) fs.FS {
+	f := memoryfs.New()
+	require.NoError(t, f.WriteFile("main.tf", []byte(`resource "special" "thing" { ok = false }`), 0o600))
+	return f
+}
+
+func scanScope(t *testing.T, fsys fs.FS) scan.Results {
+	results, err := scanner.New().ScanFS(context.TODO(), fsys, ".")
+	require.NoError(t, err)
+	return results
+}
@@ -68,6 +68,16 @@ func validate(check *Check) []error {
 	if len(check.RequiredLabels) == 0 {
 		checkErrors = append(checkErrors, errors.New("check.RequiredLabels requires a value"))
 	}
+	for id, controls := range check.Frameworks {
+		if id == "" || len(controls) == 0 {
+			checkErrors = append(checkErrors, fmt.Errorf("check.Frameworks[%s] requires a framework ID and at least one control", id))
+		}
+	}
+	for _, edit := range check.Fix {
+		if err := edit.Validate(); err != nil {
+			checkErrors = append(checkErrors, err)
+		}
+	}
 	return validateMatchSpec(check.MatchSpec, check, checkErrors)
 }
 
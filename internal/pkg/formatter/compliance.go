@@ -0,0 +1,79 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/formatters"
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/tfsec/internal/pkg/compliance"
+	"github.com/aquasecurity/tfsec/internal/pkg/metrics"
+	"github.com/aquasecurity/tfsec/internal/pkg/statistics"
+	"github.com/liamg/tml"
+)
+
+var complianceStatusFormat = map[compliance.Status]string{
+	compliance.StatusPassed:       "<green>%-14s</green>",
+	compliance.StatusFailed:       "<red><bold>%-14s</bold></red>",
+	compliance.StatusNotEvaluated: "<dim>%-14s</dim>",
+}
+
+func printCompliance(w io.Writer, report *compliance.Report) {
+	framework := report.Framework
+	if report.Name != "" {
+		framework = fmt.Sprintf("%s (%s)", report.Name, report.Framework)
+	}
+	printTitle(w, "compliance")
+	printValue(w, "framework", framework)
+	printValue(w, "controls", fmt.Sprintf("%d passed, %d failed, %d not evaluated", report.Passed, report.Failed, report.NotEvaluated))
+	_, _ = fmt.Fprintf(w, "\n")
+
+	for _, control := range report.Controls {
+		status := tml.Sprintf(complianceStatusFormat[control.Status], strings.ToUpper(string(control.Status)))
+		_ = tml.Fprintf(w, "  %s %-8s %s\n", status, control.ID, control.Title)
+		if control.Status != compliance.StatusFailed {
+			continue
+		}
+		for _, check := range control.Checks {
+			if check.Failed > 0 {
+				_ = tml.Fprintf(w, "  %-14s <dim>%-8s %s: %d failed, %d passed</dim>\n", "", "", check.ID, check.Failed, check.Passed)
+			}
+		}
+	}
+	_, _ = fmt.Fprintf(w, "\n")
+}
+
+// JSON writes the results in the same form as the built in JSON format, with the compliance report, statistics and
+// rule profile alongside them
+func JSON(report *compliance.Report, stats *statistics.Report, profile *metrics.Profile) func(b formatters.ConfigurableFormatter, results scan.Results) error {
+	return func(b formatters.ConfigurableFormatter, results scan.Results) error {
+		flatResults := []scan.FlatResult{}
+		for _, result := range results {
+			switch result.Status() {
+			case scan.StatusIgnored:
+				if !b.IncludeIgnored() {
+					continue
+				}
+			case scan.StatusPassed:
+				if !b.IncludePassed() {
+					continue
+				}
+			}
+			flat := result.Flatten()
+			flat.Links = b.GetLinks(result)
+			flat.Location.Filename = b.Path(result, result.Metadata())
+			flatResults = append(flatResults, flat)
+		}
+
+		encoder := json.NewEncoder(b.Writer())
+		encoder.SetIndent("", "\t")
+		return encoder.Encode(struct {
+			Results    []scan.FlatResult  `json:"results"`
+			Compliance *compliance.Report `json:"compliance,omitempty"`
+			Statistics *statistics.Report `json:"statistics,omitempty"`
+			Profile    *metrics.Profile   `json:"profile,omitempty"`
+		}{flatResults, report, stats, profile})
+	}
+}
@@ -1,8 +1,10 @@
 package formatter
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"path/filepath"
 	"strings"
 
@@ -11,13 +13,16 @@ import (
 
 	"github.com/aquasecurity/defsec/pkg/formatters"
 	"github.com/aquasecurity/defsec/pkg/severity"
+	"github.com/aquasecurity/tfsec/internal/pkg/compliance"
+	tfsecmetrics "github.com/aquasecurity/tfsec/internal/pkg/metrics"
+	"github.com/aquasecurity/tfsec/internal/pkg/statistics"
 	"github.com/liamg/clinch/terminal"
 	"github.com/liamg/tml"
 )
 
 var severityFormat map[severity.Severity]string
 
-func DefaultWithMetrics(metrics scanner.Metrics, conciseOutput bool, codeTheme string, withColours bool, noCode bool) func(b formatters.ConfigurableFormatter, results scan.Results) error {
+func DefaultWithMetrics(metrics scanner.Metrics, conciseOutput bool, codeTheme string, withColours bool, noCode bool, report *compliance.Report, stats *statistics.Report, profile *tfsecmetrics.Profile) func(b formatters.ConfigurableFormatter, results scan.Results) error {
 	return func(b formatters.ConfigurableFormatter, results scan.Results) error {
 
 		// turn on no-code if consise output required
@@ -46,6 +51,15 @@ func DefaultWithMetrics(metrics scanner.Metrics, conciseOutput bool, codeTheme s
 			if !conciseOutput {
 				printMetrics(b.Writer(), metrics)
 			}
+			if report != nil {
+				printCompliance(b.Writer(), report)
+			}
+			if stats != nil {
+				printStatistics(b.Writer(), stats)
+			}
+			if profile != nil {
+				printProfile(b.Writer(), profile)
+			}
 
 			_ = tml.Fprintf(b.Writer(), "\n<green><bold>No problems detected!\n\n")
 			return nil
@@ -64,6 +78,15 @@ func DefaultWithMetrics(metrics scanner.Metrics, conciseOutput bool, codeTheme s
 		if !conciseOutput {
 			printMetrics(b.Writer(), metrics)
 		}
+		if report != nil {
+			printCompliance(b.Writer(), report)
+		}
+		if stats != nil {
+			printStatistics(b.Writer(), stats)
+		}
+		if profile != nil {
+			printProfile(b.Writer(), profile)
+		}
 
 		var passInfo string
 		if passCount := len(results.GetPassed()); passCount > 0 {
@@ -222,7 +245,10 @@ func printResult(b formatters.ConfigurableFormatter, group formatters.GroupedRes
 			strings.Repeat("─", width),
 		)
 		if !noCode {
-			if err := highlightCode(b, first, theme, withColours); err != nil {
+			if err := highlightCode(b, first, theme, withColours); errors.Is(err, fs.ErrNotExist) {
+				// the source of results rendered from a saved report may not be available
+				_ = tml.Fprintf(w, "  <dim>Code unavailable: %s not found</dim>\n", b.Path(first, first.Metadata()))
+			} else if err != nil {
 				_, _ = fmt.Fprintf(w, tml.Sprintf("  <red><bold>Failed to render code:</bold> %s", err))
 			}
 
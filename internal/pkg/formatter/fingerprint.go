@@ -0,0 +1,37 @@
+package formatter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/scan"
+)
+
+// fingerprint identifies a result by its rule, file and resource, so that it can be matched across commits even
+// when lines move. Each instance of a resource created with count or for_each, or by a module called more than once,
+// has its own fingerprint.
+func fingerprint(res scan.Result, path string) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%s|%s", res.Rule().LongID(), filepath.ToSlash(path), Address(res), res.Metadata().Reference())))
+	return hex.EncodeToString(hash[:])
+}
+
+// Address is the address of the resource instance a result is for, with the modules it is in, such as
+// module.buckets["logs"].aws_s3_bucket.this[0]
+func Address(res scan.Result) string {
+	var modules []string
+	metadata := res.Metadata()
+	resource := metadata.Reference()
+	for parent := metadata.Parent(); parent != nil; parent = parent.Parent() {
+		reference := parent.Reference()
+		if strings.HasPrefix(reference, "module.") {
+			modules = append([]string{reference}, modules...)
+		} else if len(modules) == 0 {
+			// the topmost block below the modules is the resource, which has the count or for_each index
+			resource = reference
+		}
+	}
+	return strings.Join(append(modules, resource), ".")
+}
@@ -0,0 +1,223 @@
+package formatter
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/aquasecurity/defsec/pkg/formatters"
+	"github.com/aquasecurity/defsec/pkg/scan"
+	scanner "github.com/aquasecurity/defsec/pkg/scanners/terraform"
+	"github.com/aquasecurity/defsec/pkg/severity"
+	"github.com/aquasecurity/tfsec/version"
+	"github.com/google/uuid"
+)
+
+// codeQualityIssue is an issue in the subset of the Code Climate format which GitLab reads for its code quality
+// widget. See https://docs.gitlab.com/ee/ci/testing/code_quality.html#implement-a-custom-tool
+type codeQualityIssue struct {
+	Type        string              `json:"type"`
+	CheckName   string              `json:"check_name"`
+	Description string              `json:"description"`
+	Categories  []string            `json:"categories"`
+	Fingerprint string              `json:"fingerprint"`
+	Severity    string              `json:"severity"`
+	Location    codeQualityLocation `json:"location"`
+}
+
+type codeQualityLocation struct {
+	Path  string           `json:"path"`
+	Lines codeQualityLines `json:"lines"`
+}
+
+type codeQualityLines struct {
+	Begin int `json:"begin"`
+	End   int `json:"end"`
+}
+
+// GitLabCodeQuality writes the failed results as a GitLab code quality report
+func GitLabCodeQuality() func(b formatters.ConfigurableFormatter, results scan.Results) error {
+	return func(b formatters.ConfigurableFormatter, results scan.Results) error {
+		issues := []codeQualityIssue{}
+		for _, res := range results.GetFailed() {
+			path := b.Path(res, res.Metadata())
+			issues = append(issues, codeQualityIssue{
+				Type:        "issue",
+				CheckName:   res.Rule().LongID(),
+				Description: res.Description(),
+				Categories:  []string{"Security"},
+				Fingerprint: fingerprint(res, path),
+				Severity:    codeQualitySeverity(res.Severity()),
+				Location: codeQualityLocation{
+					Path: path,
+					Lines: codeQualityLines{
+						Begin: res.Range().GetStartLine(),
+						End:   res.Range().GetEndLine(),
+					},
+				},
+			})
+		}
+
+		encoder := json.NewEncoder(b.Writer())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(issues)
+	}
+}
+
+func codeQualitySeverity(sev severity.Severity) string {
+	switch sev {
+	case severity.Critical:
+		return "blocker"
+	case severity.High:
+		return "critical"
+	case severity.Medium:
+		return "major"
+	case severity.Low:
+		return "minor"
+	default:
+		return "info"
+	}
+}
+
+// gitLabSASTSchemaVersion is the version of the GitLab security report schema the SAST report conforms to. See
+// https://gitlab.com/gitlab-org/security-products/security-report-schemas
+const gitLabSASTSchemaVersion = "15.0.7"
+
+// gitLabTimeFormat is the format of the times in a GitLab security report, which have no time zone
+const gitLabTimeFormat = "2006-01-02T15:04:05"
+
+type sastReport struct {
+	Version         string              `json:"version"`
+	Scan            sastScan            `json:"scan"`
+	Vulnerabilities []sastVulnerability `json:"vulnerabilities"`
+}
+
+type sastScan struct {
+	Analyzer  sastTool `json:"analyzer"`
+	Scanner   sastTool `json:"scanner"`
+	Type      string   `json:"type"`
+	StartTime string   `json:"start_time"`
+	EndTime   string   `json:"end_time"`
+	Status    string   `json:"status"`
+}
+
+type sastTool struct {
+	ID      string     `json:"id"`
+	Name    string     `json:"name"`
+	URL     string     `json:"url"`
+	Version string     `json:"version"`
+	Vendor  sastVendor `json:"vendor"`
+}
+
+type sastVendor struct {
+	Name string `json:"name"`
+}
+
+type sastVulnerability struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Severity    string           `json:"severity"`
+	Solution    string           `json:"solution,omitempty"`
+	Location    sastLocation     `json:"location"`
+	Identifiers []sastIdentifier `json:"identifiers"`
+	Links       []sastLink       `json:"links,omitempty"`
+}
+
+type sastLocation struct {
+	File      string `json:"file"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+}
+
+type sastIdentifier struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	URL   string `json:"url,omitempty"`
+}
+
+type sastLink struct {
+	URL string `json:"url"`
+}
+
+// GitLabSAST writes the failed results as a GitLab SAST security report, for the security widget of merge requests
+func GitLabSAST(metrics scanner.Metrics) func(b formatters.ConfigurableFormatter, results scan.Results) error {
+	return func(b formatters.ConfigurableFormatter, results scan.Results) error {
+		tfsecVersion := version.Version
+		if tfsecVersion == "" {
+			tfsecVersion = "dev"
+		}
+		tool := sastTool{
+			ID:      "tfsec",
+			Name:    "tfsec",
+			URL:     "https://github.com/aquasecurity/tfsec",
+			Version: tfsecVersion,
+			Vendor:  sastVendor{Name: "Aqua Security"},
+		}
+		end := time.Now()
+		report := sastReport{
+			Version: gitLabSASTSchemaVersion,
+			Scan: sastScan{
+				Analyzer:  tool,
+				Scanner:   tool,
+				Type:      "sast",
+				StartTime: end.Add(-metrics.Timings.Total).Format(gitLabTimeFormat),
+				EndTime:   end.Format(gitLabTimeFormat),
+				Status:    "success",
+			},
+			Vulnerabilities: []sastVulnerability{},
+		}
+
+		for _, res := range results.GetFailed() {
+			path := b.Path(res, res.Metadata())
+			rule := res.Rule()
+			links := b.GetLinks(res)
+
+			identifier := sastIdentifier{
+				Type:  "tfsec_rule_id",
+				Name:  rule.LongID(),
+				Value: rule.LongID(),
+			}
+			if len(links) > 0 {
+				identifier.URL = links[0]
+			}
+			vulnerability := sastVulnerability{
+				// the ID must be a UUID, and GitLab tracks vulnerabilities between pipelines by it
+				ID:          uuid.NewSHA1(uuid.NameSpaceOID, []byte(fingerprint(res, path))).String(),
+				Name:        rule.Summary,
+				Description: res.Description(),
+				Severity:    sastSeverity(res.Severity()),
+				Solution:    rule.Resolution,
+				Location: sastLocation{
+					File:      path,
+					StartLine: res.Range().GetStartLine(),
+					EndLine:   res.Range().GetEndLine(),
+				},
+				Identifiers: []sastIdentifier{identifier},
+			}
+			for _, link := range links {
+				vulnerability.Links = append(vulnerability.Links, sastLink{URL: link})
+			}
+			report.Vulnerabilities = append(report.Vulnerabilities, vulnerability)
+		}
+
+		encoder := json.NewEncoder(b.Writer())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+}
+
+func sastSeverity(sev severity.Severity) string {
+	switch sev {
+	case severity.Critical:
+		return "Critical"
+	case severity.High:
+		return "High"
+	case severity.Medium:
+		return "Medium"
+	case severity.Low:
+		return "Low"
+	default:
+		return "Unknown"
+	}
+}
@@ -1,42 +1,117 @@
 package formatter
 
 import (
+	"bytes"
+	_ "embed"
 	"fmt"
+	"html/template"
 	"sort"
 	"strings"
+	"time"
 
-	"github.com/aquasecurity/defsec/pkg/severity"
-
+	"github.com/alecthomas/chroma"
+	chromahtml "github.com/alecthomas/chroma/formatters/html"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/alecthomas/chroma/styles"
 	"github.com/aquasecurity/defsec/pkg/formatters"
 	"github.com/aquasecurity/defsec/pkg/scan"
+	scanner "github.com/aquasecurity/defsec/pkg/scanners/terraform"
+	"github.com/aquasecurity/defsec/pkg/severity"
+	"github.com/aquasecurity/tfsec/internal/pkg/compliance"
+	"github.com/aquasecurity/tfsec/version"
 )
 
-func HTML() func(b formatters.ConfigurableFormatter, results scan.Results) error {
-	return func(b formatters.ConfigurableFormatter, results scan.Results) error {
+//go:embed html.tmpl
+var htmlTemplateSource string
 
-		// html header
-		_, _ = fmt.Fprintln(b.Writer(), `<!DOCTYPE html>
-<html lang="en">
-  <head>
-    <meta charset="utf-8">
-    <title>tfsec - Results</title>
-    <style>
-      * { background-color: #222222; color: #ffffff; }
-      body { font-size: 20px; } 
-      .severity { color: #777777; }
-      .CRITICAL { font-weight: bold; color: #cc1111;}
-      .HIGH { color: #ff0000; }
-      .MEDIUM { color: #ff6600; }
-      .LOW { color: #ffff00; }
-      table { border: 1px solid #555555; }
-      thead tr th {
-        background-color: #555555;
-        padding: 5px 10px 5px 10px;
-      }
-      tbody tr td { padding: 5px 10px 5px 10px; }
-    </style>
-  </head>
-  <body>`)
+var htmlTemplate = template.Must(template.New("report").Parse(htmlTemplateSource))
+
+var severityScores = map[severity.Severity]uint8{
+	severity.None:     0,
+	severity.Low:      1,
+	severity.Medium:   2,
+	severity.High:     3,
+	severity.Critical: 4,
+}
+
+var statusScores = map[scan.Status]uint8{
+	scan.StatusPassed:  0,
+	scan.StatusIgnored: 1,
+	scan.StatusFailed:  2,
+}
+
+type htmlReport struct {
+	Version   string
+	Generated string
+	Light     bool
+	CodeCSS   template.CSS
+	Failed    int
+	Passed    int
+	Ignored   int
+	Severity  []htmlBar
+	Timings   []htmlBar
+	Counts    []htmlCount
+	Findings  []htmlFinding
+	Filters   []htmlFilter
+	// Compliance is the outcome of each control of the framework the scan was restricted to, if any
+	Compliance *compliance.Report
+}
+
+type htmlBar struct {
+	Label   string
+	Class   string
+	Value   string
+	Percent int
+}
+
+type htmlCount struct {
+	Label string
+	Value int
+}
+
+// htmlFilter is a dropdown which filters the findings by one of their attributes
+type htmlFilter struct {
+	Name    string
+	Label   string
+	Options []string
+}
+
+type htmlFinding struct {
+	ID            string
+	Link          string
+	Severity      string
+	SeverityScore uint8
+	Status        string
+	StatusScore   uint8
+	Provider      string
+	Service       string
+	Summary       string
+	Description   string
+	File          string
+	Location      string
+	Impact        string
+	Resolution    string
+	Links         []string
+	Occurrences   []string
+	Code          []htmlCodeLine
+}
+
+type htmlCodeLine struct {
+	Number     int
+	Truncated  bool
+	IsCause    bool
+	Annotation string
+	Tokens     []htmlToken
+}
+
+type htmlToken struct {
+	Class string
+	Text  string
+}
+
+// HTML writes a single file report, with the CSS and JavaScript to filter, sort and expand the findings embedded
+func HTML(metrics scanner.Metrics, codeTheme string, report *compliance.Report) func(b formatters.ConfigurableFormatter, results scan.Results) error {
+	return func(b formatters.ConfigurableFormatter, results scan.Results) error {
 
 		filtered := results.GetFailed()
 		if b.IncludePassed() {
@@ -46,92 +121,276 @@ func HTML() func(b formatters.ConfigurableFormatter, results scan.Results) error
 			filtered = append(filtered, results.GetIgnored()...)
 		}
 
-		if len(filtered) == 0 {
-			_, _ = fmt.Fprintf(b.Writer(), "<i>No problems detected!</i>")
-			return nil
+		sort.SliceStable(filtered, func(i, j int) bool {
+			if statusI, statusJ := statusScores[filtered[i].Status()], statusScores[filtered[j].Status()]; statusI != statusJ {
+				return statusI > statusJ
+			}
+			scoreI := severityScores[filtered[i].Severity()]
+			scoreJ := severityScores[filtered[j].Severity()]
+			if scoreI == scoreJ {
+				return filtered[i].Rule().LongID() < filtered[j].Rule().LongID()
+			}
+			return scoreI > scoreJ
+		})
+
+		style := codeStyle(codeTheme)
+		css := bytes.NewBuffer(nil)
+		if err := chromahtml.New(chromahtml.WithClasses(true)).WriteCSS(css, style); err != nil {
+			return err
+		}
+
+		tfsecVersion := version.Version
+		if tfsecVersion == "" {
+			tfsecVersion = "dev"
+		}
+		report := htmlReport{
+			Version:   tfsecVersion,
+			Generated: time.Now().Format(time.RFC1123),
+			Light:     codeTheme == "light",
+			// the CSS is generated by chroma from a built in style rather than from anything in the results
+			CodeCSS: template.CSS(css.String()), // nolint: gosec
+			Failed:  len(results.GetFailed()),
+			Passed:  len(results.GetPassed()),
+			Ignored: len(results.GetIgnored()),
+			// the compliance report is only shown when the scan was restricted to a framework
+			Compliance: report,
 		}
+		report.Severity, report.Timings, report.Counts = htmlCharts(metrics)
 
-		printResultsHTML(b, filtered)
+		values := map[string]map[string]bool{}
+		for _, res := range filtered {
+			finding := htmlFindingFor(b, res)
+			report.Findings = append(report.Findings, finding)
+			for name, value := range map[string]string{
+				"severity": finding.Severity,
+				"status":   finding.Status,
+				"provider": finding.Provider,
+				"service":  finding.Service,
+				"file":     finding.File,
+			} {
+				if values[name] == nil {
+					values[name] = map[string]bool{}
+				}
+				values[name][value] = true
+			}
+		}
+		for _, filter := range []htmlFilter{
+			{Name: "severity", Label: "Severity"},
+			{Name: "status", Label: "Status"},
+			{Name: "provider", Label: "Provider"},
+			{Name: "service", Label: "Service"},
+			{Name: "file", Label: "File"},
+		} {
+			for value := range values[filter.Name] {
+				filter.Options = append(filter.Options, value)
+			}
+			sort.Strings(filter.Options)
+			report.Filters = append(report.Filters, filter)
+		}
 
-		// html footer
-		_, _ = fmt.Fprintln(b.Writer(), `  </body>
-</html>`)
+		return htmlTemplate.Execute(b.Writer(), report)
+	}
+}
 
-		return nil
+func htmlFindingFor(b formatters.ConfigurableFormatter, res scan.Result) htmlFinding {
+	rule := res.Rule()
+	path := b.Path(res, res.Metadata())
+	location := fmt.Sprintf("%s:%d", path, res.Range().GetStartLine())
+	if res.Range().GetEndLine() > res.Range().GetStartLine() {
+		location = fmt.Sprintf("%s-%d", location, res.Range().GetEndLine())
+	}
 
+	finding := htmlFinding{
+		ID:            rule.LongID(),
+		Severity:      string(res.Severity()),
+		SeverityScore: severityScores[res.Severity()],
+		Status:        htmlStatus(res.Status()),
+		StatusScore:   statusScores[res.Status()],
+		Provider:      rule.Provider.DisplayName(),
+		Service:       rule.Service,
+		Summary:       rule.Summary,
+		Description:   res.Description(),
+		File:          path,
+		Location:      location,
+		Impact:        rule.Impact,
+		Resolution:    rule.Resolution,
+		Links:         b.GetLinks(res),
+		Code:          htmlCode(res),
+	}
+	if len(finding.Links) > 0 {
+		finding.Link = finding.Links[0]
 	}
+	for _, occurrence := range getOccurrences(res, b.BaseDir()) {
+		finding.Occurrences = append(finding.Occurrences, fmt.Sprintf("%s (%s%s)", occurrence.moduleName, occurrence.filename, occurrence.lineInfo))
+	}
+	return finding
 }
 
-var severityScores = map[severity.Severity]uint8{
-	severity.None:     0,
-	severity.Low:      1,
-	severity.Medium:   2,
-	severity.High:     3,
-	severity.Critical: 4,
+func htmlStatus(status scan.Status) string {
+	switch status {
+	case scan.StatusPassed:
+		return "passed"
+	case scan.StatusIgnored:
+		return "ignored"
+	default:
+		return "failed"
+	}
 }
 
-func printResultsTableHTML(b formatters.ConfigurableFormatter, title string, results scan.Results) {
-	if len(results) == 0 {
-		return
+// htmlCode returns the code for a result as lines of tokens, which are highlighted by the classes in the CSS
+// chroma generates for the code theme
+func htmlCode(res scan.Result) []htmlCodeLine {
+	code, err := res.GetCode(scan.OptionCodeWithTruncation(true), scan.OptionCodeWithHighlighted(false))
+	if err != nil || len(code.Lines) == 0 {
+		return nil
 	}
 
-	sort.Slice(results, func(i, j int) bool {
-		scoreI := severityScores[results[i].Severity()]
-		scoreJ := severityScores[results[j].Severity()]
-		if scoreI == scoreJ {
-			return results[i].Rule().LongID() < results[j].Rule().LongID()
+	// the lines are highlighted together, so that tokens which span lines such as heredocs are highlighted properly
+	var source strings.Builder
+	for _, line := range code.Lines {
+		if !line.Truncated {
+			source.WriteString(line.Content)
 		}
-		return scoreI > scoreJ
-	})
+		source.WriteString("\n")
+	}
+	var tokenLines [][]chroma.Token
+	lexer := lexers.Match(res.Range().GetFilename())
+	if lexer == nil {
+		lexer = lexers.Get("terraform")
+	}
+	if lexer != nil {
+		if iterator, err := chroma.Coalesce(lexer).Tokenise(nil, source.String()); err == nil {
+			tokenLines = chroma.SplitTokensIntoLines(iterator.Tokens())
+		}
+	}
+
+	var lines []htmlCodeLine
+	for i, line := range code.Lines {
+		codeLine := htmlCodeLine{
+			Number:     line.Number,
+			Truncated:  line.Truncated,
+			IsCause:    line.IsCause && res.Status() != scan.StatusPassed,
+			Annotation: line.Annotation,
+		}
+		if !line.Truncated {
+			if len(tokenLines) == len(code.Lines) {
+				for _, token := range tokenLines[i] {
+					if strings.TrimSuffix(token.Value, "\n") == "" {
+						continue
+					}
+					codeLine.Tokens = append(codeLine.Tokens, htmlToken{
+						Class: tokenClass(token.Type),
+						Text:  strings.TrimSuffix(token.Value, "\n"),
+					})
+				}
+			} else {
+				codeLine.Tokens = []htmlToken{{Text: line.Content}}
+			}
+		}
+		lines = append(lines, codeLine)
+	}
+	return lines
+}
 
-	_, _ = fmt.Fprintf(b.Writer(), "    <h2>%s: %d issue(s)</h2>\n", title, len(results))
-	_, _ = fmt.Fprintf(b.Writer(), `    <table class="pure-table">
-      <thead>
-        <tr><th> # </th><th> ID </th><th> Severity </th><th> Title </th><th> Location </th><th> Description </th></tr>
-      </thead>
-      <tbody>
-`)
-	for i, result := range results {
-		desc := strings.ReplaceAll(result.Description(), "\n", "<br>")
-		location := fmt.Sprintf("%s:%d", b.Path(result, result.Metadata()), result.Range().GetStartLine())
-		if result.Range().GetEndLine() > result.Range().GetStartLine() {
-			location = fmt.Sprintf("%s-%d", location, result.Range().GetEndLine())
+// tokenClass returns the class chroma uses for a token type in its generated CSS
+func tokenClass(tokenType chroma.TokenType) string {
+	for tokenType != 0 {
+		if class, ok := chroma.StandardTypes[tokenType]; ok {
+			return class
 		}
-		var href string
-		if len(result.Rule().Links) > 0 {
-			href = result.Rule().Links[0]
+		tokenType = tokenType.Parent()
+	}
+	return ""
+}
+
+func codeStyle(codeTheme string) *chroma.Style {
+	switch codeTheme {
+	case "dark", "":
+		codeTheme = "monokai"
+	case "light":
+		codeTheme = "github"
+	}
+	if style := styles.Get(codeTheme); style != nil {
+		return style
+	}
+	return styles.Fallback
+}
+
+// htmlCharts returns the bars for the severity and timing charts, and the counts of what was scanned
+func htmlCharts(metrics scanner.Metrics) ([]htmlBar, []htmlBar, []htmlCount) {
+	results := []struct {
+		label string
+		class string
+		count int
+	}{
+		{"Critical", "CRITICAL", metrics.Executor.Counts.Critical},
+		{"High", "HIGH", metrics.Executor.Counts.High},
+		{"Medium", "MEDIUM", metrics.Executor.Counts.Medium},
+		{"Low", "LOW", metrics.Executor.Counts.Low},
+		{"Ignored", "ignored", metrics.Executor.Counts.Ignored},
+		{"Passed", "passed", metrics.Executor.Counts.Passed},
+	}
+	var largest int
+	for _, result := range results {
+		if result.count > largest {
+			largest = result.count
 		}
-		_, _ = fmt.Fprintf(
-			b.Writer(),
-			`        <tr>
-          <td>%d</td>
-          <td><a target="_blank" rel="noopener" href="%s">%s</a></td>
-          <td class="severity %s">%s</td>
-          <td><i>%s</i></td>
-          <td><code>%s</code></td>
-          <td>%s</td>
-        </tr>
-`,
-			i+1,
-			href,
-			result.Rule().LongID(),
-			result.Severity(),
-			result.Severity(),
-			result.Rule().Summary,
-			location,
-			desc,
-		)
-	}
-	_, _ = fmt.Fprint(b.Writer(), `
-      </tbody>
-    </table>
-`)
-}
-
-// nolint
-func printResultsHTML(b formatters.ConfigurableFormatter, results scan.Results) {
-	_, _ = fmt.Fprintf(b.Writer(), "    <h1>[tfsec] Results</h1>\n")
-	printResultsTableHTML(b, "Failed", results.GetFailed())
-	printResultsTableHTML(b, "Ignored", results.GetIgnored())
-	printResultsTableHTML(b, "Passed", results.GetPassed())
+	}
+	var severityBars []htmlBar
+	for _, result := range results {
+		severityBars = append(severityBars, htmlBar{
+			Label:   result.label,
+			Class:   result.class,
+			Value:   fmt.Sprintf("%d", result.count),
+			Percent: percentOf(int64(result.count), int64(largest)),
+		})
+	}
+
+	timings := []struct {
+		label    string
+		duration time.Duration
+	}{
+		{"Disk I/O", metrics.Parser.Timings.DiskIODuration},
+		{"Parsing", metrics.Parser.Timings.ParseDuration},
+		{"Adaptation", metrics.Executor.Timings.Adaptation},
+		{"Checks", metrics.Executor.Timings.RunningChecks},
+	}
+	var timingBars []htmlBar
+	if metrics.Timings.Total == 0 {
+		// results rendered from a saved report have no timings or counts of what was scanned
+		return severityBars, nil, nil
+	}
+	for _, timing := range timings {
+		timingBars = append(timingBars, htmlBar{
+			Label:   timing.label,
+			Class:   "timing",
+			Value:   timing.duration.String(),
+			Percent: percentOf(int64(timing.duration), int64(metrics.Timings.Total)),
+		})
+	}
+	timingBars = append(timingBars, htmlBar{
+		Label:   "Total",
+		Class:   "timing",
+		Value:   metrics.Timings.Total.String(),
+		Percent: percentOf(int64(metrics.Timings.Total), int64(metrics.Timings.Total)),
+	})
+
+	counts := []htmlCount{
+		{"Modules downloaded", metrics.Parser.Counts.ModuleDownloads},
+		{"Modules processed", metrics.Parser.Counts.Modules},
+		{"Blocks processed", metrics.Parser.Counts.Blocks},
+		{"Files read", metrics.Parser.Counts.Files},
+	}
+	return severityBars, timingBars, counts
+}
+
+func percentOf(value, total int64) int {
+	if total <= 0 {
+		return 0
+	}
+	percent := int(value * 100 / total)
+	if percent > 100 {
+		return 100
+	}
+	return percent
 }
@@ -39,25 +39,28 @@ func printResultsTableMarkdown(b formatters.ConfigurableFormatter, title string,
 	_, _ = fmt.Fprintf(b.Writer(), "| # | ID | Severity | Title | Location | Description |\n")
 	_, _ = fmt.Fprintf(b.Writer(), "|---|----|----------|-------|----------|-------------|\n")
 	for i, result := range results {
-		desc := strings.ReplaceAll(result.Description(), "\n", "<br>")
-		location := fmt.Sprintf("%s:%d", b.Path(result, result.Metadata()), result.Range().GetStartLine())
-		if result.Range().GetEndLine() > result.Range().GetStartLine() {
-			location = fmt.Sprintf("%s-%d", location, result.Range().GetEndLine())
-		}
-		_, _ = fmt.Fprintf(
-			b.Writer(),
-			"| %d | `%s` | *%s* | _%s_ | `%s` | %s |\n",
-			i+1,
-			result.Rule().LongID(),
-			result.Severity(),
-			result.Rule().Summary,
-			location,
-			desc,
-		)
+		_, _ = fmt.Fprintf(b.Writer(), "| %d | %s |\n", i+1, markdownResultColumns(b, result))
 	}
 	_, _ = fmt.Fprint(b.Writer(), "\n")
 }
 
+// markdownResultColumns renders the ID, severity, title, location and description columns of a result table row
+func markdownResultColumns(b formatters.ConfigurableFormatter, result scan.Result) string {
+	desc := strings.ReplaceAll(result.Description(), "\n", "<br>")
+	location := fmt.Sprintf("%s:%d", b.Path(result, result.Metadata()), result.Range().GetStartLine())
+	if result.Range().GetEndLine() > result.Range().GetStartLine() {
+		location = fmt.Sprintf("%s-%d", location, result.Range().GetEndLine())
+	}
+	return fmt.Sprintf(
+		"`%s` | *%s* | _%s_ | `%s` | %s",
+		result.Rule().LongID(),
+		result.Severity(),
+		result.Rule().Summary,
+		location,
+		desc,
+	)
+}
+
 // nolint
 func printResultsMarkdown(b formatters.ConfigurableFormatter, results scan.Results) {
 	_, _ = fmt.Fprintf(b.Writer(), "# [tfsec] Results\n")
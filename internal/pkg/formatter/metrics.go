@@ -12,20 +12,23 @@ import (
 
 func printMetrics(w io.Writer, metrics scanner.Metrics) {
 
-	printTitle(w, "timings")
-	printValue(w, "disk i/o", metrics.Parser.Timings.DiskIODuration.String())
-	printValue(w, "parsing", metrics.Parser.Timings.ParseDuration.String())
-	printValue(w, "adaptation", metrics.Executor.Timings.Adaptation.String())
-	printValue(w, "checks", metrics.Executor.Timings.RunningChecks.String())
-	printValue(w, "total", metrics.Timings.Total.String())
-	_, _ = fmt.Fprintf(w, "\n")
-
-	printTitle(w, "counts")
-	printValue(w, "modules downloaded", fmt.Sprintf("%d", metrics.Parser.Counts.ModuleDownloads))
-	printValue(w, "modules processed", fmt.Sprintf("%d", metrics.Parser.Counts.Modules))
-	printValue(w, "blocks processed", fmt.Sprintf("%d", metrics.Parser.Counts.Blocks))
-	printValue(w, "files read", fmt.Sprintf("%d", metrics.Parser.Counts.Files))
-	_, _ = fmt.Fprintf(w, "\n")
+	// results rendered from a saved report have no timings or counts of what was scanned
+	if metrics.Timings.Total > 0 {
+		printTitle(w, "timings")
+		printValue(w, "disk i/o", metrics.Parser.Timings.DiskIODuration.String())
+		printValue(w, "parsing", metrics.Parser.Timings.ParseDuration.String())
+		printValue(w, "adaptation", metrics.Executor.Timings.Adaptation.String())
+		printValue(w, "checks", metrics.Executor.Timings.RunningChecks.String())
+		printValue(w, "total", metrics.Timings.Total.String())
+		_, _ = fmt.Fprintf(w, "\n")
+
+		printTitle(w, "counts")
+		printValue(w, "modules downloaded", fmt.Sprintf("%d", metrics.Parser.Counts.ModuleDownloads))
+		printValue(w, "modules processed", fmt.Sprintf("%d", metrics.Parser.Counts.Modules))
+		printValue(w, "blocks processed", fmt.Sprintf("%d", metrics.Parser.Counts.Blocks))
+		printValue(w, "files read", fmt.Sprintf("%d", metrics.Parser.Counts.Files))
+		_, _ = fmt.Fprintf(w, "\n")
+	}
 
 	printTitle(w, "results")
 	printValue(w, "passed", fmt.Sprintf("%d", metrics.Executor.Counts.Passed))
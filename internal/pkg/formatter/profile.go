@@ -0,0 +1,34 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aquasecurity/tfsec/internal/pkg/metrics"
+	"github.com/liamg/tml"
+)
+
+func printProfile(w io.Writer, profile *metrics.Profile) {
+	printTitle(w, "rule profile")
+	if len(profile.Rules) == 0 {
+		_ = tml.Fprintf(w, "  <dim>no rules were run</dim>\n\n")
+		return
+	}
+
+	_ = tml.Fprintf(w, "  <bold>slowest rules</bold>\n")
+	for _, rule := range profile.Rules {
+		_ = tml.Fprintf(w, "  %12s  %s\n", formatSeconds(rule.Seconds), rule.ID)
+	}
+	if len(profile.RuleModules) > 0 {
+		_ = tml.Fprintf(w, "\n  <bold>slowest rules by module</bold>\n")
+		for _, pair := range profile.RuleModules {
+			_ = tml.Fprintf(w, "  %12s  %s <dim>in %s</dim>\n", formatSeconds(pair.Seconds), pair.ID, pair.Module)
+		}
+	}
+	_, _ = fmt.Fprintf(w, "\n")
+}
+
+func formatSeconds(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Microsecond).String()
+}
@@ -0,0 +1,161 @@
+package formatter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/formatters"
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/tfsec/internal/pkg/remediation"
+	"github.com/aquasecurity/tfsec/version"
+)
+
+// reviewPayload is a set of pull request review comments, one for each failed result other than the further
+// instances of a resource created with count or for_each
+type reviewPayload struct {
+	Tool     reviewTool      `json:"tool"`
+	Comments []reviewComment `json:"comments"`
+}
+
+type reviewTool struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// reviewComment is a review comment on the lines of a file. Line is the last line the comment applies to and
+// StartLine is the first, if the comment covers more than one line, as code hosts generally expect. The comment
+// covers the whole block when there is a suggested change, so that the suggestion replaces the block.
+type reviewComment struct {
+	Path      string `json:"path"`
+	Line      int    `json:"line"`
+	StartLine int    `json:"start_line,omitempty"`
+	RuleID    string `json:"rule_id"`
+	Severity  string `json:"severity"`
+	Body      string `json:"body"`
+	// Suggestion is the replacement for the lines of the comment, which is also included in the body
+	Suggestion string `json:"suggestion,omitempty"`
+	// DedupKey identifies the result by its rule, file and resource, so that a comment which was posted for an
+	// earlier commit can be recognised and not posted again. It is also in the body, as a hidden HTML comment. The
+	// instances of a resource created with count or for_each share the code, and so share a key and a comment.
+	DedupKey string `json:"dedup_key"`
+}
+
+// ReviewJSON writes the failed results as review comments, with a suggested change for each result which tfsec
+// can fix. Result filenames are relative to fsRoot.
+func ReviewJSON(fsRoot string) func(b formatters.ConfigurableFormatter, results scan.Results) error {
+	return func(b formatters.ConfigurableFormatter, results scan.Results) error {
+		tfsecVersion := version.Version
+		if tfsecVersion == "" {
+			tfsecVersion = "dev"
+		}
+		payload := reviewPayload{
+			Tool:     reviewTool{Name: "tfsec", Version: tfsecVersion},
+			Comments: []reviewComment{},
+		}
+
+		commented := make(map[string]bool)
+		for _, res := range results.GetFailed() {
+			path := b.Path(res, res.Metadata())
+			key := reviewKey(res, path)
+			if commented[key] {
+				continue
+			}
+			commented[key] = true
+			comment := reviewComment{
+				Path:     path,
+				Line:     res.Range().GetEndLine(),
+				RuleID:   res.Rule().LongID(),
+				Severity: string(res.Severity()),
+				DedupKey: key,
+			}
+			start := res.Range().GetStartLine()
+
+			var description string
+			if replacement, err := remediation.ReplacementFor(res, fsRoot); err == nil && replacement != nil {
+				if suggestion, ok := suggestedLines(res, fsRoot, replacement); ok {
+					comment.Suggestion = suggestion
+					description = replacement.Description()
+					start, comment.Line = replacement.StartLine, replacement.EndLine
+				}
+			}
+			if start < comment.Line {
+				comment.StartLine = start
+			}
+			comment.Body = reviewBody(b, res, comment, description)
+			payload.Comments = append(payload.Comments, comment)
+		}
+
+		encoder := json.NewEncoder(b.Writer())
+		encoder.SetIndent("", "  ")
+		// the bodies are markdown, which is easier to read without HTML escaping
+		encoder.SetEscapeHTML(false)
+		return encoder.Encode(payload)
+	}
+}
+
+// instanceKeys matches the count and for_each keys of an address, such as [0] or ["logs"]
+var instanceKeys = regexp.MustCompile(`\[[^\]]*\]`)
+
+// reviewKey is the fingerprint of the result, without the count and for_each keys of its resource and modules
+func reviewKey(res scan.Result, path string) string {
+	resource := instanceKeys.ReplaceAllString(Address(res)+"|"+res.Metadata().Reference(), "")
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%s", res.Rule().LongID(), filepath.ToSlash(path), resource)))
+	return hex.EncodeToString(hash[:])
+}
+
+// suggestedLines returns the whole lines which replace the lines of the replaced block, as a suggested change
+// replaces whole lines rather than a range of columns
+func suggestedLines(res scan.Result, fsRoot string, replacement *remediation.Replacement) (string, bool) {
+	content, err := os.ReadFile(filepath.Join(fsRoot, filepath.FromSlash(res.Range().GetFilename())))
+	if err != nil {
+		return "", false
+	}
+	lines := strings.Split(string(content), "\n")
+	if replacement.StartLine < 1 || replacement.EndLine > len(lines) {
+		return "", false
+	}
+	// columns count characters rather than bytes
+	first := []rune(lines[replacement.StartLine-1])
+	last := []rune(lines[replacement.EndLine-1])
+	if replacement.StartColumn-1 > len(first) || replacement.EndColumn-1 > len(last) {
+		return "", false
+	}
+	return string(first[:replacement.StartColumn-1]) + replacement.Text + string(last[replacement.EndColumn-1:]), true
+}
+
+func reviewBody(b formatters.ConfigurableFormatter, res scan.Result, comment reviewComment, fixDescription string) string {
+	var body strings.Builder
+	_, _ = fmt.Fprintf(&body, "<!-- tfsec:%s -->\n", comment.DedupKey)
+	_, _ = fmt.Fprintf(&body, "**[tfsec]** %s\n\n", res.Rule().Summary)
+	_, _ = fmt.Fprintf(&body, "| ID | Severity | Title | Location | Description |\n")
+	_, _ = fmt.Fprintf(&body, "|----|----------|-------|----------|-------------|\n")
+	_, _ = fmt.Fprintf(&body, "| %s |\n", markdownResultColumns(b, res))
+
+	if impact := res.Rule().Impact; impact != "" {
+		_, _ = fmt.Fprintf(&body, "\n**Impact:** %s\n", impact)
+	}
+	if resolution := res.Rule().Resolution; resolution != "" {
+		_, _ = fmt.Fprintf(&body, "\n**Resolution:** %s\n", resolution)
+	}
+	if comment.Suggestion != "" {
+		fence := "```"
+		for strings.Contains(comment.Suggestion, fence) {
+			fence += "`"
+		}
+		_, _ = fmt.Fprintf(&body, "\nSuggested fix (%s):\n\n%ssuggestion\n%s\n%s\n", fixDescription, fence, comment.Suggestion, fence)
+	}
+	if links := b.GetLinks(res); len(links) > 0 {
+		_, _ = fmt.Fprintf(&body, "\n<details><summary>More information</summary>\n\n")
+		for _, link := range links {
+			_, _ = fmt.Fprintf(&body, "- %s\n", link)
+		}
+		_, _ = fmt.Fprintf(&body, "\n</details>\n")
+	}
+	return body.String()
+}
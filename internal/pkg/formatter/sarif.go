@@ -0,0 +1,282 @@
+package formatter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aquasecurity/defsec/pkg/formatters"
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/defsec/pkg/severity"
+	"github.com/aquasecurity/tfsec/internal/pkg/legacy"
+	"github.com/aquasecurity/tfsec/internal/pkg/remediation"
+	"github.com/google/uuid"
+	"github.com/owenrumney/go-sarif/v2/sarif"
+)
+
+// sarifFingerprintKey is the partialFingerprints key for the hash of the rule, file and resource instance of a result,
+// which unlike the line of a result stays the same when unrelated parts of a file are edited. The version was bumped
+// when the instance address of count and for_each resources was added to the hash.
+const sarifFingerprintKey = "tfsecResourceRule/v2"
+
+// SARIF writes a SARIF report with help text, security severity scores and fingerprints for code scanning tools.
+// Failed results include a fix where one is known, and results ignored with an ignore comment are included as
+// suppressed results, so that they show as dismissed rather than disappearing. Results which are hidden by the config
+// or flags, such as --minimum-severity or exclude, are left out, as there is no comment to justify their suppression.
+// Result filenames are relative to fsRoot.
+func SARIF(fsRoot string) func(b formatters.ConfigurableFormatter, results scan.Results) error {
+	return func(b formatters.ConfigurableFormatter, results scan.Results) error {
+		report, err := sarif.New(sarif.Version210)
+		if err != nil {
+			return err
+		}
+
+		run := sarif.NewRunWithInformationURI("defsec", "https://github.com/aquasecurity/defsec")
+		report.AddRun(run)
+
+		for _, res := range results {
+			if res.Status() == scan.StatusPassed && !b.IncludePassed() {
+				continue
+			}
+			var justification string
+			if res.Status() == scan.StatusIgnored {
+				if justification = ignoreJustification(res, fsRoot); justification == "" {
+					continue
+				}
+			}
+
+			links := b.GetLinks(res)
+			addSARIFRule(run, res, links)
+
+			rng := res.Range()
+			path := b.Path(res, res.Metadata())
+			location := sarif.NewLocation().WithPhysicalLocation(
+				sarif.NewPhysicalLocation().
+					WithArtifactLocation(sarif.NewSimpleArtifactLocation(path)).
+					WithRegion(sarif.NewSimpleRegion(rng.GetStartLine(), rng.GetEndLine())),
+			)
+
+			fingerprint := fingerprint(res, path)
+			ruleResult := run.CreateResultForRule(res.Rule().LongID()).
+				WithMessage(sarif.NewTextMessage(res.Description())).
+				WithLevel(sarifLevel(res.Severity())).
+				WithPartialFingerPrints(map[string]interface{}{sarifFingerprintKey: fingerprint})
+			ruleResult.AddLocation(location)
+
+			switch res.Status() {
+			case scan.StatusPassed:
+				ruleResult.WithKind("pass")
+			case scan.StatusIgnored:
+				ruleResult.WithKind("fail")
+				ruleResult.AddSuppression(
+					sarif.NewSuppression("inSource").
+						WithStatus("accepted").
+						WithGuid(uuid.NewSHA1(uuid.NameSpaceOID, []byte(fingerprint)).String()).
+						WithLocation(location).
+						WithJustifcation(justification),
+				)
+			default:
+				ruleResult.WithKind("fail")
+				if fix := sarifFix(res, fsRoot, path); fix != nil {
+					ruleResult.AddFix(fix)
+				}
+			}
+		}
+
+		return report.PrettyWrite(b.Writer())
+	}
+}
+
+func addSARIFRule(run *sarif.Run, res scan.Result, links []string) {
+	rule := res.Rule()
+	for _, existing := range run.Tool.Driver.Rules {
+		if existing.ID == rule.LongID() {
+			return
+		}
+	}
+
+	descriptor := run.AddRule(rule.LongID()).
+		WithDescription(rule.Summary).
+		WithHelp(sarifHelp(res, links)).
+		WithProperties(sarif.Properties{
+			"precision":         "very-high",
+			"security-severity": securitySeverity(res.Rule().Severity),
+			"tags":              []string{"security", "terraform", string(rule.Provider)},
+		})
+	if explanation := strings.TrimSpace(rule.Explanation); explanation != "" {
+		descriptor.WithFullDescription(sarif.NewMultiformatMessageString(explanation))
+	}
+	if len(links) > 0 {
+		descriptor.WithHelpURI(links[0])
+	}
+}
+
+func sarifHelp(res scan.Result, links []string) *sarif.MultiformatMessageString {
+	rule := res.Rule()
+	var text, markdown []string
+
+	text = append(text, rule.Summary)
+	markdown = append(markdown, fmt.Sprintf("**%s**", rule.Summary))
+	if explanation := strings.TrimSpace(rule.Explanation); explanation != "" {
+		text = append(text, explanation)
+		markdown = append(markdown, explanation)
+	}
+	if rule.Impact != "" {
+		text = append(text, fmt.Sprintf("Impact: %s", rule.Impact))
+		markdown = append(markdown, fmt.Sprintf("**Impact:** %s", rule.Impact))
+	}
+	if rule.Resolution != "" {
+		text = append(text, fmt.Sprintf("Resolution: %s", rule.Resolution))
+		markdown = append(markdown, fmt.Sprintf("**Resolution:** %s", rule.Resolution))
+	}
+	if len(links) > 0 {
+		text = append(text, fmt.Sprintf("More information: %s", strings.Join(links, ", ")))
+		var items []string
+		for _, link := range links {
+			items = append(items, fmt.Sprintf("- <%s>", link))
+		}
+		markdown = append(markdown, fmt.Sprintf("**More information:**\n%s", strings.Join(items, "\n")))
+	}
+
+	return sarif.NewMultiformatMessageString(strings.Join(text, "\n\n")).
+		WithMarkdown(strings.Join(markdown, "\n\n"))
+}
+
+func sarifLevel(sev severity.Severity) string {
+	switch sev {
+	case severity.Low:
+		return "note"
+	case severity.Medium:
+		return "warning"
+	case severity.High, severity.Critical:
+		return "error"
+	default:
+		return "none"
+	}
+}
+
+// securitySeverity converts a severity to the score code scanning uses to rank alerts. Scores above 9.0 are shown
+// as critical, 7.0 to 8.9 as high, 4.0 to 6.9 as medium and below that as low.
+func securitySeverity(sev severity.Severity) string {
+	switch sev {
+	case severity.Critical:
+		return "9.5"
+	case severity.High:
+		return "8.0"
+	case severity.Medium:
+		return "5.5"
+	case severity.Low:
+		return "2.0"
+	default:
+		return "0.0"
+	}
+}
+
+func sarifFix(res scan.Result, fsRoot string, path string) *sarif.Fix {
+	replacement, err := remediation.ReplacementFor(res, fsRoot)
+	if err != nil || replacement == nil {
+		return nil
+	}
+	region := sarif.NewRegion().
+		WithStartLine(replacement.StartLine).
+		WithStartColumn(replacement.StartColumn).
+		WithEndLine(replacement.EndLine).
+		WithEndColumn(replacement.EndColumn)
+	change := sarif.NewArtifactChange(sarif.NewSimpleArtifactLocation(path)).
+		WithReplacement(sarif.NewReplacement(region).WithInsertedContent(sarif.NewArtifactContent().WithText(replacement.Text)))
+	return sarif.NewFix().
+		WithDescriptionText(strings.ToUpper(replacement.Description()[:1]) + replacement.Description()[1:]).
+		WithArtifactChanges([]*sarif.ArtifactChange{change})
+}
+
+// ignoreJustification returns the ignore comments which suppressed a result, or an empty string if the result was not
+// ignored by a comment. Like the scanner, it looks for comments naming the result's rule on the first line of the
+// result or of any block it is in, or on the comment lines directly above them.
+func ignoreJustification(res scan.Result, fsRoot string) string {
+	rule := res.Rule()
+	ids := map[string]struct{}{"*": {}}
+	for _, id := range append(append([]string{rule.LongID(), rule.AVDID, rule.ShortCode}, rule.Aliases...), legacy.FindIDs(rule.LongID())...) {
+		if id != "" {
+			ids[id] = struct{}{}
+		}
+	}
+
+	files := make(map[string][]string)
+	for metadata := res.Metadata(); ; {
+		filename := metadata.Range().GetFilename()
+		lines, ok := files[filename]
+		if !ok {
+			if content, err := os.ReadFile(filepath.Join(fsRoot, filepath.FromSlash(filename))); err == nil {
+				lines = strings.Split(string(content), "\n")
+			}
+			files[filename] = lines
+		}
+		if comments := ignoreComments(lines, metadata.Range().GetStartLine()-1, ids); len(comments) > 0 {
+			return strings.Join(comments, "\n")
+		}
+		parent := metadata.Parent()
+		if parent == nil {
+			return ""
+		}
+		metadata = *parent
+	}
+}
+
+// ignoreComments returns the ignore comments for any of ids on the given line, or on the comment lines above it
+func ignoreComments(lines []string, start int, ids map[string]struct{}) []string {
+	if start < 0 || start >= len(lines) {
+		return nil
+	}
+
+	var comments []string
+	if comment := ignoreComment(lines[start], ids); comment != "" {
+		comments = append(comments, comment)
+	}
+	for i := start - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "//") && !strings.HasPrefix(line, "/*") {
+			break
+		}
+		if comment := ignoreComment(line, ids); comment != "" {
+			comments = append([]string{comment}, comments...)
+		}
+	}
+	return comments
+}
+
+var ignorePattern = regexp.MustCompile(`(?:tfsec|trivy):ignore:([^\s:\[]+)(?:\[[^\]]*\])?((?::[a-z]+:[^\s:]+)*)`)
+
+// ignoreComment returns the comment on line if it has an unexpired ignore for any of ids
+func ignoreComment(line string, ids map[string]struct{}) string {
+	for _, marker := range []string{"#", "//", "/*"} {
+		index := strings.Index(line, marker)
+		if index < 0 {
+			continue
+		}
+		comment := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line[index:]), "*/"))
+		for _, match := range ignorePattern.FindAllStringSubmatch(comment, -1) {
+			if _, ok := ids[match[1]]; ok && !ignoreExpired(match[2]) {
+				return comment
+			}
+		}
+	}
+	return ""
+}
+
+// ignoreExpired returns whether the options of an ignore, such as :exp:2025-01-01, include an expiry date which has
+// passed, after which the scanner no longer applies the ignore
+func ignoreExpired(options string) bool {
+	segments := strings.Split(strings.TrimPrefix(options, ":"), ":")
+	for i := 0; i+1 < len(segments); i += 2 {
+		if segments[i] != "exp" {
+			continue
+		}
+		if expiry, err := time.Parse("2006-01-02", segments[i+1]); err == nil && time.Now().After(expiry) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/aquasecurity/tfsec/internal/pkg/statistics"
+	"github.com/liamg/tml"
+)
+
+func printStatistics(w io.Writer, report *statistics.Report) {
+	printTitle(w, "statistics")
+	printValue(w, "failures", fmt.Sprintf("%d", report.Total))
+	_, _ = fmt.Fprintf(w, "\n")
+	if report.Total == 0 {
+		return
+	}
+
+	_ = tml.Fprintf(w, "  <bold>by rule</bold>\n")
+	for _, rule := range report.Rules {
+		_ = tml.Fprintf(w, "  %6d  %s <dim>%s</dim>\n", rule.Count, rule.ID, rule.Description)
+	}
+	printStatisticsCounts(w, "by severity", report.Severities)
+	printStatisticsCounts(w, "by provider", report.Providers)
+	printStatisticsCounts(w, "by service", report.Services)
+	printStatisticsCounts(w, "by file", report.Files)
+	printStatisticsCounts(w, "by module", report.Modules)
+	_, _ = fmt.Fprintf(w, "\n")
+}
+
+func printStatisticsCounts(w io.Writer, title string, counts []statistics.Count) {
+	_ = tml.Fprintf(w, "\n  <bold>%s</bold>\n", title)
+	for _, count := range counts {
+		_ = tml.Fprintf(w, "  %6d  %s\n", count.Count, count.Name)
+	}
+}
@@ -0,0 +1,75 @@
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ConnFraming(t *testing.T) {
+	output := bytes.NewBuffer(nil)
+	c := newConn(nil, output)
+
+	id := json.RawMessage(`1`)
+	require.NoError(t, c.reply(&id, nil))
+	require.NoError(t, c.notify("window/showMessage", map[string]string{"message": "hello"}))
+
+	r := newConn(strings.NewReader(output.String()), nil)
+
+	msg, err := r.read()
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(*msg.ID))
+	assert.Contains(t, output.String(), `"result":null`)
+
+	msg, err = r.read()
+	require.NoError(t, err)
+	assert.Equal(t, "window/showMessage", msg.Method)
+	assert.JSONEq(t, `{"message":"hello"}`, string(msg.Params))
+}
+
+func Test_ConnInvalidLength(t *testing.T) {
+	c := newConn(strings.NewReader("Content-Length: nope\r\n\r\n{}"), nil)
+	_, err := c.read()
+	assert.ErrorContains(t, err, "invalid Content-Length")
+}
+
+func Test_OverlayFS(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "module"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "module", "main.tf"), []byte("saved"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "module", "other.tf"), []byte("other"), 0o600))
+
+	fsys := OverlayFS(dir, map[string][]byte{
+		filepath.Join(dir, "module", "main.tf"): []byte("unsaved content"),
+	})
+
+	data, err := fs.ReadFile(fsys, "module/main.tf")
+	require.NoError(t, err)
+	assert.Equal(t, "unsaved content", string(data))
+
+	info, err := fs.Stat(fsys, "module/main.tf")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("unsaved content")), info.Size())
+
+	data, err = fs.ReadFile(fsys, "module/other.tf")
+	require.NoError(t, err)
+	assert.Equal(t, "other", string(data))
+
+	entries, err := fs.ReadDir(fsys, "module")
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func Test_URIConversion(t *testing.T) {
+	path := filepath.Join(string(filepath.Separator)+"some dir", "main.tf")
+	uri := pathToURI(path)
+	assert.Equal(t, "file:///some%20dir/main.tf", uri)
+	assert.Equal(t, path, uriToPath(uri))
+}
@@ -0,0 +1,90 @@
+package lsp
+
+import (
+	"bytes"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/aquasecurity/defsec/pkg/extrafs"
+)
+
+// overlayFS is a filesystem rooted at root which serves the content of unsaved documents in place of the
+// files on disk. Documents which have never been saved are not listed in their directory, so are not scanned.
+type overlayFS struct {
+	base      extrafs.FS
+	documents map[string][]byte
+}
+
+// OverlayFS returns a filesystem rooted at root, in which the files at the absolute paths in documents have the
+// given content rather than their content on disk
+func OverlayFS(root string, documents map[string][]byte) extrafs.FS {
+	overlay := &overlayFS{
+		base:      extrafs.OSDir(root),
+		documents: make(map[string][]byte),
+	}
+	for path, content := range documents {
+		if rel, err := filepath.Rel(root, path); err == nil {
+			overlay.documents[filepath.ToSlash(rel)] = content
+		}
+	}
+	return overlay
+}
+
+func (o *overlayFS) Open(name string) (fs.File, error) {
+	if content, ok := o.documents[name]; ok {
+		info, err := o.base.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+		return &overlayFile{
+			Reader: bytes.NewReader(content),
+			info: overlayInfo{
+				FileInfo: info,
+				size:     int64(len(content)),
+			},
+		}, nil
+	}
+	return o.base.Open(name)
+}
+
+func (o *overlayFS) Stat(name string) (fs.FileInfo, error) {
+	info, err := o.base.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if content, ok := o.documents[name]; ok {
+		return overlayInfo{FileInfo: info, size: int64(len(content))}, nil
+	}
+	return info, nil
+}
+
+func (o *overlayFS) ResolveSymlink(name, dir string) (string, error) {
+	return o.base.ResolveSymlink(name, dir)
+}
+
+type overlayFile struct {
+	*bytes.Reader
+	info overlayInfo
+}
+
+func (f *overlayFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *overlayFile) Close() error {
+	return nil
+}
+
+type overlayInfo struct {
+	fs.FileInfo
+	size int64
+}
+
+func (i overlayInfo) Size() int64 {
+	return i.size
+}
+
+func (i overlayInfo) ModTime() time.Time {
+	return time.Now()
+}
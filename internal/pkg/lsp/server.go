@@ -0,0 +1,477 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/defsec/pkg/severity"
+)
+
+// ScanFunc scans the module in dir, using the content of the given unsaved documents in place of the files on disk,
+// and returns the results along with the directory which result filenames are relative to
+type ScanFunc func(ctx context.Context, dir string, documents map[string][]byte) (results scan.Results, fsRoot string, err error)
+
+// LinksFunc returns the documentation links for the rule of a result
+type LinksFunc func(result scan.Result) []string
+
+// Server is a language server which publishes tfsec results as diagnostics. Each time a document is opened, changed
+// or saved, the module containing it is rescanned, with the content of unsaved documents used in place of the files
+// on disk.
+type Server struct {
+	conn     *conn
+	scan     ScanFunc
+	links    LinksFunc
+	debounce time.Duration
+	logger   io.Writer
+
+	mu        sync.Mutex
+	documents map[string][]byte
+	findings  map[string][]scan.Result
+	published map[string]map[string]struct{}
+	pending   map[string]struct{}
+	timer     *time.Timer
+	scans     chan struct{}
+}
+
+func NewServer(r io.Reader, w io.Writer, scanFunc ScanFunc, linksFunc LinksFunc) *Server {
+	return &Server{
+		conn:      newConn(r, w),
+		scan:      scanFunc,
+		links:     linksFunc,
+		debounce:  300 * time.Millisecond,
+		logger:    io.Discard,
+		documents: make(map[string][]byte),
+		findings:  make(map[string][]scan.Result),
+		published: make(map[string]map[string]struct{}),
+		pending:   make(map[string]struct{}),
+		scans:     make(chan struct{}, 1),
+	}
+}
+
+// WithLogger sets where errors which can't be reported to the client are written
+func (s *Server) WithLogger(w io.Writer) *Server {
+	s.logger = w
+	return s
+}
+
+// Run handles messages until the client sends an exit notification, the input is closed or ctx is cancelled
+func (s *Server) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go s.scanLoop(ctx)
+
+	messages := make(chan *message)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := s.conn.read()
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case messages <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errs:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		case msg := <-messages:
+			if msg.Method == "exit" {
+				return nil
+			}
+			if err := s.handle(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Server) handle(msg *message) error {
+	var result interface{}
+	var err error
+
+	switch msg.Method {
+	case "initialize":
+		result = map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync": map[string]interface{}{
+					"openClose": true,
+					"change":    1, // full document sync
+					"save":      true,
+				},
+				"hoverProvider":      true,
+				"codeActionProvider": true,
+			},
+			"serverInfo": map[string]string{
+				"name": "tfsec",
+			},
+		}
+	case "shutdown":
+		result = nil
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			s.update(params.TextDocument.URI, []byte(params.TextDocument.Text))
+		}
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil && len(params.ContentChanges) > 0 {
+			// full document sync, so the last change holds the whole document
+			s.update(params.TextDocument.URI, []byte(params.ContentChanges[len(params.ContentChanges)-1].Text))
+		}
+	case "textDocument/didSave":
+		var params DidSaveTextDocumentParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			s.schedule(uriToPath(params.TextDocument.URI))
+		}
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			s.close(params.TextDocument.URI)
+		}
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			if hover := s.hover(params); hover != nil {
+				result = hover
+			}
+		}
+	case "textDocument/codeAction":
+		var params CodeActionParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			result = s.codeActions(params)
+		}
+	default:
+		if msg.ID != nil {
+			return s.conn.replyError(msg.ID, codeMethodNotFound, fmt.Errorf("method not supported: %s", msg.Method))
+		}
+		return nil
+	}
+
+	if msg.ID == nil {
+		if err != nil {
+			_, _ = fmt.Fprintf(s.logger, "invalid %s notification: %s\n", msg.Method, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return s.conn.replyError(msg.ID, codeInvalidParams, err)
+	}
+	return s.conn.reply(msg.ID, result)
+}
+
+func (s *Server) update(uri string, content []byte) {
+	path := uriToPath(uri)
+	s.mu.Lock()
+	s.documents[path] = content
+	s.mu.Unlock()
+	s.schedule(path)
+}
+
+func (s *Server) close(uri string) {
+	path := uriToPath(uri)
+	s.mu.Lock()
+	delete(s.documents, path)
+	s.mu.Unlock()
+	s.schedule(path)
+}
+
+// schedule queues a rescan of the module containing path. Scans are delayed slightly, so that a burst of changes
+// only causes a single scan.
+func (s *Server) schedule(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending[filepath.Dir(path)] = struct{}{}
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.timer = time.AfterFunc(s.debounce, func() {
+		select {
+		case s.scans <- struct{}{}:
+		default:
+		}
+	})
+}
+
+// scanLoop runs the queued scans one at a time, so that only one scan is ever running
+func (s *Server) scanLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.scans:
+		}
+
+		s.mu.Lock()
+		var dirs []string
+		for dir := range s.pending {
+			dirs = append(dirs, dir)
+		}
+		s.pending = make(map[string]struct{})
+		documents := make(map[string][]byte, len(s.documents))
+		for path, content := range s.documents {
+			documents[path] = content
+		}
+		s.mu.Unlock()
+
+		sort.Strings(dirs)
+		for _, dir := range dirs {
+			if err := s.scanModule(ctx, dir, documents); err != nil {
+				s.showError(fmt.Sprintf("tfsec failed to scan %s: %s", dir, err))
+			}
+		}
+	}
+}
+
+func (s *Server) scanModule(ctx context.Context, dir string, documents map[string][]byte) error {
+	results, fsRoot, err := s.scan(ctx, dir, documents)
+	if err != nil {
+		return err
+	}
+
+	byFile := make(map[string][]scan.Result)
+	for _, result := range results.GetFailed() {
+		path := filepath.Join(fsRoot, filepath.FromSlash(result.Range().GetFilename()))
+		byFile[path] = append(byFile[path], result)
+	}
+
+	s.mu.Lock()
+	previous := s.published[dir]
+	published := make(map[string]struct{})
+	for path, fileResults := range byFile {
+		s.findings[path] = fileResults
+		published[path] = struct{}{}
+	}
+	for path := range previous {
+		if _, ok := published[path]; !ok {
+			delete(s.findings, path)
+		}
+	}
+	s.published[dir] = published
+	s.mu.Unlock()
+
+	var paths []string
+	for path := range published {
+		paths = append(paths, path)
+	}
+	for path := range previous {
+		if _, ok := published[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		diagnostics := []Diagnostic{}
+		for _, result := range byFile[path] {
+			diagnostics = append(diagnostics, s.diagnostic(path, result))
+		}
+		if err := s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI:         pathToURI(path),
+			Diagnostics: diagnostics,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) showError(text string) {
+	if err := s.conn.notify("window/showMessage", map[string]interface{}{
+		"type":    1,
+		"message": text,
+	}); err != nil {
+		_, _ = fmt.Fprintln(s.logger, text)
+	}
+}
+
+func (s *Server) diagnostic(path string, result scan.Result) Diagnostic {
+	diagnostic := Diagnostic{
+		Range:    s.resultRange(path, result),
+		Severity: diagnosticSeverity(result.Severity()),
+		Code:     result.Rule().LongID(),
+		Source:   "tfsec",
+		Message:  result.Description(),
+	}
+	if links := s.links(result); len(links) > 0 {
+		diagnostic.CodeDescription = &CodeDescription{Href: links[0]}
+	}
+	return diagnostic
+}
+
+func diagnosticSeverity(sev severity.Severity) DiagnosticSeverity {
+	switch sev {
+	case severity.Critical, severity.High:
+		return SeverityError
+	case severity.Medium:
+		return SeverityWarning
+	case severity.Low:
+		return SeverityInformation
+	default:
+		return SeverityHint
+	}
+}
+
+// resultRange converts the 1-based line range of a result to a range covering those whole lines
+func (s *Server) resultRange(path string, result scan.Result) Range {
+	start := result.Range().GetStartLine() - 1
+	end := result.Range().GetEndLine() - 1
+	if start < 0 {
+		start = 0
+	}
+	if end < start {
+		end = start
+	}
+	var endCharacter int
+	if lines := s.lines(path); end < len(lines) {
+		endCharacter = len(lines[end])
+	}
+	return Range{
+		Start: Position{Line: start},
+		End:   Position{Line: end, Character: endCharacter},
+	}
+}
+
+// lines returns the lines of a document, using the unsaved content if it is open
+func (s *Server) lines(path string) []string {
+	s.mu.Lock()
+	content, ok := s.documents[path]
+	s.mu.Unlock()
+	if !ok {
+		var err error
+		if content, err = os.ReadFile(path); err != nil {
+			return nil
+		}
+	}
+	return strings.Split(string(content), "\n")
+}
+
+// findingsAt returns the findings for a document which overlap the given lines, narrowest first
+func (s *Server) findingsAt(path string, startLine int, endLine int) []scan.Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matching []scan.Result
+	for _, result := range s.findings[path] {
+		if result.Range().GetStartLine()-1 <= endLine && result.Range().GetEndLine()-1 >= startLine {
+			matching = append(matching, result)
+		}
+	}
+	sort.SliceStable(matching, func(i, j int) bool {
+		return matching[i].Range().LineCount() < matching[j].Range().LineCount()
+	})
+	return matching
+}
+
+func (s *Server) hover(params TextDocumentPositionParams) *Hover {
+	path := uriToPath(params.TextDocument.URI)
+	findings := s.findingsAt(path, params.Position.Line, params.Position.Line)
+	if len(findings) == 0 {
+		return nil
+	}
+
+	var sections []string
+	for _, result := range findings {
+		rule := result.Rule()
+		section := fmt.Sprintf("**tfsec: %s** (%s)\n\n%s", rule.LongID(), result.Severity(), result.Description())
+		if rule.Impact != "" {
+			section += fmt.Sprintf("\n\n**Impact:** %s", rule.Impact)
+		}
+		if rule.Resolution != "" {
+			section += fmt.Sprintf("\n\n**Resolution:** %s", rule.Resolution)
+		}
+		if links := s.links(result); len(links) > 0 {
+			section += "\n"
+			for _, link := range links {
+				section += fmt.Sprintf("\n- %s", link)
+			}
+		}
+		sections = append(sections, section)
+	}
+
+	rng := s.resultRange(path, findings[0])
+	return &Hover{
+		Contents: MarkupContent{
+			Kind:  "markdown",
+			Value: strings.Join(sections, "\n\n---\n\n"),
+		},
+		Range: &rng,
+	}
+}
+
+// codeActions offers to ignore each finding in the given range, by inserting a tfsec:ignore comment above it
+func (s *Server) codeActions(params CodeActionParams) []CodeAction {
+	path := uriToPath(params.TextDocument.URI)
+	lines := s.lines(path)
+
+	actions := []CodeAction{}
+	seen := make(map[string]struct{})
+	for _, result := range s.findingsAt(path, params.Range.Start.Line, params.Range.End.Line) {
+		line := result.Range().GetStartLine() - 1
+		if line < 0 {
+			line = 0
+		}
+		longID := result.Rule().LongID()
+		key := fmt.Sprintf("%s:%d", longID, line)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		var indent string
+		if line < len(lines) {
+			indent = lines[line][:len(lines[line])-len(strings.TrimLeft(lines[line], " \t"))]
+		}
+		actions = append(actions, CodeAction{
+			Title:       fmt.Sprintf("Ignore %s with a tfsec:ignore comment", longID),
+			Kind:        "quickfix",
+			Diagnostics: []Diagnostic{s.diagnostic(path, result)},
+			Edit: WorkspaceEdit{
+				Changes: map[string][]TextEdit{
+					params.TextDocument.URI: {
+						{
+							Range:   Range{Start: Position{Line: line}, End: Position{Line: line}},
+							NewText: fmt.Sprintf("%s# tfsec:ignore:%s\n", indent, longID),
+						},
+					},
+				},
+			},
+		})
+	}
+	return actions
+}
+
+func uriToPath(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(parsed.Path)
+}
+
+func pathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
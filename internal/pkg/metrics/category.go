@@ -38,6 +38,8 @@ var registeredCategories []*category
 
 // ClearSession removes all categories and metrics
 func ClearSession() {
+	categoriesMu.Lock()
+	defer categoriesMu.Unlock()
 	registeredCategories = nil
 }
 
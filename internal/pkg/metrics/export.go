@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	scanner "github.com/aquasecurity/defsec/pkg/scanners/terraform"
+)
+
+// ScanTimings are the seconds spent in each phase of a scan
+type ScanTimings struct {
+	DiskIO     float64 `json:"disk_io_seconds"`
+	Parsing    float64 `json:"parsing_seconds"`
+	Adaptation float64 `json:"adaptation_seconds"`
+	Checks     float64 `json:"checks_seconds"`
+	Total      float64 `json:"total_seconds"`
+}
+
+// ScanCounts are the numbers of things read by a scan
+type ScanCounts struct {
+	ModulesDownloaded int `json:"modules_downloaded"`
+	ModulesProcessed  int `json:"modules_processed"`
+	BlocksProcessed   int `json:"blocks_processed"`
+	FilesRead         int `json:"files_read"`
+}
+
+// ScanResults are the numbers of results of a scan, with the failures counted by severity
+type ScanResults struct {
+	Passed   int `json:"passed"`
+	Ignored  int `json:"ignored"`
+	Critical int `json:"critical"`
+	High     int `json:"high"`
+	Medium   int `json:"medium"`
+	Low      int `json:"low"`
+}
+
+// RuleTiming is the seconds spent running a rule
+type RuleTiming struct {
+	ID      string  `json:"rule_id"`
+	Seconds float64 `json:"seconds"`
+}
+
+// Scan is the metrics of a scan, as written by --metrics-out
+type Scan struct {
+	Timings ScanTimings  `json:"timings"`
+	Counts  ScanCounts   `json:"counts"`
+	Results ScanResults  `json:"results"`
+	Rules   []RuleTiming `json:"rules"`
+}
+
+// FromScan gathers the metrics of a scan, with the time spent running each rule if the rules were timed, slowest first
+func FromScan(metrics scanner.Metrics) Scan {
+	exported := Scan{
+		Timings: ScanTimings{
+			DiskIO:     metrics.Parser.Timings.DiskIODuration.Seconds(),
+			Parsing:    metrics.Parser.Timings.ParseDuration.Seconds(),
+			Adaptation: metrics.Executor.Timings.Adaptation.Seconds(),
+			Checks:     metrics.Executor.Timings.RunningChecks.Seconds(),
+			Total:      metrics.Timings.Total.Seconds(),
+		},
+		Counts: ScanCounts{
+			ModulesDownloaded: metrics.Parser.Counts.ModuleDownloads,
+			ModulesProcessed:  metrics.Parser.Counts.Modules,
+			BlocksProcessed:   metrics.Parser.Counts.Blocks,
+			FilesRead:         metrics.Parser.Counts.Files,
+		},
+		Results: ScanResults{
+			Passed:   metrics.Executor.Counts.Passed,
+			Ignored:  metrics.Executor.Counts.Ignored,
+			Critical: metrics.Executor.Counts.Critical,
+			High:     metrics.Executor.Counts.High,
+			Medium:   metrics.Executor.Counts.Medium,
+			Low:      metrics.Executor.Counts.Low,
+		},
+		Rules: ruleTimings(),
+	}
+	return exported
+}
+
+// WriteJSON writes the metrics as JSON
+func (s Scan) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(s)
+}
+
+// WriteOpenMetrics writes the metrics in the OpenMetrics text format, which Prometheus can scrape or import
+func (s Scan) WriteOpenMetrics(w io.Writer) error {
+	var b strings.Builder
+
+	writeFamily(&b, "tfsec_scan_duration_seconds", "Seconds spent in each phase of the scan.")
+	for _, phase := range []struct {
+		name    string
+		seconds float64
+	}{
+		{"disk_io", s.Timings.DiskIO},
+		{"parsing", s.Timings.Parsing},
+		{"adaptation", s.Timings.Adaptation},
+		{"checks", s.Timings.Checks},
+		{"total", s.Timings.Total},
+	} {
+		writeSample(&b, "tfsec_scan_duration_seconds", "phase", phase.name, phase.seconds)
+	}
+
+	for _, count := range []struct {
+		name  string
+		help  string
+		value int
+	}{
+		{"tfsec_modules_downloaded", "Remote modules downloaded by the scan.", s.Counts.ModulesDownloaded},
+		{"tfsec_modules_processed", "Modules processed by the scan.", s.Counts.ModulesProcessed},
+		{"tfsec_blocks_processed", "Blocks processed by the scan.", s.Counts.BlocksProcessed},
+		{"tfsec_files_read", "Files read by the scan.", s.Counts.FilesRead},
+	} {
+		writeFamily(&b, count.name, count.help)
+		writeSample(&b, count.name, "", "", float64(count.value))
+	}
+
+	writeFamily(&b, "tfsec_results", "Results of the scan, by status, with failures by severity.")
+	for _, result := range []struct {
+		status   string
+		severity string
+		count    int
+	}{
+		{"passed", "", s.Results.Passed},
+		{"ignored", "", s.Results.Ignored},
+		{"failed", "CRITICAL", s.Results.Critical},
+		{"failed", "HIGH", s.Results.High},
+		{"failed", "MEDIUM", s.Results.Medium},
+		{"failed", "LOW", s.Results.Low},
+	} {
+		labels := fmt.Sprintf(`status="%s"`, result.status)
+		if result.severity != "" {
+			labels += fmt.Sprintf(`,severity="%s"`, result.severity)
+		}
+		_, _ = fmt.Fprintf(&b, "tfsec_results{%s} %d\n", labels, result.count)
+	}
+
+	if len(s.Rules) > 0 {
+		writeFamily(&b, "tfsec_rule_duration_seconds", "Seconds spent running each rule.")
+		for _, rule := range s.Rules {
+			writeSample(&b, "tfsec_rule_duration_seconds", "rule", rule.ID, rule.Seconds)
+		}
+	}
+
+	b.WriteString("# EOF\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeFamily(b *strings.Builder, name, help string) {
+	_, _ = fmt.Fprintf(b, "# TYPE %s gauge\n# HELP %s %s\n", name, name, help)
+}
+
+func writeSample(b *strings.Builder, name, label, value string, sample float64) {
+	if label == "" {
+		_, _ = fmt.Fprintf(b, "%s %s\n", name, formatSample(sample))
+		return
+	}
+	_, _ = fmt.Fprintf(b, "%s{%s=\"%s\"} %s\n", name, label, escapeLabel(value), formatSample(sample))
+}
+
+func formatSample(sample float64) string {
+	return fmt.Sprintf("%g", sample)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
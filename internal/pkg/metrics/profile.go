@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+)
+
+// RuleModuleTiming is the seconds spent running a rule on the blocks of one module
+type RuleModuleTiming struct {
+	ID      string  `json:"rule_id"`
+	Module  string  `json:"module"`
+	Seconds float64 `json:"seconds"`
+}
+
+// Profile is the slowest rules of a scan, as reported by --profile-rules. Only checks which are run block by block,
+// such as custom checks, are timed for each module; the others check every module at once.
+type Profile struct {
+	Rules       []RuleTiming       `json:"slowest_rules"`
+	RuleModules []RuleModuleTiming `json:"slowest_rule_modules"`
+}
+
+// RuleProfile returns the top slowest rules, and the top slowest pairs of rule and module, of the timed rules
+func RuleProfile(top int) *Profile {
+	profile := &Profile{
+		Rules:       ruleTimings(),
+		RuleModules: []RuleModuleTiming{},
+	}
+	for _, timer := range timers(RuleModulesCategory) {
+		id, module, _ := strings.Cut(timer.Name(), " ")
+		profile.RuleModules = append(profile.RuleModules, RuleModuleTiming{
+			ID:      id,
+			Module:  module,
+			Seconds: timer.Duration().Seconds(),
+		})
+	}
+	sort.Slice(profile.RuleModules, func(i, j int) bool {
+		a, b := profile.RuleModules[i], profile.RuleModules[j]
+		if a.Seconds != b.Seconds {
+			return a.Seconds > b.Seconds
+		}
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		return a.Module < b.Module
+	})
+
+	if top > 0 {
+		if len(profile.Rules) > top {
+			profile.Rules = profile.Rules[:top]
+		}
+		if len(profile.RuleModules) > top {
+			profile.RuleModules = profile.RuleModules[:top]
+		}
+	}
+	return profile
+}
+
+// ruleTimings returns the time spent running each timed rule, slowest first
+func ruleTimings() []RuleTiming {
+	timings := []RuleTiming{}
+	for _, timer := range timers(RulesCategory) {
+		timings = append(timings, RuleTiming{ID: timer.Name(), Seconds: timer.Duration().Seconds()})
+	}
+	sort.Slice(timings, func(i, j int) bool {
+		if timings[i].Seconds != timings[j].Seconds {
+			return timings[i].Seconds > timings[j].Seconds
+		}
+		return timings[i].ID < timings[j].ID
+	})
+	return timings
+}
+
+func timers(category string) []TimerMetric {
+	var found []TimerMetric
+	for _, cat := range General() {
+		if cat.Name() != category {
+			continue
+		}
+		for _, metric := range cat.Metrics() {
+			if timer, ok := metric.(TimerMetric); ok {
+				found = append(found, timer)
+			}
+		}
+	}
+	return found
+}
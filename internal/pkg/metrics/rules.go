@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aquasecurity/defsec/pkg/framework"
+	"github.com/aquasecurity/defsec/pkg/rules"
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/defsec/pkg/state"
+	"github.com/aquasecurity/defsec/pkg/terraform"
+	"github.com/aquasecurity/tfsec/internal/pkg/statistics"
+)
+
+// RulesCategory is the category of the timers of each rule, named by the rule's long ID
+const RulesCategory = "rules"
+
+// RuleModulesCategory is the category of the timers of each rule checked block by block, for each module, named by
+// the rule's long ID and the module's address, separated by a space
+const RuleModulesCategory = "rules by module"
+
+// TimedFramework is the framework the timed copies of the rules are registered under. Scanning with only this
+// framework runs the same rules as a default scan, timing each of them.
+const TimedFramework framework.Framework = "tfsec-timed-rules"
+
+// registeredRule is a rule from the rule registry, which has its check func
+type registeredRule interface {
+	Rule() scan.Rule
+	Evaluate(*state.State) scan.Results
+}
+
+var timedRules struct {
+	sync.Mutex
+	count int
+}
+
+// TimeRules registers a timed copy of each rule a default scan runs, under TimedFramework, and returns the framework.
+// The rule registry cannot be changed once rules are registered, so copies are made of rules, such as custom checks,
+// which were registered since the last call.
+func TimeRules() framework.Framework {
+	timedRules.Lock()
+	defer timedRules.Unlock()
+
+	registered := rules.GetRegistered()
+	for _, original := range registered[timedRules.count:] {
+		rules.Register(timedRule(original.Rule()), timedCheck(original))
+	}
+	timedRules.count = len(registered)
+	return TimedFramework
+}
+
+func timedRule(rule scan.Rule) scan.Rule {
+	id := rule.LongID()
+	rule.Frameworks = map[framework.Framework][]string{TimedFramework: nil}
+
+	// checks written in terms of blocks, such as custom checks, are run by the scanner block by block rather than
+	// through the check func
+	if custom := rule.CustomChecks.Terraform; custom != nil && custom.Check != nil {
+		timed := *custom
+		check := custom.Check
+		timed.Check = func(block *terraform.Block, module *terraform.Module) scan.Results {
+			defer recordRuleModule(id, statistics.ModuleAddress(block.GetMetadata()), time.Now())
+			return check(block, module)
+		}
+		rule.CustomChecks.Terraform = &timed
+	}
+	return rule
+}
+
+func timedCheck(original registeredRule) scan.CheckFunc {
+	id := original.Rule().LongID()
+	return func(s *state.State) scan.Results {
+		defer recordRule(id, time.Now())
+		return original.Evaluate(s)
+	}
+}
+
+// recording serialises finding or creating the timer of a rule, which is checked concurrently
+var recording sync.Mutex
+
+func recordRule(id string, started time.Time) {
+	elapsed := time.Since(started)
+	recording.Lock()
+	defer recording.Unlock()
+	Timer(RulesCategory, id).Add(elapsed)
+}
+
+func recordRuleModule(id, module string, started time.Time) {
+	elapsed := time.Since(started)
+	recording.Lock()
+	defer recording.Unlock()
+	Timer(RulesCategory, id).Add(elapsed)
+	Timer(RuleModulesCategory, id+" "+module).Add(elapsed)
+}
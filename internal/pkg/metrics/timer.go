@@ -9,6 +9,9 @@ type TimerMetric interface {
 	Metric
 	Start()
 	Stop()
+	// Add adds time measured elsewhere, for work which runs concurrently and so cannot share Start and Stop
+	Add(d time.Duration)
+	Duration() time.Duration
 }
 
 type timerMetric struct {
@@ -57,10 +60,22 @@ func (t *timerMetric) Stop() {
 	t.total += now.Sub(t.started)
 }
 
+func (t *timerMetric) Add(d time.Duration) {
+	t.Lock()
+	defer t.Unlock()
+	t.total += d
+}
+
+func (t *timerMetric) Duration() time.Duration {
+	t.Lock()
+	defer t.Unlock()
+	return t.total
+}
+
 func (t *timerMetric) Name() string {
 	return t.name
 }
 
 func (t *timerMetric) Value() string {
-	return t.total.String()
+	return t.Duration().String()
 }
@@ -0,0 +1,92 @@
+package remediation
+
+// builtin holds the fixes for built in rules which have a single correct value, so can be fixed without any
+// knowledge of the wider infrastructure
+var builtin = map[string][]Fix{
+	"aws-cloudtrail-enable-all-regions": {
+		set("aws_cloudtrail", "is_multi_region_trail", true),
+	},
+	"aws-cloudtrail-enable-log-validation": {
+		set("aws_cloudtrail", "enable_log_file_validation", true),
+	},
+	"aws-documentdb-enable-storage-encryption": {
+		set("aws_docdb_cluster", "storage_encrypted", true),
+	},
+	"aws-dynamodb-enable-recovery": {
+		set("aws_dynamodb_table", "point_in_time_recovery.enabled", true),
+	},
+	"aws-ec2-enable-at-rest-encryption": {
+		set("aws_instance", "root_block_device.encrypted", true),
+	},
+	"aws-ec2-enable-volume-encryption": {
+		set("aws_ebs_volume", "encrypted", true),
+	},
+	"aws-ec2-enforce-http-token-imds": {
+		set("aws_instance", "metadata_options.http_tokens", "required"),
+	},
+	"aws-ecr-enable-image-scans": {
+		set("aws_ecr_repository", "image_scanning_configuration.scan_on_push", true),
+	},
+	"aws-ecr-enforce-immutable-repository": {
+		set("aws_ecr_repository", "image_tag_mutability", "IMMUTABLE"),
+	},
+	"aws-efs-enable-at-rest-encryption": {
+		set("aws_efs_file_system", "encrypted", true),
+	},
+	"aws-elasticache-enable-at-rest-encryption": {
+		set("aws_elasticache_replication_group", "at_rest_encryption_enabled", true),
+	},
+	"aws-elasticache-enable-in-transit-encryption": {
+		set("aws_elasticache_replication_group", "transit_encryption_enabled", true),
+	},
+	"aws-elb-drop-invalid-headers": {
+		set("aws_lb", "drop_invalid_header_fields", true),
+		set("aws_alb", "drop_invalid_header_fields", true),
+	},
+	"aws-kms-auto-rotate-keys": {
+		set("aws_kms_key", "enable_key_rotation", true),
+	},
+	"aws-neptune-enable-storage-encryption": {
+		set("aws_neptune_cluster", "storage_encrypted", true),
+	},
+	"aws-rds-encrypt-cluster-storage-data": {
+		set("aws_rds_cluster", "storage_encrypted", true),
+	},
+	"aws-rds-encrypt-instance-storage-data": {
+		set("aws_db_instance", "storage_encrypted", true),
+	},
+	"aws-s3-block-public-acls": {
+		set("aws_s3_bucket_public_access_block", "block_public_acls", true),
+	},
+	"aws-s3-block-public-policy": {
+		set("aws_s3_bucket_public_access_block", "block_public_policy", true),
+	},
+	"aws-s3-enable-versioning": {
+		set("aws_s3_bucket", "versioning.enabled", true),
+		set("aws_s3_bucket_versioning", "versioning_configuration.status", "Enabled"),
+	},
+	"aws-s3-ignore-public-acls": {
+		set("aws_s3_bucket_public_access_block", "ignore_public_acls", true),
+	},
+	"aws-s3-no-public-buckets": {
+		set("aws_s3_bucket_public_access_block", "restrict_public_buckets", true),
+	},
+	"azure-storage-enforce-https": {
+		set("azurerm_storage_account", "enable_https_traffic_only", true),
+	},
+	"azure-storage-use-secure-tls-policy": {
+		set("azurerm_storage_account", "min_tls_version", "TLS1_2"),
+	},
+	"google-storage-enable-ubla": {
+		set("google_storage_bucket", "uniform_bucket_level_access", true),
+	},
+}
+
+func set(blockType string, attribute string, value interface{}) Fix {
+	return Fix{
+		BlockTypes: []string{blockType},
+		Edits: []Edit{
+			{Action: Set, Attribute: attribute, Value: value},
+		},
+	}
+}
@@ -0,0 +1,148 @@
+package remediation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type EditAction string
+
+// Set sets the attribute to the edit value, creating any nested blocks in its path which are missing
+const Set EditAction = "set"
+
+// Remove removes the attribute, if it is present
+const Remove EditAction = "remove"
+
+// Edit is a change to an attribute of a block. The attribute is a dot separated path, where all but the last part
+// name nested blocks, such as metadata_options.http_tokens.
+type Edit struct {
+	Action    EditAction  `json:"action,omitempty" yaml:"action,omitempty"`
+	Attribute string      `json:"attribute" yaml:"attribute"`
+	Value     interface{} `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+func (e Edit) action() EditAction {
+	if e.Action == "" {
+		return Set
+	}
+	return e.Action
+}
+
+// Validate checks that the edit can be applied
+func (e Edit) Validate() error {
+	if e.Attribute == "" {
+		return fmt.Errorf("fix.Attribute requires a value")
+	}
+	for _, part := range strings.Split(e.Attribute, ".") {
+		if part == "" {
+			return fmt.Errorf("fix.Attribute[%s] is not a valid attribute path", e.Attribute)
+		}
+	}
+	switch e.action() {
+	case Set:
+		if e.Value == nil {
+			return fmt.Errorf("fix.Value requires a value for the %s action", Set)
+		}
+		if _, err := toCty(e.Value); err != nil {
+			return fmt.Errorf("fix.Value is not valid: %w", err)
+		}
+	case Remove:
+	default:
+		return fmt.Errorf("fix.Action[%s] is not a recognised option. Should be %s or %s", e.Action, Set, Remove)
+	}
+	return nil
+}
+
+// String describes the edit, such as "set enable_key_rotation = true"
+func (e Edit) String() string {
+	if e.action() == Remove {
+		return fmt.Sprintf("remove %s", e.Attribute)
+	}
+	value, err := toCty(e.Value)
+	if err != nil {
+		return fmt.Sprintf("set %s = %v", e.Attribute, e.Value)
+	}
+	return fmt.Sprintf("set %s = %s", e.Attribute, strings.TrimSpace(string(hclwrite.TokensForValue(value).Bytes())))
+}
+
+func (e Edit) apply(body *hclwrite.Body) error {
+	parts := strings.Split(e.Attribute, ".")
+	for _, name := range parts[:len(parts)-1] {
+		child := body.FirstMatchingBlock(name, nil)
+		if child == nil {
+			if e.action() == Remove {
+				return nil
+			}
+			child = body.AppendNewBlock(name, nil)
+		}
+		body = child.Body()
+	}
+
+	name := parts[len(parts)-1]
+	switch e.action() {
+	case Remove:
+		body.RemoveAttribute(name)
+	case Set:
+		value, err := toCty(e.Value)
+		if err != nil {
+			return err
+		}
+		body.SetAttributeValue(name, value)
+	default:
+		return fmt.Errorf("unknown fix action '%s'", e.Action)
+	}
+	return nil
+}
+
+// toCty converts a value decoded from JSON or YAML to a cty value
+func toCty(value interface{}) (cty.Value, error) {
+	switch v := value.(type) {
+	case bool:
+		return cty.BoolVal(v), nil
+	case string:
+		return cty.StringVal(v), nil
+	case int:
+		return cty.NumberIntVal(int64(v)), nil
+	case int64:
+		return cty.NumberIntVal(v), nil
+	case float64:
+		return cty.NumberFloatVal(v), nil
+	case []interface{}:
+		if len(v) == 0 {
+			return cty.EmptyTupleVal, nil
+		}
+		values := make([]cty.Value, 0, len(v))
+		for _, item := range v {
+			converted, err := toCty(item)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			values = append(values, converted)
+		}
+		return cty.TupleVal(values), nil
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return cty.EmptyObjectVal, nil
+		}
+		values := make(map[string]cty.Value, len(v))
+		for key, item := range v {
+			converted, err := toCty(item)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			values[key] = converted
+		}
+		return cty.ObjectVal(values), nil
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[fmt.Sprintf("%v", key)] = item
+		}
+		return toCty(converted)
+	default:
+		return cty.NilVal, fmt.Errorf("unsupported value type %T", value)
+	}
+}
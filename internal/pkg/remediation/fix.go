@@ -0,0 +1,265 @@
+package remediation
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// Change is the outcome of fixing a single failed result
+type Change struct {
+	Result scan.Result
+	// Path is the absolute path of the file containing the result
+	Path string
+	// Block is the address of the block the edits were made to, such as aws_kms_key.example
+	Block string
+	Edits []Edit
+	// Skipped is the reason the result could not be fixed, if it was not
+	Skipped string
+}
+
+// Fixed returns true if edits were made for the result
+func (c Change) Fixed() bool {
+	return c.Skipped == ""
+}
+
+// Plan holds the edits which fix a set of results, along with the new content of each file they change
+type Plan struct {
+	Changes []Change
+	files   map[string][]byte
+}
+
+// NewPlan works out the edits which fix the failed results, and applies them to the content of the files in memory.
+// Nothing is written until Write is called. Result filenames are relative to fsRoot.
+func NewPlan(results scan.Results, fsRoot string) (*Plan, error) {
+	plan := &Plan{
+		files: make(map[string][]byte),
+	}
+
+	byFile := make(map[string][]scan.Result)
+	var paths []string
+	for _, result := range results.GetFailed() {
+		path := filepath.Join(fsRoot, filepath.FromSlash(result.Range().GetFilename()))
+		if _, ok := byFile[path]; !ok {
+			paths = append(paths, path)
+		}
+		byFile[path] = append(byFile[path], result)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		changes, content, err := fixFile(path, byFile[path])
+		if err != nil {
+			return nil, err
+		}
+		plan.Changes = append(plan.Changes, changes...)
+		if content != nil {
+			plan.files[path] = content
+		}
+	}
+	return plan, nil
+}
+
+// Fixed returns the changes which made edits
+func (p *Plan) Fixed() []Change {
+	var fixed []Change
+	for _, change := range p.Changes {
+		if change.Fixed() {
+			fixed = append(fixed, change)
+		}
+	}
+	return fixed
+}
+
+// Files returns the paths of the files which the plan changes, in order
+func (p *Plan) Files() []string {
+	var paths []string
+	for path := range p.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Write writes the fixed content of each changed file
+func (p *Plan) Write() error {
+	for _, path := range p.Files() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, p.files[path], info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func skipAll(path string, results []scan.Result, reason string) []Change {
+	changes := make([]Change, 0, len(results))
+	for _, result := range results {
+		changes = append(changes, Change{Result: result, Path: path, Skipped: reason})
+	}
+	return changes
+}
+
+// parsedFile is a file parsed both as a syntax tree, which has the position of each block, and as a write tree,
+// which can be edited. Both parsers see the same top level blocks in the same order, so blocks found by line in the
+// syntax tree can be edited through the same index in the write tree.
+type parsedFile struct {
+	content      []byte
+	syntaxBlocks hclsyntax.Blocks
+	writeBlocks  []*hclwrite.Block
+}
+
+// parseFile parses a file which fixes can be applied to, or returns the reason fixes can't be applied to it
+func parseFile(path string) (*parsedFile, string) {
+	if filepath.Ext(path) != ".tf" {
+		return nil, "only .tf files can be fixed"
+	}
+	for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+		if part == ".terraform" {
+			return nil, "the file is part of a downloaded module"
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Sprintf("the file could not be read: %s", err)
+	}
+	syntaxFile, diags := hclsyntax.ParseConfig(content, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Sprintf("the file could not be parsed: %s", diags.Error())
+	}
+	writeFile, diags := hclwrite.ParseConfig(content, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Sprintf("the file could not be parsed: %s", diags.Error())
+	}
+
+	parsed := &parsedFile{
+		content:      content,
+		syntaxBlocks: syntaxFile.Body.(*hclsyntax.Body).Blocks,
+		writeBlocks:  writeFile.Body().Blocks(),
+	}
+	if len(parsed.syntaxBlocks) != len(parsed.writeBlocks) {
+		return nil, "the file could not be parsed"
+	}
+	return parsed, ""
+}
+
+// blockAt returns the index of the top level block which contains the given line, or -1 if there isn't one
+func (p *parsedFile) blockAt(line int) int {
+	for i, block := range p.syntaxBlocks {
+		if block.Range().Start.Line <= line && block.Range().End.Line >= line {
+			return i
+		}
+	}
+	return -1
+}
+
+// fixFile applies the fixes for the results in a single file, returning the new content of the file, or nil if
+// nothing was changed
+func fixFile(path string, results []scan.Result) ([]Change, []byte, error) {
+	parsed, reason := parseFile(path)
+	if parsed == nil {
+		return skipAll(path, results, reason), nil, nil
+	}
+	original, syntaxBlocks, writeBlocks := parsed.content, parsed.syntaxBlocks, parsed.writeBlocks
+
+	var changes []Change
+	edited := make(map[int]struct{})
+	for _, result := range results {
+		index := parsed.blockAt(result.Range().GetStartLine())
+		if index < 0 {
+			changes = append(changes, Change{Result: result, Path: path, Skipped: "the result is not inside a block"})
+			continue
+		}
+
+		block := syntaxBlocks[index]
+		change := Change{
+			Result: result,
+			Path:   path,
+			Block:  blockAddress(block),
+		}
+		change.Edits = Lookup(result.Rule().LongID(), blockType(block))
+		if len(change.Edits) == 0 {
+			change.Skipped = "no automatic fix is available"
+			changes = append(changes, change)
+			continue
+		}
+		for _, edit := range change.Edits {
+			if err := edit.apply(writeBlocks[index].Body()); err != nil {
+				return nil, nil, fmt.Errorf("failed to fix %s in %s: %w", result.Rule().LongID(), path, err)
+			}
+		}
+		edited[index] = struct{}{}
+		changes = append(changes, change)
+	}
+
+	if len(edited) == 0 {
+		return changes, nil, nil
+	}
+
+	// only the edited blocks are replaced, so the rest of the file keeps its formatting exactly
+	var indexes []int
+	for index := range edited {
+		indexes = append(indexes, index)
+	}
+	// replace from the end of the file, so the byte offsets of earlier blocks stay valid
+	sort.Sort(sort.Reverse(sort.IntSlice(indexes)))
+
+	content := append([]byte{}, original...)
+	for _, index := range indexes {
+		replacement, err := formatBlock(writeBlocks[index], path)
+		if err != nil {
+			return nil, nil, err
+		}
+		rng := syntaxBlocks[index].Range()
+		content = append(content[:rng.Start.Byte:rng.Start.Byte], append(replacement, content[rng.End.Byte:]...)...)
+	}
+	if bytes.Equal(content, original) {
+		return changes, nil, nil
+	}
+	return changes, content, nil
+}
+
+// formatBlock returns the formatted source of an edited block. Attributes and blocks added through hclwrite are
+// not indented, so the block needs formatting. The tokens of a block include the comments around it, so the block
+// itself is found within them, as those comments are left in place in the original file.
+func formatBlock(block *hclwrite.Block, path string) ([]byte, error) {
+	formatted := hclwrite.Format(block.BuildTokens(nil).Bytes())
+	file, diags := hclsyntax.ParseConfig(formatted, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("fixes produced invalid HCL in %s: %s", path, diags.Error())
+	}
+	blocks := file.Body.(*hclsyntax.Body).Blocks
+	if len(blocks) != 1 {
+		return nil, fmt.Errorf("fixes produced invalid HCL in %s", path)
+	}
+	rng := blocks[0].Range()
+	return formatted[rng.Start.Byte:rng.End.Byte], nil
+}
+
+func blockType(block *hclsyntax.Block) string {
+	if len(block.Labels) > 0 {
+		return block.Labels[0]
+	}
+	return ""
+}
+
+func blockAddress(block *hclsyntax.Block) string {
+	switch block.Type {
+	case "resource":
+		return strings.Join(block.Labels, ".")
+	default:
+		return strings.Join(append([]string{block.Type}, block.Labels...), ".")
+	}
+}
@@ -0,0 +1,71 @@
+package remediation
+
+import (
+	"strings"
+	"sync"
+)
+
+// Fix is a set of edits which remediates the findings of a rule for blocks of the given types. Block types are
+// matched against the first label of the block, such as the resource type, and an empty list or "*" matches any
+// block.
+type Fix struct {
+	BlockTypes []string
+	Edits      []Edit
+}
+
+func (f Fix) appliesTo(blockType string) bool {
+	if len(f.BlockTypes) == 0 {
+		return true
+	}
+	for _, t := range f.BlockTypes {
+		if t == "*" || t == blockType {
+			return true
+		}
+	}
+	return false
+}
+
+// registered holds the fixes for custom checks, which take precedence over the built in fixes
+var registered = struct {
+	sync.Mutex
+	fixes map[string]Fix
+}{
+	fixes: make(map[string]Fix),
+}
+
+// Register sets the fix for the rule with the given ID, replacing any fix previously registered for it
+func Register(longID string, fix Fix) {
+	registered.Lock()
+	defer registered.Unlock()
+	registered.fixes[strings.ToLower(longID)] = fix
+}
+
+// Unregister removes the fix registered for the rule with the given ID
+func Unregister(longID string) {
+	registered.Lock()
+	defer registered.Unlock()
+	delete(registered.fixes, strings.ToLower(longID))
+}
+
+// Lookup returns the edits which fix a finding of the rule with the given ID in a block of the given type, or nil if
+// there is no fix
+func Lookup(longID string, blockType string) []Edit {
+	longID = strings.ToLower(longID)
+
+	registered.Lock()
+	fix, ok := registered.fixes[longID]
+	registered.Unlock()
+	if ok {
+		if fix.appliesTo(blockType) {
+			return fix.Edits
+		}
+		return nil
+	}
+
+	for _, fix := range builtin[longID] {
+		if fix.appliesTo(blockType) {
+			return fix.Edits
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package remediation
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EditValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    Edit
+		wantErr string
+	}{
+		{name: "set", edit: Edit{Attribute: "encrypted", Value: true}},
+		{name: "nested set", edit: Edit{Action: Set, Attribute: "metadata_options.http_tokens", Value: "required"}},
+		{name: "remove", edit: Edit{Action: Remove, Attribute: "acl"}},
+		{name: "missing attribute", edit: Edit{Value: true}, wantErr: "fix.Attribute requires a value"},
+		{name: "bad path", edit: Edit{Attribute: "a..b", Value: true}, wantErr: "not a valid attribute path"},
+		{name: "missing value", edit: Edit{Attribute: "encrypted"}, wantErr: "fix.Value requires a value"},
+		{name: "unknown action", edit: Edit{Action: "rename", Attribute: "encrypted"}, wantErr: "fix.Action[rename] is not a recognised option"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.edit.Validate()
+			if test.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, test.wantErr)
+		})
+	}
+}
+
+func Test_EditString(t *testing.T) {
+	assert.Equal(t, "set enable_key_rotation = true", Edit{Attribute: "enable_key_rotation", Value: true}.String())
+	assert.Equal(t, `set tags = ["a", "b"]`, Edit{Attribute: "tags", Value: []interface{}{"a", "b"}}.String())
+	assert.Equal(t, "remove acl", Edit{Action: Remove, Attribute: "acl"}.String())
+}
+
+func Test_EditApply(t *testing.T) {
+	file, diags := hclwrite.ParseConfig([]byte(`resource "aws_instance" "web" {
+  acl = "public-read"
+  metadata_options {
+    http_endpoint = "enabled"
+  }
+}
+`), "main.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors())
+	body := file.Body().Blocks()[0].Body()
+
+	require.NoError(t, Edit{Attribute: "metadata_options.http_tokens", Value: "required"}.apply(body))
+	require.NoError(t, Edit{Attribute: "root_block_device.encrypted", Value: true}.apply(body))
+	require.NoError(t, Edit{Action: Remove, Attribute: "acl"}.apply(body))
+	require.NoError(t, Edit{Action: Remove, Attribute: "missing.thing"}.apply(body))
+
+	assert.Equal(t, `resource "aws_instance" "web" {
+  metadata_options {
+    http_endpoint = "enabled"
+    http_tokens   = "required"
+  }
+  root_block_device {
+    encrypted = true
+  }
+}
+`, string(hclwrite.Format(file.Bytes())))
+}
+
+func Test_Lookup(t *testing.T) {
+	assert.Equal(t, []Edit{{Action: Set, Attribute: "enable_key_rotation", Value: true}}, Lookup("aws-kms-auto-rotate-keys", "aws_kms_key"))
+	assert.Nil(t, Lookup("aws-kms-auto-rotate-keys", "aws_s3_bucket"))
+	assert.Nil(t, Lookup("aws-s3-enable-bucket-encryption", "aws_s3_bucket"))
+
+	custom := []Edit{{Attribute: "ok", Value: true}}
+	Register("CUSTOM-custom-fix001", Fix{BlockTypes: []string{"*"}, Edits: custom})
+	assert.Equal(t, custom, Lookup("custom-custom-fix001", "anything"))
+
+	Register("aws-kms-auto-rotate-keys", Fix{BlockTypes: []string{"aws_kms_key"}, Edits: custom})
+	assert.Equal(t, custom, Lookup("aws-kms-auto-rotate-keys", "aws_kms_key"))
+
+	Unregister("custom-custom-fix001")
+	Unregister("aws-kms-auto-rotate-keys")
+	assert.Nil(t, Lookup("custom-custom-fix001", "anything"))
+	assert.Equal(t, []Edit{{Action: Set, Attribute: "enable_key_rotation", Value: true}}, Lookup("aws-kms-auto-rotate-keys", "aws_kms_key"))
+}
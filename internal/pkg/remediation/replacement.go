@@ -0,0 +1,67 @@
+package remediation
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/scan"
+)
+
+// Replacement is a fix for a single result, as new source for the block which contains it. Lines and columns start
+// at 1, and the end column is the column after the last character replaced.
+type Replacement struct {
+	Edits       []Edit
+	StartLine   int
+	StartColumn int
+	EndLine     int
+	EndColumn   int
+	Text        string
+}
+
+// Description describes the edits made by the replacement
+func (r *Replacement) Description() string {
+	var descriptions []string
+	for _, edit := range r.Edits {
+		descriptions = append(descriptions, edit.String())
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// ReplacementFor works out the fix for a single failed result, without applying the fixes for any other results
+// in the same block. It returns nil if the result can't be fixed. Result filenames are relative to fsRoot.
+func ReplacementFor(result scan.Result, fsRoot string) (*Replacement, error) {
+	path := filepath.Join(fsRoot, filepath.FromSlash(result.Range().GetFilename()))
+	parsed, _ := parseFile(path)
+	if parsed == nil {
+		return nil, nil
+	}
+	index := parsed.blockAt(result.Range().GetStartLine())
+	if index < 0 {
+		return nil, nil
+	}
+	block := parsed.syntaxBlocks[index]
+	edits := Lookup(result.Rule().LongID(), blockType(block))
+	if len(edits) == 0 {
+		return nil, nil
+	}
+	for _, edit := range edits {
+		if err := edit.apply(parsed.writeBlocks[index].Body()); err != nil {
+			return nil, fmt.Errorf("failed to fix %s in %s: %w", result.Rule().LongID(), path, err)
+		}
+	}
+	text, err := formatBlock(parsed.writeBlocks[index], path)
+	if err != nil {
+		return nil, err
+	}
+
+	rng := block.Range()
+	return &Replacement{
+		Edits:       edits,
+		StartLine:   rng.Start.Line,
+		StartColumn: rng.Start.Column,
+		EndLine:     rng.End.Line,
+		EndColumn:   rng.End.Column,
+		Text:        string(text),
+	}, nil
+}
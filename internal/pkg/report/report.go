@@ -0,0 +1,92 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/framework"
+	"github.com/aquasecurity/defsec/pkg/rules"
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/defsec/pkg/types"
+)
+
+// Load reads the results from a report written with --format json
+func Load(path string) ([]scan.FlatResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var report struct {
+		Results json.RawMessage `json:"results"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("%s is not a tfsec JSON report: %w", path, err)
+	}
+	if report.Results == nil {
+		return nil, fmt.Errorf("%s is not a tfsec JSON report: it has no results", path)
+	}
+	var results []scan.FlatResult
+	if err := json.Unmarshal(report.Results, &results); err != nil {
+		return nil, fmt.Errorf("%s is not a tfsec JSON report: %w", path, err)
+	}
+	return results, nil
+}
+
+// Rehydrate turns the results of a JSON report back into scan results, so that they can be written in any other
+// format. The rules are looked up in the rule registry, so rules which are not registered, such as custom checks,
+// only have the metadata which was in the report. Filenames are made relative to fsRoot, and code is read from
+// srcFS, which is rooted at fsRoot.
+func Rehydrate(flat []scan.FlatResult, fsRoot string, srcFS fs.FS) scan.Results {
+	registered := make(map[string]scan.Rule)
+	for _, rule := range rules.GetRegistered(framework.ALL) {
+		registered[rule.Rule().LongID()] = rule.Rule()
+	}
+
+	var results scan.Results
+	for _, result := range flat {
+		rule, ok := registered[result.LongID]
+		if !ok {
+			rule = ruleFromResult(result)
+		}
+		// the severity in the report includes any overrides, so it wins over the severity of the registered rule
+		rule.Severity = result.Severity
+
+		var single scan.Results
+		single.Add(result.Description, types.NewMetadata(rangeFromResult(result, fsRoot, srcFS), result.Resource))
+		single.SetRule(rule)
+		single[0].OverrideStatus(result.Status)
+		results = append(results, single[0])
+	}
+	return results
+}
+
+func rangeFromResult(result scan.FlatResult, fsRoot string, srcFS fs.FS) types.Range {
+	location := result.Location
+	if filepath.IsAbs(location.Filename) {
+		if relative, err := filepath.Rel(fsRoot, location.Filename); err == nil {
+			return types.NewRange(filepath.ToSlash(relative), location.StartLine, location.EndLine, "", srcFS)
+		}
+	}
+	// the results of remote modules are named by their module source rather than a path on disk, so the source
+	// is kept as it was and there is no code for them
+	return types.NewRange(filepath.Base(location.Filename), location.StartLine, location.EndLine, filepath.Dir(location.Filename), nil)
+}
+
+func ruleFromResult(result scan.FlatResult) scan.Rule {
+	prefix := strings.ToLower(fmt.Sprintf("%s-%s-", result.RuleProvider, result.RuleService))
+	return scan.Rule{
+		AVDID:      result.RuleID,
+		Provider:   result.RuleProvider,
+		Service:    result.RuleService,
+		ShortCode:  strings.TrimPrefix(result.LongID, prefix),
+		Summary:    result.RuleSummary,
+		Impact:     result.Impact,
+		Resolution: result.Resolution,
+		Links:      result.Links,
+		Severity:   result.Severity,
+	}
+}
@@ -0,0 +1,93 @@
+package report
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/aquasecurity/defsec/pkg/providers"
+	"github.com/aquasecurity/defsec/pkg/scan"
+	_ "github.com/aquasecurity/defsec/pkg/scanners/terraform"
+	"github.com/aquasecurity/defsec/pkg/severity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Load(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.json")
+	require.NoError(t, os.WriteFile(valid, []byte(`{"results": [{"long_id": "aws-kms-auto-rotate-keys", "severity": "MEDIUM", "status": 0}]}`), 0o600))
+	results, err := Load(valid)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "aws-kms-auto-rotate-keys", results[0].LongID)
+
+	empty := filepath.Join(dir, "empty.json")
+	require.NoError(t, os.WriteFile(empty, []byte(`{"results": []}`), 0o600))
+	results, err = Load(empty)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	sarif := filepath.Join(dir, "report.sarif")
+	require.NoError(t, os.WriteFile(sarif, []byte(`{"version": "2.1.0", "runs": []}`), 0o600))
+	_, err = Load(sarif)
+	assert.ErrorContains(t, err, "it has no results")
+}
+
+func Test_Rehydrate(t *testing.T) {
+	srcFS := fstest.MapFS{
+		"project/main.tf": &fstest.MapFile{Data: []byte("resource \"aws_kms_key\" \"key\" {\n}\n")},
+	}
+	flat := []scan.FlatResult{
+		{
+			RuleID:       "AVD-AWS-0065",
+			LongID:       "aws-kms-auto-rotate-keys",
+			RuleProvider: providers.AWSProvider,
+			RuleService:  "kms",
+			Description:  "Key does not have rotation enabled.",
+			Severity:     severity.High,
+			Status:       scan.StatusIgnored,
+			Resource:     "aws_kms_key.key",
+			Location:     scan.FlatRange{Filename: "/src/project/main.tf", StartLine: 1, EndLine: 2},
+		},
+		{
+			RuleID:       "CUS001",
+			LongID:       "custom-custom-cus001",
+			RuleSummary:  "Buckets must be tagged",
+			RuleProvider: providers.CustomProvider,
+			RuleService:  "custom",
+			Links:        []string{"https://example.com/tagging"},
+			Description:  "Custom check failed for resource aws_s3_bucket.bkt.",
+			Severity:     severity.Low,
+			Status:       scan.StatusFailed,
+			Resource:     "aws_s3_bucket.bkt",
+			Location:     scan.FlatRange{Filename: "/src/project/s3.tf", StartLine: 3, EndLine: 5},
+		},
+	}
+
+	results := Rehydrate(flat, "/src", srcFS)
+	require.Len(t, results, 2)
+
+	registered := results[0]
+	assert.Equal(t, "aws-kms-auto-rotate-keys", registered.Rule().LongID())
+	assert.NotEmpty(t, registered.Rule().Explanation, "the registered rule should be used")
+	assert.Equal(t, severity.High, registered.Severity())
+	assert.Equal(t, scan.StatusIgnored, registered.Status())
+	assert.Equal(t, "project/main.tf", registered.Range().GetFilename())
+	assert.Equal(t, "aws_kms_key.key", registered.Metadata().Reference())
+	code, err := registered.GetCode()
+	require.NoError(t, err)
+	assert.Equal(t, `resource "aws_kms_key" "key" {`, code.Lines[0].Content)
+
+	custom := results[1]
+	assert.Equal(t, "custom-custom-cus001", custom.Rule().LongID())
+	assert.Equal(t, "Buckets must be tagged", custom.Rule().Summary)
+	assert.Equal(t, []string{"https://example.com/tagging"}, custom.Rule().Links)
+	assert.Equal(t, scan.StatusFailed, custom.Status())
+	// the file is no longer there, so there is no code for the result
+	_, err = custom.GetCode()
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
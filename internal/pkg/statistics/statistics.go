@@ -0,0 +1,171 @@
+package statistics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/defsec/pkg/severity"
+	defsecTypes "github.com/aquasecurity/defsec/pkg/types"
+)
+
+// RootModule is the module results of resources outside of any module are counted under
+const RootModule = "root"
+
+// Severities are the severities failures are counted by, most severe first
+var Severities = []severity.Severity{
+	severity.Critical,
+	severity.High,
+	severity.Medium,
+	severity.Low,
+}
+
+// Count is the number of failures of one rule, severity, provider, service, file or module
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// RuleCount is the number of failures of a rule
+type RuleCount struct {
+	ID          string   `json:"rule_id"`
+	Description string   `json:"rule_description"`
+	Severity    string   `json:"severity"`
+	Links       []string `json:"links"`
+	Count       int      `json:"count"`
+}
+
+// Report counts the failed results of a scan in different ways. Other than the severities, which are always listed
+// in order, the counts are ordered from the most failures to the fewest.
+type Report struct {
+	Total      int         `json:"total"`
+	Rules      []RuleCount `json:"rules"`
+	Severities []Count     `json:"severities"`
+	Providers  []Count     `json:"providers"`
+	Services   []Count     `json:"services"`
+	Files      []Count     `json:"files"`
+	Modules    []Count     `json:"modules"`
+}
+
+// Compute counts the failed results. Files are named by the path func, so that they match the other output of the
+// scan; services are named with their provider, such as aws/s3, as services of different providers can share names.
+func Compute(results scan.Results, path func(scan.Result) string) *Report {
+	rules := make(map[string]*RuleCount)
+	severities := make(map[string]int)
+	providers := make(map[string]int)
+	services := make(map[string]int)
+	files := make(map[string]int)
+	modules := make(map[string]int)
+
+	report := &Report{}
+	for _, result := range results.GetFailed() {
+		rule := result.Rule()
+		report.Total++
+
+		id := rule.LongID()
+		count, ok := rules[id]
+		if !ok {
+			count = &RuleCount{
+				ID:          id,
+				Description: rule.Summary,
+				Severity:    string(result.Severity()),
+				Links:       rule.Links,
+			}
+			rules[id] = count
+		}
+		count.Count++
+
+		severities[string(result.Severity())]++
+		providers[string(rule.Provider)]++
+		services[string(rule.Provider)+"/"+rule.Service]++
+		files[path(result)]++
+		modules[ModuleAddress(result.Metadata())]++
+	}
+
+	report.Rules = []RuleCount{}
+	for _, count := range rules {
+		report.Rules = append(report.Rules, *count)
+	}
+	sort.Slice(report.Rules, func(i, j int) bool {
+		if report.Rules[i].Count != report.Rules[j].Count {
+			return report.Rules[i].Count > report.Rules[j].Count
+		}
+		return report.Rules[i].ID < report.Rules[j].ID
+	})
+
+	report.Severities = []Count{}
+	for _, sev := range Severities {
+		report.Severities = append(report.Severities, Count{Name: string(sev), Count: severities[string(sev)]})
+	}
+	report.Providers = sortedCounts(providers)
+	report.Services = sortedCounts(services)
+	report.Files = sortedCounts(files)
+	report.Modules = sortedCounts(modules)
+	return report
+}
+
+// ModuleAddress is the address of the module a resource or block is in, such as module.network.module.subnets
+func ModuleAddress(metadata defsecTypes.Metadata) string {
+	var calls []string
+	for parent := metadata.Parent(); parent != nil; parent = parent.Parent() {
+		if reference := parent.Reference(); strings.HasPrefix(reference, "module.") {
+			calls = append([]string{reference}, calls...)
+		}
+	}
+	if len(calls) == 0 {
+		return RootModule
+	}
+	return strings.Join(calls, ".")
+}
+
+func sortedCounts(counts map[string]int) []Count {
+	sorted := []Count{}
+	for name, count := range counts {
+		sorted = append(sorted, Count{Name: name, Count: count})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// WriteJSON writes the report as JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(r)
+}
+
+// WriteCSV writes every count of the report as a row of a single table, with the kind of count in its first column
+func (r *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	rows := [][]string{{"category", "name", "count"}}
+	for _, rule := range r.Rules {
+		rows = append(rows, []string{"rule", rule.ID, strconv.Itoa(rule.Count)})
+	}
+	for _, section := range []struct {
+		category string
+		counts   []Count
+	}{
+		{"severity", r.Severities},
+		{"provider", r.Providers},
+		{"service", r.Services},
+		{"file", r.Files},
+		{"module", r.Modules},
+	} {
+		for _, count := range section.counts {
+			rows = append(rows, []string{section.category, count.Name, strconv.Itoa(count.Count)})
+		}
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
@@ -0,0 +1,137 @@
+package statistics
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+
+	"github.com/aquasecurity/defsec/pkg/scan"
+	scanner "github.com/aquasecurity/defsec/pkg/scanners/terraform"
+	"github.com/liamg/memoryfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scanModules(t *testing.T) scan.Results {
+	f := memoryfs.New()
+	require.NoError(t, f.MkdirAll("modules/a", 0o700))
+	require.NoError(t, f.MkdirAll("modules/b", 0o700))
+	require.NoError(t, f.WriteFile("main.tf", []byte(`
+module "outer" {
+  source = "./modules/a"
+}
+
+resource "aws_s3_bucket" "root" {}
+`), 0o600))
+	require.NoError(t, f.WriteFile("modules/a/main.tf", []byte(`
+module "inner" {
+  source = "../b"
+}
+
+resource "aws_security_group_rule" "a" {
+  type        = "ingress"
+  description = "open"
+  cidr_blocks = ["0.0.0.0/0"]
+}
+`), 0o600))
+	require.NoError(t, f.WriteFile("modules/b/main.tf", []byte(`
+resource "aws_s3_bucket" "b" {}
+`), 0o600))
+
+	results, err := scanner.New().ScanFS(context.TODO(), f, ".")
+	require.NoError(t, err)
+	require.NotEmpty(t, results.GetFailed())
+	return results
+}
+
+func countOf(counts []Count, name string) int {
+	for _, count := range counts {
+		if count.Name == name {
+			return count.Count
+		}
+	}
+	return 0
+}
+
+func Test_Compute(t *testing.T) {
+	results := scanModules(t)
+	report := Compute(results, func(result scan.Result) string {
+		return result.Range().GetFilename()
+	})
+
+	failed := len(results.GetFailed())
+	assert.Equal(t, failed, report.Total)
+
+	var byRule, bySeverity, byFile, byModule int
+	for _, rule := range report.Rules {
+		byRule += rule.Count
+	}
+	for _, count := range report.Severities {
+		bySeverity += count.Count
+	}
+	for _, count := range report.Files {
+		byFile += count.Count
+	}
+	for _, count := range report.Modules {
+		byModule += count.Count
+	}
+	assert.Equal(t, failed, byRule)
+	assert.Equal(t, failed, bySeverity)
+	assert.Equal(t, failed, byFile)
+	assert.Equal(t, failed, byModule)
+
+	require.Len(t, report.Severities, 4)
+	assert.Equal(t, "CRITICAL", report.Severities[0].Name)
+	assert.Equal(t, failed, countOf(report.Providers, "aws"))
+	assert.Greater(t, countOf(report.Services, "aws/s3"), 0)
+	assert.Greater(t, countOf(report.Services, "aws/ec2"), 0)
+
+	assert.Greater(t, countOf(report.Modules, RootModule), 0)
+	assert.Greater(t, countOf(report.Modules, "module.outer"), 0)
+	assert.Greater(t, countOf(report.Modules, "module.outer.module.inner"), 0)
+	assert.Equal(t, countOf(report.Modules, RootModule), countOf(report.Files, "main.tf"))
+	assert.Equal(t, countOf(report.Modules, "module.outer.module.inner"), countOf(report.Files, "modules/b/main.tf"))
+
+	for i := 1; i < len(report.Rules); i++ {
+		assert.GreaterOrEqual(t, report.Rules[i-1].Count, report.Rules[i].Count)
+	}
+}
+
+func Test_ComputeNoFailures(t *testing.T) {
+	report := Compute(nil, nil)
+	assert.Equal(t, 0, report.Total)
+	assert.Empty(t, report.Rules)
+	assert.Len(t, report.Severities, 4)
+
+	var buffer bytes.Buffer
+	require.NoError(t, report.WriteJSON(&buffer))
+	assert.Contains(t, buffer.String(), `"rules": []`)
+}
+
+func Test_Export(t *testing.T) {
+	report := Compute(scanModules(t), func(result scan.Result) string {
+		return result.Range().GetFilename()
+	})
+
+	var jsonExport bytes.Buffer
+	require.NoError(t, report.WriteJSON(&jsonExport))
+	var decoded Report
+	require.NoError(t, json.Unmarshal(jsonExport.Bytes(), &decoded))
+	assert.Equal(t, *report, decoded)
+
+	var csvExport bytes.Buffer
+	require.NoError(t, report.WriteCSV(&csvExport))
+	rows, err := csv.NewReader(&csvExport).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"category", "name", "count"}, rows[0])
+	categories := make(map[string]int)
+	for _, row := range rows[1:] {
+		categories[row[0]]++
+	}
+	assert.Equal(t, len(report.Rules), categories["rule"])
+	assert.Equal(t, 4, categories["severity"])
+	assert.Equal(t, len(report.Modules), categories["module"])
+	assert.Contains(t, rows, []string{"module", "module.outer", "1"})
+}
@@ -0,0 +1,113 @@
+package test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type comparisonOutput struct {
+	New []struct {
+		Result struct {
+			LongID string `json:"long_id"`
+		} `json:"result"`
+	} `json:"new"`
+	Fixed      []json.RawMessage `json:"fixed"`
+	Unchanged  []json.RawMessage `json:"unchanged"`
+	Severities []struct {
+		Severity string `json:"severity"`
+		Delta    int    `json:"delta"`
+	} `json:"severities"`
+}
+
+// writeComparisonReports scans a module before and after adding a KMS key without rotation, and moving the
+// existing bucket down by a few lines
+func writeComparisonReports(t *testing.T) (string, string) {
+	dir := t.TempDir()
+	module := filepath.Join(dir, "module")
+	require.NoError(t, os.Mkdir(module, 0o700))
+	bucket := `resource "aws_s3_bucket" "bkt" {
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(module, "main.tf"), []byte(bucket), 0o600))
+	before, _, _ := runWithArgs(module, "-f", "json", "--no-module-downloads")
+
+	require.NoError(t, os.WriteFile(filepath.Join(module, "main.tf"), []byte(`variable "region" {}
+
+resource "aws_kms_key" "key" {
+}
+
+`+bucket), 0o600))
+	after, _, _ := runWithArgs(module, "-f", "json", "--no-module-downloads")
+
+	oldReport, newReport := filepath.Join(dir, "old.json"), filepath.Join(dir, "new.json")
+	require.NoError(t, os.WriteFile(oldReport, []byte(before), 0o600))
+	require.NoError(t, os.WriteFile(newReport, []byte(after), 0o600))
+	return oldReport, newReport
+}
+
+func Test_Compare_JSON(t *testing.T) {
+	oldReport, newReport := writeComparisonReports(t)
+
+	out, stderr, exit := runWithArgs("compare", oldReport, newReport, "-f", "json")
+	assert.Equal(t, 1, exit)
+	assert.Contains(t, stderr, "1 new finding(s) at or above LOW")
+
+	var comparison comparisonOutput
+	require.NoError(t, json.Unmarshal([]byte(out), &comparison))
+	require.Len(t, comparison.New, 1)
+	assert.Equal(t, "aws-kms-auto-rotate-keys", comparison.New[0].Result.LongID)
+	assert.Empty(t, comparison.Fixed)
+	assert.Len(t, comparison.Unchanged, len(parseJSON(t, mustRead(t, oldReport))))
+	for _, delta := range comparison.Severities {
+		if delta.Severity == "MEDIUM" {
+			assert.Equal(t, 1, delta.Delta)
+		} else {
+			assert.Equal(t, 0, delta.Delta)
+		}
+	}
+}
+
+func Test_Compare_Fixed(t *testing.T) {
+	oldReport, newReport := writeComparisonReports(t)
+
+	out, _, exit := runWithArgs("compare", newReport, oldReport, "--no-colour")
+	assert.Equal(t, 0, exit)
+	assert.Contains(t, out, "fixed (1)")
+	assert.Contains(t, out, "aws-kms-auto-rotate-keys")
+	assert.Contains(t, out, "0 new, 1 fixed")
+}
+
+func Test_Compare_Threshold(t *testing.T) {
+	oldReport, newReport := writeComparisonReports(t)
+
+	_, _, exit := runWithArgs("compare", oldReport, newReport, "--threshold", "HIGH")
+	assert.Equal(t, 0, exit)
+
+	_, _, exit = runWithArgs("compare", oldReport, newReport, "--max-new", "1")
+	assert.Equal(t, 0, exit)
+
+	out, _, exit := runWithArgs("compare", oldReport, newReport, "--threshold", "medium", "-f", "markdown")
+	assert.Equal(t, 1, exit)
+	assert.Contains(t, out, "| *MEDIUM* | 2 | 3 | +1 |")
+	assert.Contains(t, out, "## New: 1 finding(s)")
+}
+
+func Test_Compare_InvalidReport(t *testing.T) {
+	report := filepath.Join(t.TempDir(), "report.json")
+	require.NoError(t, os.WriteFile(report, []byte(`{"runs": []}`), 0o600))
+
+	_, stderr, exit := runWithArgs("compare", report, report)
+	assert.Equal(t, 1, exit)
+	assert.Contains(t, stderr, "is not a tfsec JSON report")
+}
+
+func mustRead(t *testing.T, path string) string {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
@@ -0,0 +1,131 @@
+package test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type complianceJSON struct {
+	Results []struct {
+		LongID string `json:"long_id"`
+	} `json:"results"`
+	Compliance struct {
+		Framework    string `json:"framework"`
+		Passed       int    `json:"passed"`
+		Failed       int    `json:"failed"`
+		NotEvaluated int    `json:"not_evaluated"`
+		Controls     []struct {
+			ID     string `json:"id"`
+			Status string `json:"status"`
+			Checks []struct {
+				ID     string `json:"id"`
+				Failed int    `json:"failed"`
+			} `json:"checks"`
+		} `json:"controls"`
+	} `json:"compliance"`
+}
+
+func Test_Flag_Compliance(t *testing.T) {
+	out, stderr, exit := runWithArgs("./testdata/fail", "--compliance", "cis-aws-1.4", "--no-colour")
+	assert.Equal(t, 1, exit, stderr)
+	assert.Contains(t, out, "compliance")
+	assert.Contains(t, out, "CIS Amazon Web Services Foundations Benchmark v1.4.0 (cis-aws-1.4)")
+	assert.Contains(t, out, "0 passed, 2 failed")
+	assert.Regexp(t, `FAILED +2\.1\.1 +Ensure all S3 buckets employ encryption-at-rest`, out)
+}
+
+func Test_Flag_ComplianceJSON(t *testing.T) {
+	out, stderr, exit := runWithArgs("./testdata/fail", "--compliance", "cis-aws-1.4", "-f", "json")
+	assert.Equal(t, 1, exit, stderr)
+
+	var report complianceJSON
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+	assert.Equal(t, "cis-aws-1.4", report.Compliance.Framework)
+	assert.Greater(t, report.Compliance.Failed, 0)
+	assert.Equal(t, len(report.Compliance.Controls), report.Compliance.Passed+report.Compliance.Failed+report.Compliance.NotEvaluated)
+	require.NotEmpty(t, report.Results)
+
+	checks := make(map[string]bool)
+	for _, control := range report.Compliance.Controls {
+		for _, check := range control.Checks {
+			checks[check.ID] = true
+		}
+	}
+	for _, result := range report.Results {
+		assert.True(t, checks[result.LongID], "%s is not a check of the framework", result.LongID)
+	}
+}
+
+func Test_Flag_ComplianceHTML(t *testing.T) {
+	out, _, exit := runWithArgs("./testdata/fail", "--compliance", "cis-aws-1.4", "-f", "html")
+	assert.Equal(t, 1, exit)
+	assert.Contains(t, out, `id="compliance"`)
+	assert.Contains(t, out, "cis-aws-1.4")
+}
+
+func Test_Flag_ComplianceUnknown(t *testing.T) {
+	_, stderr, exit := runWithArgs("./testdata/fail", "--compliance", "cis-aws-9.9")
+	assert.Equal(t, 1, exit)
+	assert.Contains(t, stderr, "unknown compliance framework 'cis-aws-9.9' - should be one of")
+	assert.Contains(t, stderr, "cis-aws-1.4")
+}
+
+func Test_Flag_ComplianceFromConfigAndCustomChecks(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`resource "compliance_special" "fails" {
+  ok = false
+}
+
+resource "aws_s3_bucket" "bkt" {
+}
+`), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".tfsec"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".tfsec", "config.yml"), []byte(`
+frameworks:
+  - id: internal-standard
+    name: Internal Standard
+    controls:
+      - id: "1"
+        title: Buckets are encrypted
+        checks:
+          - aws-s3-enable-bucket-encryption
+      - id: "2"
+        title: Special resources are ok
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".tfsec", "compliance_tfchecks.yaml"), []byte(`
+checks:
+  - code: CMP001
+    description: Special resources must be ok
+    requiredTypes:
+      - resource
+    requiredLabels:
+      - compliance_special
+    severity: HIGH
+    matchSpec:
+      name: ok
+      action: equals
+      value: true
+    errorMessage: Not ok
+    frameworks:
+      internal-standard:
+        - "2"
+`), 0o600))
+
+	out, stderr, exit := runWithArgs(dir, "--compliance", "internal-standard", "-f", "json")
+	assert.Equal(t, 1, exit, stderr)
+
+	var report complianceJSON
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+	require.Len(t, report.Compliance.Controls, 2)
+	assert.Equal(t, 2, report.Compliance.Failed)
+	assert.Equal(t, "custom-custom-cmp001", report.Compliance.Controls[1].Checks[0].ID)
+
+	out, _, exit = runWithArgs(dir, "--no-colour")
+	assert.Equal(t, 1, exit)
+	assert.NotContains(t, out, "Internal Standard")
+}
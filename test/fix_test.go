@@ -0,0 +1,107 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const unfixedKey = `# the key for the app
+resource "aws_kms_key" "key" {
+  description = "app key" # keep this comment
+  enable_key_rotation = false
+}
+
+variable "untouched"   {
+}
+`
+
+func Test_Flag_Fix(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.tf")
+	require.NoError(t, os.WriteFile(path, []byte(unfixedKey), 0o600))
+
+	out, stderr, exit := runWithArgs(dir, "--fix", "--no-colour", "--no-module-downloads", "-f", "json")
+	assert.Equal(t, 0, exit, stderr)
+	assert.Contains(t, stderr, "Fixed 1 of 1 failed result(s)")
+	assert.Contains(t, stderr, "set enable_key_rotation = true")
+	assert.Contains(t, stderr, "All 1 fixed result(s) are resolved.")
+	assertResultsNotContain(t, parseJSON(t, out), "aws-kms-auto-rotate-keys")
+
+	fixed, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `# the key for the app
+resource "aws_kms_key" "key" {
+  description         = "app key" # keep this comment
+  enable_key_rotation = true
+}
+
+variable "untouched"   {
+}
+`, string(fixed))
+}
+
+func Test_Flag_FixDryRun(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.tf")
+	require.NoError(t, os.WriteFile(path, []byte(unfixedKey), 0o600))
+
+	out, stderr, exit := runWithArgs(dir, "--fix", "--dry-run", "--no-colour", "--no-module-downloads", "-f", "json")
+	assert.Equal(t, 1, exit)
+	assert.Contains(t, stderr, "Would fix 1 of 1 failed result(s)")
+	assertResultsContain(t, parseJSON(t, out), "aws-kms-auto-rotate-keys")
+
+	unchanged, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, unfixedKey, string(unchanged))
+}
+
+func Test_Flag_FixCustomCheck(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`resource "special" "thing" {
+  ok = false
+}
+`), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".tfsec"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".tfsec", "fix_tfchecks.json"), []byte(`{
+  "checks": [
+    {
+      "code": "FIX001",
+      "description": "Special resources must be ok",
+      "requiredTypes": ["resource"],
+      "requiredLabels": ["special"],
+      "severity": "HIGH",
+      "matchSpec": {"name": "ok", "action": "equals", "value": true},
+      "errorMessage": "Not ok",
+      "fix": [
+        {"attribute": "ok", "value": true},
+        {"attribute": "settings.level", "value": 3}
+      ]
+    }
+  ]
+}
+`), 0o600))
+
+	_, stderr, exit := runWithArgs(dir, "--fix", "--no-colour", "--no-module-downloads")
+	assert.Equal(t, 0, exit, stderr)
+	assert.Contains(t, stderr, "All 1 fixed result(s) are resolved.")
+
+	fixed, err := os.ReadFile(filepath.Join(dir, "main.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, `resource "special" "thing" {
+  ok = true
+  settings {
+    level = 3
+  }
+}
+`, string(fixed))
+}
+
+func Test_Flag_DryRunRequiresFix(t *testing.T) {
+	_, stderr, exit := runWithArgs("./testdata/pass", "--dry-run")
+	assert.Equal(t, 1, exit)
+	assert.Contains(t, stderr, "--dry-run can only be used with --fix")
+}
@@ -8,6 +8,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/defsec/pkg/severity"
 	"github.com/aquasecurity/tfsec/version"
 	"github.com/stretchr/testify/require"
 
@@ -322,6 +324,27 @@ func Test_Flag_CustomCheckDir(t *testing.T) {
 	assert.Equal(t, 1, exit)
 }
 
+func Test_Flag_CustomCheckDirMultiple(t *testing.T) {
+	out, err, exit := runWithArgs("./testdata/custom-sources", "-f", "json",
+		"--custom-check-dir", "./testdata/custom-sources/org",
+		"--custom-check-dir", "./testdata/custom-sources/team",
+	)
+	assert.Equal(t, "", err)
+	results := parseJSON(t, out)
+	assertResultsContain(t, results, "custom-custom-org001")
+	assertResultsContain(t, results, "custom-custom-team001")
+	assert.Equal(t, 1, exit)
+}
+
+func Test_Flag_CustomCheckDirConflict(t *testing.T) {
+	_, err, exit := runWithArgs("./testdata/custom-sources",
+		"--custom-check-dir", "./testdata/custom-sources/org",
+		"--custom-check-dir", "./testdata/custom-sources/conflict",
+	)
+	assert.Contains(t, err, "custom check 'ORG001' in testdata/custom-sources/conflict/conflict_tfchecks.yaml conflicts with the check of the same code in testdata/custom-sources/org/org_tfchecks.yaml")
+	assert.Equal(t, 1, exit)
+}
+
 func Test_Flag_ConfigFile(t *testing.T) {
 	out, err, exit := runWithArgs("./testdata/config", "--config-file", "./testdata/config/config.yml")
 	results := parseLovely(t, out)
@@ -330,6 +353,111 @@ func Test_Flag_ConfigFile(t *testing.T) {
 	assert.Equal(t, 1, exit)
 }
 
+func Test_Flag_ConfigInheritance(t *testing.T) {
+	out, err, exit := runWithArgs("./testdata/config-inheritance/team")
+	results := parseLovely(t, out)
+	assertResultsNotContain(t, results, "aws-s3-enable-versioning")
+	assertResultsNotContain(t, results, "aws-s3-enable-bucket-logging")
+	assertResultsContain(t, results, "aws-s3-block-public-acls")
+	assert.Equal(t, "", err)
+	assert.Equal(t, 1, exit)
+}
+
+func Test_Flag_ConfigInheritanceScopedToSubdirectory(t *testing.T) {
+	out, err, exit := runWithArgs("./testdata/config-inheritance")
+	results := parseLovely(t, out)
+	assertResultsNotContain(t, results, "aws-s3-enable-versioning")
+	assertResultsNotContain(t, results, "aws-s3-enable-bucket-logging")
+	assert.Equal(t, "", err)
+	assert.Equal(t, 1, exit)
+}
+
+func Test_Flag_ConfigInheritanceLowersMinimumSeverityForSubdirectory(t *testing.T) {
+	out, err, exit := runWithArgs("./testdata/config-scoped-minimum-severity/team")
+	direct := parseLovely(t, out)
+	assertResultsContain(t, direct, "aws-s3-specify-public-access-block")
+	assert.Equal(t, "", err)
+	assert.Equal(t, 1, exit)
+
+	out, err, exit = runWithArgs("./testdata/config-scoped-minimum-severity")
+	fromRoot := parseLovely(t, out)
+	assertResultsContain(t, fromRoot, "aws-s3-specify-public-access-block")
+	assert.Len(t, fromRoot, len(direct))
+	assert.Equal(t, "", err)
+	assert.Equal(t, 1, exit)
+}
+
+func Test_Flag_NoConfigInheritance(t *testing.T) {
+	out, err, exit := runWithArgs("./testdata/config-inheritance/team", "--no-config-inheritance")
+	results := parseLovely(t, out)
+	assertResultsContain(t, results, "aws-s3-enable-versioning")
+	assertResultsNotContain(t, results, "aws-s3-enable-bucket-logging")
+	assert.Equal(t, "", err)
+	assert.Equal(t, 1, exit)
+}
+
+func Test_ConfigPathOverrides(t *testing.T) {
+	out, err, exit := runWithArgs("./testdata/config-path-overrides", "-f", "json")
+	assert.Equal(t, "", err)
+	assert.Equal(t, 1, exit)
+
+	byFile := make(map[string][]scan.FlatResult)
+	for _, result := range parseJSON(t, out) {
+		dir := filepath.Base(filepath.Dir(result.Location.Filename))
+		byFile[dir] = append(byFile[dir], result)
+	}
+	assertResultsContain(t, byFile["prod"], "aws-s3-enable-versioning")
+	assertResultsNotContain(t, byFile["sandbox"], "aws-s3-enable-versioning")
+
+	var found bool
+	for _, result := range byFile["sandbox"] {
+		if result.LongID == "aws-s3-enable-bucket-logging" {
+			found = true
+			assert.Equal(t, severity.Low, result.Severity)
+			assert.Contains(t, result.Description, "(override for 'sandbox/**' in .tfsec/config.yml)")
+		}
+	}
+	assert.True(t, found)
+}
+
+func Test_ConfigShow(t *testing.T) {
+	out, err, exit := runWithArgs("config", "show", "./testdata/config-inheritance/team")
+	assert.Equal(t, "", err)
+	assert.Contains(t, out, "- aws-s3-enable-versioning\n- aws-s3-enable-bucket-logging\n")
+	assert.Contains(t, out, fmt.Sprintf("exclude.aws-s3-enable-versioning: %s\n", mustAbs(t, "./testdata/config-inheritance/.tfsec/config.yml")))
+	assert.Contains(t, out, fmt.Sprintf("exclude.aws-s3-enable-bucket-logging: %s\n", mustAbs(t, "./testdata/config-inheritance/team/.tfsec/config.yml")))
+	assert.Equal(t, 0, exit)
+}
+
+func Test_ConfigFile_SetsFlags(t *testing.T) {
+	out, err, exit := runWithArgs("./testdata/config-options")
+	assert.Equal(t, "", err)
+	results := parseJSON(t, out)
+	var passed int
+	for _, result := range results {
+		if result.Status == scan.StatusPassed {
+			passed++
+		}
+	}
+	assert.Greater(t, passed, 0, "passed results should be included")
+	assert.Equal(t, 1, exit)
+}
+
+func Test_ConfigFile_FlagTakesPrecedence(t *testing.T) {
+	out, err, exit := runWithArgs("./testdata/config-options", "-f", "csv")
+	assert.Equal(t, "", err)
+	assert.Greater(t, len(parseCSV(t, out)), 0)
+	assert.Equal(t, 1, exit)
+}
+
+func Test_ConfigFile_EnvTakesPrecedence(t *testing.T) {
+	t.Setenv("TFSEC_FORMAT", "csv")
+	out, err, exit := runWithArgs("./testdata/config-options")
+	assert.Equal(t, "", err)
+	assert.Greater(t, len(parseCSV(t, out)), 0)
+	assert.Equal(t, 1, exit)
+}
+
 func Test_Flag_Debug(t *testing.T) {
 	// use json to ensure all debug goes to stderr and does not break json format
 	for _, flag := range []string{"--debug", "--verbose"} {
@@ -393,8 +521,10 @@ func Test_Flag_ForceAllDirs(t *testing.T) {
 }
 
 func Test_Flag_RunStatistics(t *testing.T) {
-	_, err, exit := runWithArgs("./testdata/pass", "--run-statistics")
-	assert.Contains(t, err, "\n+--------")
+	out, err, exit := runWithArgs("./testdata/pass", "--run-statistics", "--no-colour")
+	assert.Equal(t, "", err)
+	assert.Regexp(t, `failures +0`, out)
+	assert.Contains(t, out, "No problems detected!")
 	assert.Equal(t, 0, exit)
 }
 
@@ -453,6 +583,45 @@ func Test_Flag_PrintRegoInput(t *testing.T) {
 	assert.Equal(t, 0, exit)
 }
 
+func Test_Flag_RegoInputOut(t *testing.T) {
+	tmp, err := os.MkdirTemp(os.TempDir(), "tfsec")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(tmp) }()
+	file := filepath.Join(tmp, "input.json")
+
+	out, stderr, exit := runWithArgs("./testdata/fail", "--rego-input-out", file, "--rego-input-filter", "aws.s3")
+	assert.Equal(t, "", stderr)
+	assert.Greater(t, len(parseLovely(t, out)), 0, "results should still be output when writing rego input")
+	assert.Equal(t, 1, exit)
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "\n  ")
+
+	var raw map[string]map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &raw))
+	require.Contains(t, raw, "aws")
+	assert.Len(t, raw, 1)
+	assert.Contains(t, raw["aws"], "s3")
+	assert.Len(t, raw["aws"], 1)
+}
+
+func Test_Flag_RegoEval(t *testing.T) {
+	out, stderr, exit := runWithArgs("./testdata/fail", "--rego-eval", "count(input.aws.s3.buckets) > 0")
+	assert.Equal(t, "", stderr)
+
+	var raw []interface{}
+	require.NoError(t, json.Unmarshal([]byte(out), &raw))
+	assert.Equal(t, []interface{}{true}, raw)
+	assert.Equal(t, 0, exit)
+}
+
+func Test_Flag_RegoEvalInvalidQuery(t *testing.T) {
+	_, stderr, exit := runWithArgs("./testdata/fail", "--rego-eval", "input.aws[")
+	assert.Contains(t, stderr, "invalid rego query")
+	assert.Equal(t, 1, exit)
+}
+
 func Test_Flag_NoModuleDownloads(t *testing.T) {
 	_ = os.RemoveAll("./.tfsec")
 	out, err, exit := runWithArgs("./testdata/external-module", "--no-module-downloads", "--include-ignored")
@@ -483,21 +652,15 @@ func Test_Flag_ConfigFileUrl(t *testing.T) {
 
 func Test_Flag_ConfigFileUrlNotFound(t *testing.T) {
 	configFileUrl := "https://raw.githubusercontent.com/aquasecurity/tfsec/master/_examples/with_config_overrides/.tfsec/config_not_found.yml"
-	out, err, exit := runWithArgs("./testdata/with_config_overrides", "--config-file-url", configFileUrl)
-	assert.Equal(t, "", err)
-	result := parseLovely(t, out)
-	assertResultsContain(t, result, "aws-s3-specify-public-access-block")
-	assert.Len(t, result, 9)
+	_, err, exit := runWithArgs("./testdata/with_config_overrides", "--config-file-url", configFileUrl)
+	assert.Contains(t, err, "failed to download config file")
 	assert.Equal(t, 1, exit)
 }
 
 func Test_Flag_CustomCheckUrlNotFound(t *testing.T) {
 	customCheckUrl := "https://raw.githubusercontent.com/aquasecurity/tfsec/master/_examples/custom/.tfsec/custom_tfchecks_not_found.yaml"
-	out, err, exit := runWithArgs("./testdata/custom_url", "--custom-check-url", customCheckUrl)
-	assert.Equal(t, "", err)
-	result := parseLovely(t, out)
-	assertResultsContain(t, result, "aws-s3-specify-public-access-block")
-	assert.Len(t, result, 43)
+	_, err, exit := runWithArgs("./testdata/custom_url", "--custom-check-url", customCheckUrl)
+	assert.Contains(t, err, "failed to download custom checks")
 	assert.Equal(t, 1, exit)
 }
 
@@ -510,3 +673,94 @@ func Test_Flag_CustomCheckUrl(t *testing.T) {
 	assert.Len(t, result, 55)
 	assert.Equal(t, 1, exit)
 }
+
+func Test_Flag_PolicyBundle(t *testing.T) {
+	policy, err := os.ReadFile("./testdata/rego/policies/rego.rego")
+	require.NoError(t, err)
+	archive, digest := writeBundle(t, map[string]string{
+		"config.yml":         "exclude:\n  - aws-s3-enable-versioning\n",
+		"policies/rego.rego": string(policy),
+	})
+
+	out, stderr, exit := runWithArgs("./testdata/fail", "--policy-bundle", archive, "--policy-bundle-sha256", digest)
+	assert.Equal(t, "", stderr)
+	results := parseLovely(t, out)
+	assertResultsContain(t, results, "custom.rego.rego.sauce")
+	assertResultsContain(t, results, "aws-s3-enable-bucket-logging")
+	assertResultsNotContain(t, results, "aws-s3-enable-versioning")
+	assert.Equal(t, 1, exit)
+}
+
+func Test_Flag_PolicyBundleChecksumMismatch(t *testing.T) {
+	archive, _ := writeBundle(t, map[string]string{"config.yml": "exclude: []\n"})
+
+	_, stderr, exit := runWithArgs("./testdata/fail", "--policy-bundle", archive, "--policy-bundle-sha256", strings.Repeat("0", 64))
+	assert.Contains(t, stderr, "checksum mismatch")
+	assert.Equal(t, 1, exit)
+}
+
+func Test_ConfigFile_InvalidIsFatal(t *testing.T) {
+	_, err, exit := runWithArgs("./testdata/config-invalid")
+	assert.Contains(t, err, "invalid config")
+	assert.Contains(t, err, "'SEVERE' is not a valid severity")
+	assert.Equal(t, 1, exit)
+}
+
+func Test_Flag_IgnoreConfigErrors(t *testing.T) {
+	out, err, exit := runWithArgs("./testdata/config-invalid", "--ignore-config-errors", "-f", "json")
+	assert.Contains(t, err, "WARNING: Ignoring invalid config")
+	assertResultsContain(t, parseJSON(t, out), "aws-s3-enable-versioning")
+	assert.Equal(t, 1, exit)
+}
+
+func Test_ConfigFile_UnknownRuleFails(t *testing.T) {
+	out, err, exit := runWithArgs("./testdata/config-unknown-rule", "-f", "json")
+	assert.Equal(t, "", out)
+	assert.Contains(t, err, "invalid config: exclude: unknown rule 'aws-s3-enable-versoning' - did you mean 'aws-s3-enable-versioning'?")
+	assert.Equal(t, 1, exit)
+}
+
+func Test_ConfigFile_UnknownRuleIgnored(t *testing.T) {
+	out, err, exit := runWithArgs("./testdata/config-unknown-rule", "--ignore-config-errors", "-f", "json")
+	assert.Contains(t, err, "WARNING: Ignoring invalid config: exclude: unknown rule 'aws-s3-enable-versoning'")
+	assertResultsContain(t, parseJSON(t, out), "aws-s3-enable-versioning")
+	assert.Equal(t, 1, exit)
+}
+
+func Test_ConfigValidate(t *testing.T) {
+	out, err, exit := runWithArgs("config", "validate", "./testdata/config-inheritance/team")
+	assert.Equal(t, "", err)
+	assert.Contains(t, out, fmt.Sprintf("%s: OK\n", mustAbs(t, "./testdata/config-inheritance/team/.tfsec/config.yml")))
+	assert.Equal(t, 0, exit)
+}
+
+func Test_ConfigValidate_UnknownRule(t *testing.T) {
+	out, err, exit := runWithArgs("config", "validate", "./testdata/config-unknown-rule")
+	assert.Contains(t, out, "did you mean 'aws-s3-enable-versioning'?")
+	assert.Contains(t, err, "config validation failed")
+	assert.Equal(t, 1, exit)
+}
+
+func Test_SubcommandNameDirectory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "render"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "render", "main.tf"), []byte(`resource "aws_s3_bucket" "bkt" {}`), 0o600))
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() {
+		require.NoError(t, os.Chdir(workingDir))
+	})
+
+	// the subcommand runs, but warns that the directory is not scanned
+	_, stderr, exit := runWithArgs("render", "--input", filepath.Join(dir, "missing.json"))
+	assert.Contains(t, stderr, "WARNING: Running the render command rather than scanning the render directory - use 'tfsec ./render' or 'tfsec -- render' to scan it")
+	assert.Equal(t, 1, exit)
+
+	for _, args := range [][]string{{"-f", "json", "./render"}, {"-f", "json", "--", "render"}} {
+		out, stderr, exit := runWithArgs(args...)
+		assert.NotContains(t, stderr, "WARNING: Running the render command")
+		assertResultsContain(t, parseJSON(t, out), "aws-s3-enable-versioning")
+		assert.Equal(t, 1, exit)
+	}
+}
@@ -0,0 +1,132 @@
+package test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type codeQualityIssue struct {
+	CheckName   string `json:"check_name"`
+	Fingerprint string `json:"fingerprint"`
+	Severity    string `json:"severity"`
+	Location    struct {
+		Path  string `json:"path"`
+		Lines struct {
+			Begin int `json:"begin"`
+		} `json:"lines"`
+	} `json:"location"`
+}
+
+type sastReport struct {
+	Version string `json:"version"`
+	Scan    struct {
+		Type    string `json:"type"`
+		Scanner struct {
+			ID string `json:"id"`
+		} `json:"scanner"`
+	} `json:"scan"`
+	Vulnerabilities []struct {
+		ID          string `json:"id"`
+		Severity    string `json:"severity"`
+		Identifiers []struct {
+			Value string `json:"value"`
+		} `json:"identifiers"`
+		Location struct {
+			File      string `json:"file"`
+			StartLine int    `json:"start_line"`
+		} `json:"location"`
+	} `json:"vulnerabilities"`
+}
+
+func Test_Flag_Format_GitLabCodeQuality(t *testing.T) {
+	jsonOut, _, _ := runWithArgs("./testdata/fail", "-f", "json")
+	out, _, exit := runWithArgs("./testdata/fail", "-f", "gitlab-codequality")
+	assert.Equal(t, 1, exit)
+
+	var issues []codeQualityIssue
+	require.NoError(t, json.Unmarshal([]byte(out), &issues))
+	require.Len(t, issues, len(parseJSON(t, jsonOut)))
+	for _, issue := range issues {
+		assert.Equal(t, "main.tf", issue.Location.Path)
+		assert.Len(t, issue.Fingerprint, 64)
+		assert.Contains(t, []string{"blocker", "critical", "major", "minor", "info"}, issue.Severity)
+	}
+}
+
+func Test_Flag_Format_GitLabSAST(t *testing.T) {
+	out, _, exit := runWithArgs("./testdata/fail", "-f", "gitlab-sast")
+	assert.Equal(t, 1, exit)
+
+	var report sastReport
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+	assert.Equal(t, "sast", report.Scan.Type)
+	assert.Equal(t, "tfsec", report.Scan.Scanner.ID)
+	require.NotEmpty(t, report.Vulnerabilities)
+	var ids []string
+	for _, vulnerability := range report.Vulnerabilities {
+		assert.Equal(t, "main.tf", vulnerability.Location.File)
+		assert.Contains(t, []string{"Critical", "High", "Medium", "Low"}, vulnerability.Severity)
+		ids = append(ids, vulnerability.Identifiers[0].Value)
+	}
+	assert.Contains(t, ids, "aws-s3-enable-bucket-encryption")
+}
+
+func Test_Flag_Format_GitLabFingerprintsIgnoreLineChanges(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.tf")
+	resource := `resource "aws_kms_key" "key" {
+  enable_key_rotation = false
+}
+`
+	require.NoError(t, os.WriteFile(path, []byte(resource), 0o600))
+	before, _, _ := runWithArgs(dir, "-f", "gitlab-codequality", "--no-module-downloads")
+	require.NoError(t, os.WriteFile(path, []byte("variable \"region\" {}\n\n"+resource), 0o600))
+	after, _, _ := runWithArgs(dir, "-f", "gitlab-codequality", "--no-module-downloads")
+
+	var beforeIssues, afterIssues []codeQualityIssue
+	require.NoError(t, json.Unmarshal([]byte(before), &beforeIssues))
+	require.NoError(t, json.Unmarshal([]byte(after), &afterIssues))
+	require.Len(t, beforeIssues, 1)
+	require.Len(t, afterIssues, 1)
+	assert.NotEqual(t, beforeIssues[0].Location.Lines.Begin, afterIssues[0].Location.Lines.Begin)
+	assert.Equal(t, beforeIssues[0].Fingerprint, afterIssues[0].Fingerprint)
+}
+
+func Test_Flag_Format_GitLabMultipleFormats(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "tfsec")
+	_, stderr, _ := runWithArgs("./testdata/fail", "-f", "gitlab-codequality,gitlab-sast", "--out", base)
+	assert.Contains(t, stderr, "2 file(s) written")
+	assert.FileExists(t, base+".gl-code-quality-report.json")
+	assert.FileExists(t, base+".gl-sast-report.json")
+}
+
+func Test_Flag_Format_GitLabFingerprintsUniquePerInstance(t *testing.T) {
+	out, _, exit := runWithArgs("./testdata/instances", "-f", "gitlab-codequality")
+	assert.Equal(t, 1, exit)
+
+	var issues []codeQualityIssue
+	require.NoError(t, json.Unmarshal([]byte(out), &issues))
+	require.NotEmpty(t, issues)
+	fingerprints := make(map[string]int)
+	for _, issue := range issues {
+		fingerprints[issue.Fingerprint]++
+	}
+	assert.Len(t, fingerprints, len(issues), "each count and for_each instance should have its own fingerprint")
+
+	out, _, exit = runWithArgs("./testdata/instances", "-f", "gitlab-sast")
+	assert.Equal(t, 1, exit)
+
+	var report sastReport
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+	require.Len(t, report.Vulnerabilities, len(issues))
+	ids := make(map[string]int)
+	for _, vulnerability := range report.Vulnerabilities {
+		ids[vulnerability.ID]++
+	}
+	assert.Len(t, ids, len(report.Vulnerabilities), "each count and for_each instance should have its own id")
+}
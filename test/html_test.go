@@ -0,0 +1,46 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Flag_Format_HTML(t *testing.T) {
+	out, _, exit := runWithArgs("./testdata/fail", "-f", "html", "--include-passed")
+	assert.Equal(t, 1, exit)
+
+	assert.True(t, strings.HasPrefix(out, "<!DOCTYPE html>"))
+	assert.Contains(t, out, `data-id="aws-s3-enable-bucket-encryption"`)
+	assert.Contains(t, out, `<select data-filter="severity">`)
+	assert.Contains(t, out, `<select data-filter="file">`)
+	assert.Contains(t, out, "<option>main.tf</option>")
+	assert.Contains(t, out, "<option>passed</option>")
+	assert.Contains(t, out, "<dt>Resolution</dt>")
+	assert.Contains(t, out, `<pre class="chroma code">`)
+	assert.Contains(t, out, "<h2>Timings</h2>")
+	assert.Contains(t, strings.TrimSpace(out), "</html>")
+}
+
+func Test_Flag_Format_HTMLNoResults(t *testing.T) {
+	out, _, exit := runWithArgs("./testdata/pass", "-f", "html")
+	assert.Equal(t, 0, exit)
+	assert.Contains(t, out, "No problems detected!")
+	assert.True(t, strings.HasSuffix(strings.TrimSpace(out), "</html>"))
+}
+
+func Test_Flag_Format_HTMLEscapesContent(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`resource "aws_s3_bucket" "<img src=x onerror=alert(1)>" {
+}
+`), 0o600))
+
+	out, _, _ := runWithArgs(dir, "-f", "html", "--no-module-downloads")
+	assert.Contains(t, out, "aws-s3-enable-bucket-encryption")
+	assert.NotContains(t, out, "<img src=x")
+	assert.Contains(t, out, "&lt;img src=x onerror=alert(1)&gt;")
+}
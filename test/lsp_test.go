@@ -0,0 +1,274 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aquasecurity/defsec/pkg/rules"
+	"github.com/aquasecurity/tfsec/internal/app/tfsec/cmd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type lspClient struct {
+	t        *testing.T
+	in       io.Writer
+	messages chan map[string]interface{}
+	nextID   int
+}
+
+func startLanguageServer(t *testing.T) *lspClient {
+	ctx, cancel := context.WithCancel(context.Background())
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+
+	rootCmd := cmd.Root()
+	rootCmd.SetIn(stdinReader)
+	rootCmd.SetOut(stdoutWriter)
+	rootCmd.SetErr(io.Discard)
+	rootCmd.SetArgs([]string{"lsp", "--no-module-downloads"})
+
+	done := make(chan error)
+	go func() {
+		done <- rootCmd.ExecuteContext(ctx)
+		_ = stdoutWriter.Close()
+	}()
+
+	client := &lspClient{
+		t:        t,
+		in:       stdinWriter,
+		messages: make(chan map[string]interface{}, 100),
+	}
+	go func() {
+		reader := textproto.NewReader(bufio.NewReader(stdoutReader))
+		for {
+			header, err := reader.ReadMIMEHeader()
+			if err != nil {
+				close(client.messages)
+				return
+			}
+			length, _ := strconv.Atoi(header.Get("Content-Length"))
+			body := make([]byte, length)
+			if _, err := io.ReadFull(reader.R, body); err != nil {
+				close(client.messages)
+				return
+			}
+			var msg map[string]interface{}
+			if err := json.Unmarshal(body, &msg); err == nil {
+				client.messages <- msg
+			}
+		}
+	}()
+
+	t.Cleanup(func() {
+		_ = stdinWriter.Close()
+		cancel()
+		require.NoError(t, <-done)
+	})
+	return client
+}
+
+func (c *lspClient) send(method string, params interface{}, withID bool) int {
+	msg := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	}
+	if withID {
+		c.nextID++
+		msg["id"] = c.nextID
+	}
+	body, err := json.Marshal(msg)
+	require.NoError(c.t, err)
+	_, err = fmt.Fprintf(c.in, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	require.NoError(c.t, err)
+	return c.nextID
+}
+
+// await returns the first message for which match returns true, discarding any others
+func (c *lspClient) await(match func(msg map[string]interface{}) bool) map[string]interface{} {
+	timeout := time.After(30 * time.Second)
+	for {
+		select {
+		case msg, ok := <-c.messages:
+			require.True(c.t, ok, "language server exited")
+			if match(msg) {
+				return msg
+			}
+		case <-timeout:
+			c.t.Fatal("timed out waiting for message from language server")
+		}
+	}
+}
+
+func (c *lspClient) request(method string, params interface{}) interface{} {
+	id := c.send(method, params, true)
+	msg := c.await(func(msg map[string]interface{}) bool {
+		return msg["id"] == float64(id)
+	})
+	require.Nil(c.t, msg["error"])
+	return msg["result"]
+}
+
+func (c *lspClient) awaitDiagnostics(uri string) []interface{} {
+	msg := c.await(func(msg map[string]interface{}) bool {
+		if msg["method"] != "textDocument/publishDiagnostics" {
+			return false
+		}
+		return msg["params"].(map[string]interface{})["uri"] == uri
+	})
+	return msg["params"].(map[string]interface{})["diagnostics"].([]interface{})
+}
+
+func Test_LSP_DiagnosticsHoverAndCodeActions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.tf")
+	content := `
+resource "aws_s3_bucket" "bkt" {
+
+}
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	uri := "file://" + filepath.ToSlash(path)
+
+	client := startLanguageServer(t)
+
+	result := client.request("initialize", map[string]interface{}{"capabilities": map[string]interface{}{}})
+	capabilities := result.(map[string]interface{})["capabilities"].(map[string]interface{})
+	assert.Equal(t, true, capabilities["hoverProvider"])
+	client.send("initialized", map[string]interface{}{}, false)
+
+	client.send("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri":        uri,
+			"languageId": "terraform",
+			"version":    1,
+			"text":       content,
+		},
+	}, false)
+
+	diagnostics := client.awaitDiagnostics(uri)
+	require.NotEmpty(t, diagnostics)
+	var found bool
+	for _, d := range diagnostics {
+		diagnostic := d.(map[string]interface{})
+		if diagnostic["code"] == "aws-s3-enable-bucket-encryption" {
+			found = true
+			assert.Equal(t, "tfsec", diagnostic["source"])
+			assert.Equal(t, float64(1), diagnostic["range"].(map[string]interface{})["start"].(map[string]interface{})["line"])
+		}
+	}
+	require.True(t, found, "expected a diagnostic for aws-s3-enable-bucket-encryption")
+
+	position := map[string]interface{}{"line": 1, "character": 2}
+	hover := client.request("textDocument/hover", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri},
+		"position":     position,
+	})
+	require.NotNil(t, hover)
+	markdown := hover.(map[string]interface{})["contents"].(map[string]interface{})["value"].(string)
+	assert.Contains(t, markdown, "aws-s3-enable-bucket-encryption")
+	assert.Contains(t, markdown, "**Resolution:**")
+
+	actions := client.request("textDocument/codeAction", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri},
+		"range":        map[string]interface{}{"start": position, "end": position},
+		"context":      map[string]interface{}{"diagnostics": []interface{}{}},
+	}).([]interface{})
+	require.NotEmpty(t, actions)
+	var insertsIgnore bool
+	for _, a := range actions {
+		edits := a.(map[string]interface{})["edit"].(map[string]interface{})["changes"].(map[string]interface{})[uri].([]interface{})
+		if edits[0].(map[string]interface{})["newText"] == "# tfsec:ignore:aws-s3-enable-bucket-encryption\n" {
+			insertsIgnore = true
+		}
+	}
+	assert.True(t, insertsIgnore, "expected a code action which ignores aws-s3-enable-bucket-encryption")
+
+	// unsaved changes are scanned in place of the file on disk
+	client.send("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": 2},
+		"contentChanges": []interface{}{map[string]interface{}{"text": `variable "region" {}`}},
+	}, false)
+	assert.Empty(t, client.awaitDiagnostics(uri))
+
+	client.request("shutdown", nil)
+	client.send("exit", nil, false)
+}
+
+func Test_LSP_CustomChecksOnlyApplyToTheirModule(t *testing.T) {
+	dir := t.TempDir()
+	checked := filepath.Join(dir, "checked")
+	unchecked := filepath.Join(dir, "unchecked")
+	require.NoError(t, os.MkdirAll(filepath.Join(checked, ".tfsec"), 0o700))
+	require.NoError(t, os.MkdirAll(unchecked, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(checked, ".tfsec", "special_tfchecks.yaml"), []byte(`---
+checks:
+  - code: LSP001
+    description: Special resources must be ok
+    requiredTypes:
+      - resource
+    requiredLabels:
+      - special
+    severity: HIGH
+    matchSpec:
+      name: ok
+      action: equals
+      value: true
+    errorMessage: Not ok
+`), 0o600))
+
+	// the bucket has results in both modules, so diagnostics are always published
+	content := `
+resource "special" "thing" { ok = false }
+resource "aws_s3_bucket" "bkt" {}
+`
+	client := startLanguageServer(t)
+	client.request("initialize", map[string]interface{}{"capabilities": map[string]interface{}{}})
+	client.send("initialized", map[string]interface{}{}, false)
+
+	codes := func(diagnostics []interface{}) []string {
+		var codes []string
+		for _, d := range diagnostics {
+			codes = append(codes, d.(map[string]interface{})["code"].(string))
+		}
+		return codes
+	}
+	open := func(moduleDir string) string {
+		path := filepath.Join(moduleDir, "main.tf")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		uri := "file://" + filepath.ToSlash(path)
+		client.send("textDocument/didOpen", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri, "languageId": "terraform", "version": 1, "text": content},
+		}, false)
+		return uri
+	}
+
+	checkedURI := open(checked)
+	assert.Contains(t, codes(client.awaitDiagnostics(checkedURI)), "custom-custom-lsp001")
+	registered := len(rules.GetRegistered())
+
+	uncheckedURI := open(unchecked)
+	assert.NotContains(t, codes(client.awaitDiagnostics(uncheckedURI)), "custom-custom-lsp001")
+
+	for version := 2; version < 5; version++ {
+		client.send("textDocument/didChange", map[string]interface{}{
+			"textDocument":   map[string]interface{}{"uri": checkedURI, "version": version},
+			"contentChanges": []interface{}{map[string]interface{}{"text": content}},
+		}, false)
+		assert.Contains(t, codes(client.awaitDiagnostics(checkedURI)), "custom-custom-lsp001")
+	}
+	assert.Len(t, rules.GetRegistered(), registered, "rescans re-use the registered custom checks")
+
+	client.request("shutdown", nil)
+	client.send("exit", nil, false)
+}
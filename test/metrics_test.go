@@ -0,0 +1,78 @@
+package test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MetricsOut_JSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.json")
+	out, stderr, exit := runWithArgs("./testdata/group", "--metrics-out", path, "-f", "json")
+	assert.Equal(t, 1, exit, stderr)
+
+	var report struct {
+		Results []json.RawMessage `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+	assert.Len(t, report.Results, 18)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var exported struct {
+		Timings struct {
+			Total float64 `json:"total_seconds"`
+		} `json:"timings"`
+		Counts struct {
+			ModulesProcessed int `json:"modules_processed"`
+		} `json:"counts"`
+		Results struct {
+			High   int `json:"high"`
+			Medium int `json:"medium"`
+			Low    int `json:"low"`
+		} `json:"results"`
+		Rules []struct {
+			ID      string  `json:"rule_id"`
+			Seconds float64 `json:"seconds"`
+		} `json:"rules"`
+	}
+	require.NoError(t, json.Unmarshal(data, &exported))
+	assert.Greater(t, exported.Timings.Total, 0.0)
+	assert.Equal(t, 3, exported.Counts.ModulesProcessed)
+	assert.Equal(t, 18, exported.Results.High+exported.Results.Medium+exported.Results.Low)
+	require.NotEmpty(t, exported.Rules)
+
+	var ids []string
+	for _, rule := range exported.Rules {
+		ids = append(ids, rule.ID)
+	}
+	assert.Contains(t, ids, "aws-s3-enable-bucket-encryption")
+}
+
+func Test_MetricsOut_OpenMetrics(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.prom")
+	_, stderr, exit := runWithArgs("./testdata/group", "--metrics-out", path, "--soft-fail")
+	assert.Equal(t, 0, exit, stderr)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	exported := string(data)
+
+	assert.Contains(t, exported, "# TYPE tfsec_scan_duration_seconds gauge")
+	assert.Regexp(t, `tfsec_scan_duration_seconds\{phase="total"\} \S+`, exported)
+	assert.Contains(t, exported, "tfsec_modules_downloaded 0")
+	assert.Regexp(t, `tfsec_results\{status="failed",severity="HIGH"\} \d+`, exported)
+	assert.Regexp(t, `tfsec_rule_duration_seconds\{rule="aws-s3-enable-bucket-encryption"\} \S+`, exported)
+	assert.Regexp(t, `# EOF\n$`, exported)
+}
+
+func Test_MetricsOut_Watch(t *testing.T) {
+	_, stderr, exit := runWithArgs("./testdata/group", "--metrics-out", filepath.Join(t.TempDir(), "metrics.json"), "--watch")
+	assert.Equal(t, 1, exit)
+	assert.Contains(t, stderr, "--metrics-out cannot be used with --watch")
+}
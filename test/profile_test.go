@@ -0,0 +1,153 @@
+package test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type profileJSON struct {
+	Rules []struct {
+		ID      string  `json:"rule_id"`
+		Seconds float64 `json:"seconds"`
+	} `json:"slowest_rules"`
+	RuleModules []struct {
+		ID      string  `json:"rule_id"`
+		Module  string  `json:"module"`
+		Seconds float64 `json:"seconds"`
+	} `json:"slowest_rule_modules"`
+}
+
+func Test_ProfileRules_Lovely(t *testing.T) {
+	out, stderr, exit := runWithArgs("./testdata/group", "--profile-rules", "--no-colour")
+	assert.Equal(t, 1, exit, stderr)
+
+	results := parseLovely(t, out)
+	require.NotEmpty(t, results)
+	assert.Contains(t, out, "rule profile")
+	assert.Contains(t, out, "slowest rules")
+	assert.Regexp(t, `\d\S*s  aws-\S+`, out)
+}
+
+func Test_ProfileRules_JSON(t *testing.T) {
+	out, stderr, exit := runWithArgs("./testdata/rego/tf", "--rego-policy-dir", "./testdata/rego/policies", "--profile-rules", "--profile-top", "1000", "-f", "json")
+	assert.Equal(t, 1, exit, stderr)
+
+	var report struct {
+		Results []json.RawMessage `json:"results"`
+		Profile profileJSON       `json:"profile"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+	assert.NotEmpty(t, report.Results)
+	require.NotEmpty(t, report.Profile.Rules)
+
+	var ids []string
+	for i, rule := range report.Profile.Rules {
+		ids = append(ids, rule.ID)
+		if i > 0 {
+			assert.LessOrEqual(t, rule.Seconds, report.Profile.Rules[i-1].Seconds)
+		}
+	}
+	assert.Contains(t, ids, "aws-s3-enable-bucket-encryption")
+	// rego policies run together in the scan, so are not timed
+	assert.NotContains(t, ids, "custom.rego.rego.sauce")
+}
+
+func Test_ProfileRules_RegoPoliciesNotTimed(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`resource "aws_db_instance" "db" {}`), 0o600))
+	out, stderr, _ := runWithArgs(dir, "--profile-rules", "--profile-top", "1000", "-f", "json")
+
+	var report struct {
+		Profile profileJSON `json:"profile"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &report), stderr)
+
+	// the embedded rego policies for rds instances run, but are left out of the profile
+	require.NotEmpty(t, report.Profile.Rules)
+	for _, rule := range report.Profile.Rules {
+		assert.NotContains(t, rule.ID, "builtin.aws.rds.")
+	}
+}
+
+func Test_ProfileRules_Top(t *testing.T) {
+	out, stderr, exit := runWithArgs("./testdata/group", "--profile-rules", "--profile-top", "3", "-f", "json")
+	assert.Equal(t, 1, exit, stderr)
+
+	var report struct {
+		Profile profileJSON `json:"profile"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+	assert.Len(t, report.Profile.Rules, 3)
+}
+
+func Test_ProfileRules_CustomCheckModules(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`module "child" {
+  source = "./child"
+}
+
+resource "profiled_special" "root" {
+  ok = false
+}
+`), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "child"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "child", "main.tf"), []byte(`resource "profiled_special" "child" {
+  ok = false
+}
+`), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".tfsec"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".tfsec", "profiled_tfchecks.yaml"), []byte(`
+checks:
+  - code: PRF001
+    description: Special resources must be ok
+    requiredTypes:
+      - resource
+    requiredLabels:
+      - profiled_special
+    severity: HIGH
+    matchSpec:
+      name: ok
+      action: equals
+      value: true
+    errorMessage: Not ok
+`), 0o600))
+
+	out, stderr, exit := runWithArgs(dir, "--profile-rules", "--profile-top", "1000", "-f", "json")
+	assert.Equal(t, 1, exit, stderr)
+
+	var report struct {
+		Results []json.RawMessage `json:"results"`
+		Profile profileJSON       `json:"profile"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+	assert.Len(t, report.Results, 2)
+
+	modules := make(map[string]bool)
+	for _, pair := range report.Profile.RuleModules {
+		if pair.ID == "custom-custom-prf001" {
+			modules[pair.Module] = true
+		}
+	}
+	assert.Equal(t, map[string]bool{"root": true, "module.child": true}, modules)
+}
+
+func Test_CPUProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cpu.pprof")
+	_, stderr, exit := runWithArgs("./testdata/pass", "--cpu-profile", path)
+	assert.Equal(t, 0, exit, stderr)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Greater(t, info.Size(), int64(0))
+}
+
+func Test_ProfileRules_Watch(t *testing.T) {
+	_, stderr, exit := runWithArgs("./testdata/group", "--profile-rules", "--watch")
+	assert.Equal(t, 1, exit)
+	assert.Contains(t, stderr, "--watch cannot be combined with profiling flags")
+}
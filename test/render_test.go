@@ -0,0 +1,85 @@
+package test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeRenderReport scans a module with a failing bucket and writes the results to a JSON report
+func writeRenderReport(t *testing.T, args ...string) (string, string) {
+	dir := t.TempDir()
+	module := filepath.Join(dir, "module")
+	require.NoError(t, os.Mkdir(module, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(module, "main.tf"), []byte(`resource "aws_s3_bucket" "bkt" {
+}
+`), 0o600))
+	out, _, _ := runWithArgs(append([]string{module, "-f", "json", "--no-module-downloads"}, args...)...)
+
+	report := filepath.Join(dir, "results.json")
+	require.NoError(t, os.WriteFile(report, []byte(out), 0o600))
+	return report, module
+}
+
+func Test_Render_MultipleFormats(t *testing.T) {
+	report, _ := writeRenderReport(t)
+	base := filepath.Join(t.TempDir(), "rendered")
+
+	_, stderr, exit := runWithArgs("render", "--input", report, "--format", "sarif,html,markdown", "--out", base)
+	require.Equal(t, 0, exit, stderr)
+	assert.Contains(t, stderr, "3 file(s) written")
+
+	var sarif struct {
+		Runs []struct {
+			Results []struct {
+				RuleID string `json:"ruleId"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(mustRead(t, base+".sarif.json")), &sarif))
+	require.Len(t, sarif.Runs, 1)
+	assert.Len(t, sarif.Runs[0].Results, len(parseJSON(t, mustRead(t, report))))
+
+	assert.Contains(t, mustRead(t, base+".html"), "aws-s3-enable-bucket-encryption")
+	assert.Contains(t, mustRead(t, base+".markdown"), "`aws-s3-enable-bucket-encryption`")
+}
+
+func Test_Render_MissingSource(t *testing.T) {
+	report, module := writeRenderReport(t)
+	require.NoError(t, os.Remove(filepath.Join(module, "main.tf")))
+
+	out, _, exit := runWithArgs("render", "-i", report, "--no-colour")
+	assert.Equal(t, 0, exit)
+	assert.Contains(t, out, "aws-s3-enable-bucket-encryption")
+	assert.Contains(t, out, "Code unavailable")
+	assert.NotContains(t, out, "Failed to render code")
+}
+
+func Test_Render_IncludesPassed(t *testing.T) {
+	report, _ := writeRenderReport(t, "--include-passed")
+	flat := parseJSON(t, mustRead(t, report))
+
+	out, _, exit := runWithArgs("render", "-i", report, "-f", "json")
+	assert.Equal(t, 0, exit)
+	rendered := parseJSON(t, out)
+	require.Len(t, rendered, len(flat))
+	var passed int
+	for i, result := range rendered {
+		assert.Equal(t, flat[i].Status, result.Status)
+		if result.Status == scan.StatusPassed {
+			passed++
+		}
+	}
+	assert.Greater(t, passed, 0)
+}
+
+func Test_Render_RequiresInput(t *testing.T) {
+	_, stderr, exit := runWithArgs("render", "-f", "sarif")
+	assert.Equal(t, 1, exit)
+	assert.Contains(t, stderr, `required flag(s) "input" not set`)
+}
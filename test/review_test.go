@@ -0,0 +1,136 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reviewPayload struct {
+	Tool struct {
+		Name string `json:"name"`
+	} `json:"tool"`
+	Comments []struct {
+		Path       string `json:"path"`
+		Line       int    `json:"line"`
+		StartLine  int    `json:"start_line"`
+		RuleID     string `json:"rule_id"`
+		Body       string `json:"body"`
+		Suggestion string `json:"suggestion"`
+		DedupKey   string `json:"dedup_key"`
+	} `json:"comments"`
+}
+
+func writeUnrotatedKey(t *testing.T) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`variable "region" {}
+
+resource "aws_kms_key" "key" {
+  description = "key"
+}
+`), 0o600))
+	return dir
+}
+
+func Test_Flag_Format_ReviewJSON(t *testing.T) {
+	out, _, exit := runWithArgs(writeUnrotatedKey(t), "-f", "review-json", "--no-module-downloads")
+	assert.Equal(t, 1, exit)
+
+	var payload reviewPayload
+	require.NoError(t, json.Unmarshal([]byte(out), &payload))
+	assert.Equal(t, "tfsec", payload.Tool.Name)
+	require.Len(t, payload.Comments, 1)
+
+	comment := payload.Comments[0]
+	assert.Equal(t, "main.tf", comment.Path)
+	assert.Equal(t, "aws-kms-auto-rotate-keys", comment.RuleID)
+	assert.Equal(t, 3, comment.StartLine)
+	assert.Equal(t, 5, comment.Line)
+	assert.Len(t, comment.DedupKey, 64)
+	assert.Contains(t, comment.Suggestion, "enable_key_rotation = true")
+	assert.Contains(t, comment.Body, "<!-- tfsec:"+comment.DedupKey+" -->")
+	assert.Contains(t, comment.Body, "| `aws-kms-auto-rotate-keys` | *MEDIUM* |")
+	assert.Contains(t, comment.Body, "```suggestion\n"+comment.Suggestion+"\n```")
+}
+
+func Test_Flag_Format_ReviewJSONWithoutFix(t *testing.T) {
+	out, _, _ := runWithArgs("./testdata/fail", "-f", "review-json")
+
+	var payload reviewPayload
+	require.NoError(t, json.Unmarshal([]byte(out), &payload))
+	require.NotEmpty(t, payload.Comments)
+	for _, comment := range payload.Comments {
+		if comment.Suggestion == "" {
+			assert.NotContains(t, comment.Body, "```suggestion")
+		}
+	}
+}
+
+func Test_Flag_Format_ReviewJSONCountInstances(t *testing.T) {
+	out, _, exit := runWithArgs("./testdata/instances", "-f", "review-json")
+	assert.Equal(t, 1, exit)
+
+	var payload reviewPayload
+	require.NoError(t, json.Unmarshal([]byte(out), &payload))
+	require.NotEmpty(t, payload.Comments)
+
+	keys := make(map[string]bool)
+	comments := make(map[string]int)
+	for _, comment := range payload.Comments {
+		assert.False(t, keys[comment.DedupKey], "dedup key %s is used by more than one comment", comment.DedupKey)
+		keys[comment.DedupKey] = true
+		comments[fmt.Sprintf("%s:%d:%s", comment.Path, comment.Line, comment.RuleID)]++
+	}
+	for location, count := range comments {
+		assert.Equal(t, 1, count, "the instances of a counted resource should share a comment at %s", location)
+	}
+}
+
+func Test_Flag_PostTo(t *testing.T) {
+	var received reviewPayload
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		contentType = r.Header.Get("Content-Type")
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	out, stderr, exit := runWithArgs(writeUnrotatedKey(t), "--post-to", server.URL, "--no-module-downloads", "--no-colour")
+	assert.Equal(t, 1, exit)
+	assert.Contains(t, out, "aws-kms-auto-rotate-keys")
+	assert.Equal(t, "application/json", contentType)
+	require.Len(t, received.Comments, 1)
+	assert.Equal(t, "aws-kms-auto-rotate-keys", received.Comments[0].RuleID)
+	assert.Contains(t, stderr, "1 review comment(s) posted to "+server.URL)
+}
+
+func Test_Flag_PostToFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad token", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, stderr, exit := runWithArgs("./testdata/pass", "--post-to", server.URL)
+	assert.Equal(t, 1, exit)
+	assert.Contains(t, stderr, "failed to post review comments")
+	assert.Contains(t, stderr, "401 Unauthorized")
+	assert.Contains(t, stderr, "bad token")
+}
+
+func Test_Flag_PostToWithWatch(t *testing.T) {
+	_, stderr, exit := runWithArgs("./testdata/pass", "--post-to", "http://localhost", "--watch")
+	assert.Equal(t, 1, exit)
+	assert.Contains(t, stderr, "--post-to cannot be combined with --watch")
+}
@@ -0,0 +1,105 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type listedRule struct {
+	ID        string   `json:"id"`
+	LegacyIDs []string `json:"legacy_ids"`
+	Source    string   `json:"source"`
+	Provider  string   `json:"provider"`
+	Service   string   `json:"service"`
+	Severity  string   `json:"severity"`
+	Summary   string   `json:"summary"`
+}
+
+func Test_Rules_List(t *testing.T) {
+	out, stderr, exit := runWithArgs("rules", "list", "--provider", "aws", "--service", "s3", "--no-colour")
+	require.Equal(t, 0, exit, stderr)
+	assert.Contains(t, out, "aws-s3-enable-bucket-encryption")
+	assert.Contains(t, out, "Unencrypted S3 bucket.")
+	assert.NotContains(t, out, "aws-ec2-")
+	assert.Regexp(t, `\d+ rule\(s\)\.`, out)
+}
+
+func Test_Rules_ListJSON(t *testing.T) {
+	out, stderr, exit := runWithArgs("rules", "list", "-f", "json", "--provider", "aws", "--severity", "critical")
+	require.Equal(t, 0, exit, stderr)
+
+	var rules []listedRule
+	require.NoError(t, json.Unmarshal([]byte(out), &rules))
+	require.NotEmpty(t, rules)
+	for _, rule := range rules {
+		assert.Equal(t, "aws", rule.Provider)
+		assert.Equal(t, "CRITICAL", rule.Severity)
+		assert.Equal(t, "builtin", rule.Source)
+	}
+
+	out, _, exit = runWithArgs("rules", "list", "-f", "json", "--provider", "nonexistent")
+	require.Equal(t, 0, exit)
+	assert.Equal(t, "[]", out[:2])
+}
+
+func Test_Rules_ListCustomAndRego(t *testing.T) {
+	out, stderr, exit := runWithArgs("rules", "list", "-f", "json",
+		"--custom-check-dir", "./testdata/custom-sources/org",
+		"--rego-policy-dir", "./testdata/rego/policies",
+	)
+	require.Equal(t, 0, exit, stderr)
+
+	var rules []listedRule
+	require.NoError(t, json.Unmarshal([]byte(out), &rules))
+	sources := make(map[string]string)
+	for _, rule := range rules {
+		sources[rule.ID] = rule.Source
+	}
+	assert.Equal(t, "custom", sources["custom-custom-org001"])
+	assert.Equal(t, "rego", sources["custom.rego.rego.sauce"])
+	assert.Equal(t, "builtin", sources["aws-s3-enable-bucket-encryption"])
+}
+
+func Test_Rules_ListInvalidFormat(t *testing.T) {
+	_, stderr, exit := runWithArgs("rules", "list", "-f", "xml")
+	assert.Equal(t, 1, exit)
+	assert.Contains(t, stderr, "invalid format specified: 'xml'")
+}
+
+func Test_Rules_Explain(t *testing.T) {
+	out, stderr, exit := runWithArgs("rules", "explain", "AWS017", "--no-colour")
+	require.Equal(t, 0, exit, stderr)
+	assert.Contains(t, out, "aws-s3-enable-bucket-encryption")
+	assert.Contains(t, out, "Unencrypted S3 bucket.")
+	assert.Regexp(t, `legacy ids +AWS017`, out)
+	assert.Regexp(t, `avd id +AVD-AWS-0088`, out)
+	assert.Contains(t, out, "insecure example")
+	assert.Contains(t, out, `resource "aws_s3_bucket" "bad_example"`)
+	assert.Contains(t, out, "secure example")
+	assert.Contains(t, out, "https://registry.terraform.io/providers/hashicorp/aws/latest/docs/resources/s3_bucket")
+}
+
+func Test_Rules_ExplainJSON(t *testing.T) {
+	out, stderr, exit := runWithArgs("rules", "explain", "custom-custom-org001", "-f", "json", "--custom-check-dir", "./testdata/custom-sources/org")
+	require.Equal(t, 0, exit, stderr)
+
+	var rule struct {
+		listedRule
+		Impact     string `json:"impact"`
+		Resolution string `json:"resolution"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &rule))
+	assert.Equal(t, "custom", rule.Source)
+	assert.Equal(t, "Special resources must be ok", rule.Summary)
+	assert.Equal(t, "Things are not ok", rule.Impact)
+	assert.Equal(t, "Make things ok", rule.Resolution)
+}
+
+func Test_Rules_ExplainUnknown(t *testing.T) {
+	_, stderr, exit := runWithArgs("rules", "explain", "aws-s3-does-not-exist")
+	assert.Equal(t, 1, exit)
+	assert.Contains(t, stderr, "unknown rule 'aws-s3-does-not-exist'")
+}
@@ -0,0 +1,171 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/owenrumney/go-sarif/v2/sarif"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func findSARIFResult(t *testing.T, report *sarif.Report, ruleID string) *sarif.Result {
+	for _, run := range report.Runs {
+		for _, res := range run.Results {
+			if res.RuleID != nil && *res.RuleID == ruleID {
+				return res
+			}
+		}
+	}
+	t.Fatalf("no SARIF result for %s", ruleID)
+	return nil
+}
+
+func findSARIFRule(t *testing.T, report *sarif.Report, ruleID string) *sarif.ReportingDescriptor {
+	for _, run := range report.Runs {
+		for _, rule := range run.Tool.Driver.Rules {
+			if rule.ID == ruleID {
+				return rule
+			}
+		}
+	}
+	t.Fatalf("no SARIF rule for %s", ruleID)
+	return nil
+}
+
+func Test_Flag_Format_SARIFRuleMetadataAndFixes(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`resource "aws_kms_key" "key" {
+  enable_key_rotation = false
+}
+`), 0o600))
+
+	out, _, _ := runWithArgs(dir, "-f", "sarif", "--no-module-downloads")
+	report, err := sarif.FromString(out)
+	require.NoError(t, err)
+
+	rule := findSARIFRule(t, report, "aws-kms-auto-rotate-keys")
+	require.NotNil(t, rule.Help)
+	require.NotNil(t, rule.Help.Markdown)
+	assert.Contains(t, *rule.Help.Markdown, "**Resolution:**")
+	assert.Contains(t, *rule.Help.Markdown, "https://aquasecurity.github.io/tfsec/")
+	assert.Equal(t, "5.5", rule.Properties["security-severity"])
+
+	res := findSARIFResult(t, report, "aws-kms-auto-rotate-keys")
+	assert.Len(t, res.PartialFingerprints["tfsecResourceRule/v2"], 64)
+	require.Len(t, res.Fixes, 1)
+	change := res.Fixes[0].ArtifactChanges[0]
+	require.Len(t, change.Replacements, 1)
+	assert.Equal(t, 1, *change.Replacements[0].DeletedRegion.StartLine)
+	assert.Contains(t, *change.Replacements[0].InsertedContent.Text, "enable_key_rotation = true")
+}
+
+func Test_Flag_Format_SARIFFingerprintsIgnoreLineChanges(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.tf")
+	resource := `resource "aws_kms_key" "key" {
+  enable_key_rotation = false
+}
+`
+	require.NoError(t, os.WriteFile(path, []byte(resource), 0o600))
+	before, _, _ := runWithArgs(dir, "-f", "sarif", "--no-module-downloads")
+
+	require.NoError(t, os.WriteFile(path, []byte("variable \"region\" {}\n\n"+resource), 0o600))
+	after, _, _ := runWithArgs(dir, "-f", "sarif", "--no-module-downloads")
+
+	beforeReport, err := sarif.FromString(before)
+	require.NoError(t, err)
+	afterReport, err := sarif.FromString(after)
+	require.NoError(t, err)
+
+	beforeResult := findSARIFResult(t, beforeReport, "aws-kms-auto-rotate-keys")
+	afterResult := findSARIFResult(t, afterReport, "aws-kms-auto-rotate-keys")
+	assert.NotEqual(t, *beforeResult.Locations[0].PhysicalLocation.Region.StartLine, *afterResult.Locations[0].PhysicalLocation.Region.StartLine)
+	assert.Equal(t, beforeResult.PartialFingerprints, afterResult.PartialFingerprints)
+}
+
+func Test_Flag_Format_SARIFSuppressions(t *testing.T) {
+	out, _, exit := runWithArgs("./testdata/ignored", "-f", "sarif")
+	assert.Equal(t, 0, exit)
+	report, err := sarif.FromString(out)
+	require.NoError(t, err)
+
+	res := findSARIFResult(t, report, "aws-s3-enable-bucket-encryption")
+	require.Len(t, res.Suppressions, 1)
+	assert.Equal(t, "inSource", res.Suppressions[0].Kind)
+	require.NotNil(t, res.Suppressions[0].Justification)
+	assert.Equal(t, "// tfsec:ignore:*", *res.Suppressions[0].Justification)
+}
+
+func Test_Flag_Format_SARIFFingerprintsUniquePerInstance(t *testing.T) {
+	out, _, _ := runWithArgs("./testdata/instances", "-f", "sarif")
+	report, err := sarif.FromString(out)
+	require.NoError(t, err)
+
+	fingerprints := make(map[interface{}]int)
+	var total int
+	for _, run := range report.Runs {
+		for _, res := range run.Results {
+			fingerprints[res.PartialFingerprints["tfsecResourceRule/v2"]]++
+			total++
+		}
+	}
+	require.NotZero(t, total)
+	assert.Len(t, fingerprints, total, "each count and for_each instance should have its own fingerprint")
+}
+
+func Test_Flag_Format_SARIFSuppressionsUniquePerInstance(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`resource "aws_kms_key" "key" {
+  count = 3
+  #tfsec:ignore:aws-kms-auto-rotate-keys
+  enable_key_rotation = false
+}
+`), 0o600))
+
+	out, _, _ := runWithArgs(dir, "-f", "sarif", "--no-module-downloads")
+	report, err := sarif.FromString(out)
+	require.NoError(t, err)
+
+	guids := make(map[string]int)
+	for _, run := range report.Runs {
+		for _, res := range run.Results {
+			for _, suppression := range res.Suppressions {
+				require.NotNil(t, suppression.Guid)
+				guids[*suppression.Guid]++
+			}
+		}
+	}
+	assert.Len(t, guids, 3)
+}
+
+func Test_Flag_Format_SARIFFilteredResultsAreNotInSourceSuppressions(t *testing.T) {
+	out, _, _ := runWithArgs("./testdata/fail", "-f", "sarif", "--minimum-severity", "CRITICAL")
+	report, err := sarif.FromString(out)
+	require.NoError(t, err)
+
+	for _, run := range report.Runs {
+		for _, res := range run.Results {
+			assert.Empty(t, res.Suppressions, "%s was hidden by --minimum-severity, not by an ignore comment", *res.RuleID)
+		}
+	}
+}
+
+func Test_Flag_Format_SARIFSuppressionsNeedAMatchingComment(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`#tfsec:ignore:aws-s3-enable-versioning
+resource "aws_kms_key" "key" {
+  enable_key_rotation = false
+}
+`), 0o600))
+
+	out, _, _ := runWithArgs(dir, "-f", "sarif", "--no-module-downloads", "--exclude", "aws-kms-auto-rotate-keys")
+	report, err := sarif.FromString(out)
+	require.NoError(t, err)
+	for _, run := range report.Runs {
+		for _, res := range run.Results {
+			assert.NotEqual(t, "aws-kms-auto-rotate-keys", *res.RuleID, "excluded results have no ignore comment to justify them")
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/aquasecurity/tfsec/internal/app/tfsec/cmd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startServer(t *testing.T, args ...string) string {
+	ctx, cancel := context.WithCancel(context.Background())
+	stderr := &syncBuffer{}
+	rootCmd := cmd.Root()
+	rootCmd.SetOut(io.Discard)
+	rootCmd.SetErr(stderr)
+	rootCmd.SetArgs(append([]string{"serve", "--listen", "127.0.0.1:0"}, args...))
+
+	done := make(chan error)
+	go func() {
+		done <- rootCmd.ExecuteContext(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	listening := regexp.MustCompile(`Listening on (http://\S+)`)
+	var url string
+	require.Eventually(t, func() bool {
+		if match := listening.FindStringSubmatch(stderr.String()); match != nil {
+			url = match[1]
+			return true
+		}
+		return false
+	}, 10*time.Second, 20*time.Millisecond)
+	return url
+}
+
+func postScan(t *testing.T, url string, request map[string]interface{}) (int, string) {
+	body, err := json.Marshal(request)
+	require.NoError(t, err)
+	resp, err := http.Post(url+"/v1/scan", "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(data)
+}
+
+func Test_Serve_ScanPath(t *testing.T) {
+	url := startServer(t, "--allow-path", "./testdata")
+
+	status, body := postScan(t, url, map[string]interface{}{
+		"path": mustAbs(t, "./testdata/fail"),
+	})
+	require.Equal(t, http.StatusOK, status, body)
+	assert.Greater(t, len(parseJSON(t, body)), 0)
+
+	status, body = postScan(t, url, map[string]interface{}{
+		"path":   mustAbs(t, "./testdata/fail"),
+		"format": "csv",
+		"config": map[string]interface{}{
+			"minimum_severity": "CRITICAL",
+		},
+	})
+	require.Equal(t, http.StatusOK, status, body)
+	assert.Len(t, parseCSV(t, body), 0)
+}
+
+func Test_Serve_ScanPathNotAllowed(t *testing.T) {
+	url := startServer(t, "--allow-path", "./testdata/pass")
+
+	status, body := postScan(t, url, map[string]interface{}{
+		"path": mustAbs(t, "./testdata/fail"),
+	})
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, body, "is not allowed")
+}
+
+func Test_Serve_InvalidConfig(t *testing.T) {
+	url := startServer(t, "--allow-path", "./testdata")
+
+	status, body := postScan(t, url, map[string]interface{}{
+		"path": mustAbs(t, "./testdata/fail"),
+		"config": map[string]interface{}{
+			"minimum_severity": "SEVERE",
+		},
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, body, "not a valid severity")
+}
+
+func Test_Serve_ScanTarballWithCustomChecks(t *testing.T) {
+	url := startServer(t, "--allow-path", "./testdata")
+
+	main, err := os.ReadFile("./testdata/custom-sources/main.tf")
+	require.NoError(t, err)
+	tarball, _ := writeBundle(t, map[string]string{"main.tf": string(main)})
+	data, err := os.ReadFile(tarball)
+	require.NoError(t, err)
+
+	request, err := json.Marshal(map[string]interface{}{
+		"custom_checks": []map[string]interface{}{
+			{
+				"code":           "SRV001",
+				"description":    "Special resources must be ok",
+				"requiredTypes":  []string{"resource"},
+				"requiredLabels": []string{"special"},
+				"severity":       "HIGH",
+				"matchSpec": map[string]interface{}{
+					"name":   "ok",
+					"action": "equals",
+					"value":  true,
+				},
+				"errorMessage": "Not ok",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	body := bytes.NewBuffer(nil)
+	form := multipart.NewWriter(body)
+	require.NoError(t, form.WriteField("request", string(request)))
+	part, err := form.CreateFormFile("tarball", "src.tar.gz")
+	require.NoError(t, err)
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	resp, err := http.Post(url+"/v1/scan", form.FormDataContentType(), body)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	output, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(output))
+	assertResultsContain(t, parseJSON(t, string(output)), "custom-custom-srv001")
+
+	// the custom check only applies to the request which supplied it
+	status, plain := postScan(t, url, map[string]interface{}{
+		"path": mustAbs(t, "./testdata/custom-sources"),
+	})
+	require.Equal(t, http.StatusOK, status, plain)
+	assertResultsNotContain(t, parseJSON(t, plain), "custom-custom-srv001")
+}
+
+func Test_Serve_LimitsCustomCheckDefinitions(t *testing.T) {
+	url := startServer(t, "--allow-path", "./testdata", "--max-custom-checks", "1")
+
+	check := func(code string) map[string]interface{} {
+		return map[string]interface{}{
+			"path": mustAbs(t, "./testdata/custom-sources"),
+			"custom_checks": []map[string]interface{}{
+				{
+					"code":           code,
+					"description":    "Special resources must be ok",
+					"requiredTypes":  []string{"resource"},
+					"requiredLabels": []string{"special"},
+					"severity":       "HIGH",
+					"matchSpec": map[string]interface{}{
+						"name":   "ok",
+						"action": "equals",
+						"value":  true,
+					},
+					"errorMessage": "Not ok",
+				},
+			},
+		}
+	}
+
+	status, body := postScan(t, url, check("SRV002"))
+	require.Equal(t, http.StatusOK, status, body)
+	assertResultsContain(t, parseJSON(t, body), "custom-custom-srv002")
+
+	// sending the same check again re-uses its rule
+	status, body = postScan(t, url, check("SRV002"))
+	require.Equal(t, http.StatusOK, status, body)
+	assertResultsContain(t, parseJSON(t, body), "custom-custom-srv002")
+
+	status, body = postScan(t, url, check("SRV003"))
+	assert.Equal(t, http.StatusInsufficientStorage, status)
+	assert.Contains(t, body, "--max-custom-checks")
+}
+
+func Test_Serve_HealthAndMetrics(t *testing.T) {
+	url := startServer(t, "--allow-path", "./testdata")
+
+	resp, err := http.Get(url + "/healthz")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	status, body := postScan(t, url, map[string]interface{}{
+		"path": mustAbs(t, "./testdata/fail"),
+	})
+	require.Equal(t, http.StatusOK, status, body)
+
+	resp, err = http.Get(url + "/metrics")
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	metrics, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(metrics), `tfsec_scans_total{outcome="success"} 1`)
+	assert.Contains(t, string(metrics), "tfsec_scan_duration_seconds_count 1")
+}
@@ -1,12 +1,18 @@
 package test
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
 	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -39,6 +45,35 @@ func runWithArgs(args ...string) (stdout string, stderr string, exit int) {
 	return sOut.String(), sErr.String(), exit
 }
 
+func mustAbs(t *testing.T, path string) string {
+	abs, err := filepath.Abs(path)
+	require.NoError(t, err)
+	return abs
+}
+
+func writeBundle(t *testing.T, files map[string]string) (path string, digest string) {
+	buffer := bytes.NewBuffer(nil)
+	gz := gzip.NewWriter(buffer)
+	archive := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, archive.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o600,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := archive.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, archive.Close())
+	require.NoError(t, gz.Close())
+
+	path = filepath.Join(t.TempDir(), "bundle.tar.gz")
+	require.NoError(t, os.WriteFile(path, buffer.Bytes(), 0o600))
+	sum := sha256.Sum256(buffer.Bytes())
+	return path, hex.EncodeToString(sum[:])
+}
+
 func parseJSON(t *testing.T, data string) []scan.FlatResult {
 	jsonResults := struct {
 		Results []scan.FlatResult `json:"results"`
@@ -0,0 +1,84 @@
+package test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type statisticsJSON struct {
+	Total int `json:"total"`
+	Rules []struct {
+		ID    string `json:"rule_id"`
+		Count int    `json:"count"`
+	} `json:"rules"`
+	Modules []struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	} `json:"modules"`
+	Files []struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	} `json:"files"`
+}
+
+func Test_Statistics_Lovely(t *testing.T) {
+	out, stderr, exit := runWithArgs("./testdata/group", "--run-statistics", "--no-colour")
+	assert.Equal(t, 1, exit, stderr)
+
+	results := parseLovely(t, out)
+	require.NotEmpty(t, results)
+	assert.Contains(t, out, "statistics")
+	assert.Regexp(t, `failures +18`, out)
+	assert.Regexp(t, `\d+  aws-s3-enable-bucket-encryption`, out)
+	assert.Regexp(t, `\d+  aws/s3`, out)
+	assert.Regexp(t, `18  \.\./fail/main\.tf`, out)
+	assert.Regexp(t, `9  module\.fail1`, out)
+	assert.Regexp(t, `9  module\.fail2`, out)
+}
+
+func Test_Statistics_JSON(t *testing.T) {
+	out, stderr, exit := runWithArgs("./testdata/group", "--run-statistics", "-f", "json")
+	assert.Equal(t, 1, exit, stderr)
+
+	var report struct {
+		Results    []json.RawMessage `json:"results"`
+		Statistics statisticsJSON    `json:"statistics"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+	require.NotEmpty(t, report.Results)
+	assert.Equal(t, len(report.Results), report.Statistics.Total)
+	require.Len(t, report.Statistics.Modules, 2)
+	assert.Equal(t, "module.fail1", report.Statistics.Modules[0].Name)
+	assert.Equal(t, "module.fail2", report.Statistics.Modules[1].Name)
+}
+
+func Test_Statistics_Files(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "results")
+	out, stderr, exit := runWithArgs("./testdata/group", "-f", "lovely,statistics-json,statistics-csv", "--out", base, "--no-colour")
+	assert.Equal(t, 1, exit, stderr)
+	assert.NotEmpty(t, parseLovely(t, out))
+	assert.Contains(t, stderr, "3 file(s) written")
+
+	content, err := os.ReadFile(base + ".statistics.json")
+	require.NoError(t, err)
+	var report statisticsJSON
+	require.NoError(t, json.Unmarshal(content, &report))
+	assert.Greater(t, report.Total, 0)
+	require.Len(t, report.Files, 1)
+	assert.Equal(t, "../fail/main.tf", report.Files[0].Name)
+
+	f, err := os.Open(base + ".statistics.csv")
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	rows, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"category", "name", "count"}, rows[0])
+	assert.Contains(t, rows, []string{"provider", "aws", "18"})
+	assert.Contains(t, rows, []string{"module", "module.fail1", "9"})
+}
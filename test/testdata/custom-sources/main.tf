@@ -0,0 +1,3 @@
+resource "special" "fail" {
+  ok = false
+}
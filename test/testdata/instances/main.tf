@@ -0,0 +1,15 @@
+resource "aws_s3_bucket" "counted" {
+  count  = 3
+  bucket = "counted-${count.index}"
+}
+
+resource "aws_s3_bucket" "keyed" {
+  for_each = toset(["logs", "assets"])
+  bucket   = each.key
+}
+
+module "buckets" {
+  source   = "./module"
+  for_each = toset(["a", "b"])
+  name     = each.key
+}
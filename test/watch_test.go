@@ -0,0 +1,196 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aquasecurity/tfsec/internal/app/tfsec/cmd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type syncBuffer struct {
+	sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.Lock()
+	defer b.Unlock()
+	return b.buffer.String()
+}
+
+func Test_Flag_Watch(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`variable "region" {}`), 0o600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stdout := &syncBuffer{}
+	stderr := &syncBuffer{}
+	rootCmd := cmd.Root()
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(stderr)
+	rootCmd.SetArgs([]string{dir, "--watch", "--no-colour"})
+
+	done := make(chan error)
+	go func() {
+		done <- rootCmd.ExecuteContext(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(stderr.String(), "Watching")
+	}, 30*time.Second, 50*time.Millisecond)
+	assert.Contains(t, stdout.String(), "No problems detected!")
+
+	bucket := filepath.Join(dir, "bucket.tf")
+	require.NoError(t, os.WriteFile(bucket, []byte(`resource "aws_s3_bucket" "logs" {}`), 0o600))
+	require.Eventually(t, func() bool {
+		return strings.Contains(stdout.String(), "+ [") && strings.Contains(stdout.String(), "aws_s3_bucket.logs")
+	}, 30*time.Second, 50*time.Millisecond)
+
+	require.NoError(t, os.Remove(bucket))
+	require.Eventually(t, func() bool {
+		return strings.Contains(stdout.String(), "- [")
+	}, 30*time.Second, 50*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(30 * time.Second):
+		t.Fatal("watch did not stop when its context was cancelled")
+	}
+}
+
+func Test_Flag_WatchRejectsOut(t *testing.T) {
+	_, err, exit := runWithArgs("./testdata/pass", "--watch", "--out", filepath.Join(t.TempDir(), "results"))
+	assert.Equal(t, 1, exit)
+	assert.Contains(t, err, "--out cannot be used with --watch")
+}
+
+func Test_Flag_WatchRescansChangedModule(t *testing.T) {
+	dir := t.TempDir()
+	for _, module := range []string{"network", "storage"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, module), 0o700))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "network", "main.tf"), []byte(`variable "region" {}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "storage", "main.tf"), []byte(`resource "aws_s3_bucket" "assets" {}`), 0o600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stdout := &syncBuffer{}
+	stderr := &syncBuffer{}
+	rootCmd := cmd.Root()
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(stderr)
+	rootCmd.SetArgs([]string{dir, "--watch", "--no-colour"})
+
+	done := make(chan error)
+	go func() {
+		done <- rootCmd.ExecuteContext(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(stderr.String(), "Watching")
+	}, 30*time.Second, 50*time.Millisecond)
+
+	// each instance of a counted resource is a separate finding
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "network", "web.tf"), []byte(`
+resource "aws_instance" "web" {
+  count = 2
+  root_block_device {
+    encrypted = false
+  }
+}
+`), 0o600))
+	require.Eventually(t, func() bool {
+		return strings.Contains(stdout.String(), "Changes since the previous scan")
+	}, 30*time.Second, 50*time.Millisecond)
+
+	assert.Contains(t, stderr.String(), "Rescanning network...")
+	changes := stdout.String()[strings.Index(stdout.String(), "Changes since the previous scan"):]
+	assert.NotContains(t, changes, "- [", "the findings of the module which was not rescanned are kept")
+	assert.NotContains(t, changes, "aws_s3_bucket.assets")
+	assert.Contains(t, changes, "(root_block_device.encrypted in aws_instance.web[1])")
+	assert.Equal(t, 2, strings.Count(changes, "+ [HIGH] aws-ec2-enable-at-rest-encryption"), changes)
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(30 * time.Second):
+		t.Fatal("watch did not stop when its context was cancelled")
+	}
+}
+
+func Test_Flag_WatchRescansCallersOfCleanModule(t *testing.T) {
+	dir := t.TempDir()
+	for _, module := range []string{"app", "other", filepath.Join("modules", "bucket")} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, module), 0o700))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app", "main.tf"), []byte(`
+module "bucket" {
+  source = "../modules/bucket"
+}
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other", "main.tf"), []byte(`variable "region" {}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "modules", "bucket", "main.tf"), []byte(`variable "name" {}`), 0o600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stdout := &syncBuffer{}
+	stderr := &syncBuffer{}
+	rootCmd := cmd.Root()
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(stderr)
+	rootCmd.SetArgs([]string{dir, "--watch", "--no-colour"})
+
+	done := make(chan error)
+	go func() {
+		done <- rootCmd.ExecuteContext(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(stderr.String(), "Watching")
+	}, 30*time.Second, 50*time.Millisecond)
+
+	// the module had no findings, so only its module block ties it to the module calling it
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "modules", "bucket", "main.tf"), []byte(`
+variable "name" {}
+
+resource "aws_s3_bucket" "this" {
+  bucket = var.name
+}
+`), 0o600))
+	require.Eventually(t, func() bool {
+		return strings.Contains(stdout.String(), "Changes since the previous scan")
+	}, 30*time.Second, 50*time.Millisecond)
+
+	assert.Contains(t, stderr.String(), "Rescanning app...")
+	changes := stdout.String()[strings.Index(stdout.String(), "Changes since the previous scan"):]
+	assert.Contains(t, changes, "+ [HIGH] aws-s3-enable-bucket-encryption")
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(30 * time.Second):
+		t.Fatal("watch did not stop when its context was cancelled")
+	}
+}